@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// command 命令行子命令
+type command struct {
+	name    string
+	summary string
+	run     func(logger *slog.Logger, args []string) int
+}
+
+// cliCommands 所有子命令，不带子命令时执行 serve
+func cliCommands() []command {
+	return []command{
+		{"serve", "启动 HTTP 服务（默认）", runServe},
+		{"migrate", "执行、回滚数据库迁移或查看迁移状态", runMigrate},
+		{"seed", "初始化商品和供应商数据", runSeed},
+		{"import-products", "从 JSON、CSV 或 Excel 文件同步商品目录", runImportProducts},
+		{"export-orders", "导出订单明细（CSV 或 Excel）", runExportOrders},
+		{"backup", "在线备份 SQLite 数据库", runBackup},
+		{"restore", "从备份文件恢复 SQLite 数据库（需先停止服务）", runRestore},
+		{"create-user", "创建用户并设置角色", runCreateUser},
+		{"issue-token", "为用户重新生成访问令牌", runIssueToken},
+	}
+}
+
+// runCommand 按第一个参数分发子命令，返回进程退出码
+func runCommand(logger *slog.Logger, args []string) int {
+	if len(args) == 0 {
+		return runServe(logger, nil)
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	for _, cmd := range cliCommands() {
+		if cmd.name == name {
+			return cmd.run(logger, args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+	printUsage(os.Stderr)
+	return 2
+}
+
+// printUsage 输出子命令列表
+func printUsage(w *os.File) {
+	var b strings.Builder
+	b.WriteString("用法: purches-backend <命令> [参数]\n\n命令:\n")
+	for _, cmd := range cliCommands() {
+		fmt.Fprintf(&b, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\n使用 purches-backend <命令> -h 查看命令参数")
+	fmt.Fprintln(w, b.String())
+}
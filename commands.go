@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/models"
+	"purches-backend/services"
+	"strings"
+	"time"
+)
+
+// newFlagSet 创建子命令参数解析器，usage 为参数之外的用法说明
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: purches-backend %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，返回 false 时以 code 退出
+func parseFlags(fs *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0, false
+		}
+		return 2, false
+	}
+	return 0, true
+}
+
+// prepareDatabase 连接数据库并检查迁移，失败时输出错误并释放连接。
+// 成功时调用方需要 defer closeDatabase()
+func prepareDatabase(logger *slog.Logger) bool {
+	if err := database.Prepare(logger); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		closeDatabase()
+		return false
+	}
+	return true
+}
+
+// connectDatabase 只连接数据库，不检查迁移，失败时输出错误并释放文件锁。
+// 成功时调用方需要 defer closeDatabase()
+func connectDatabase(logger *slog.Logger) bool {
+	if err := database.Connect(logger); err != nil {
+		fmt.Fprintf(os.Stderr, "连接数据库失败: %v\n", err)
+		closeDatabase()
+		return false
+	}
+	return true
+}
+
+// closeDatabase 关闭数据库连接并释放 SQLite 文件锁，命令结束前调用，
+// 之后 restore 等需要独占数据库的命令才能执行
+func closeDatabase() {
+	if err := database.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "关闭数据库失败: %v\n", err)
+	}
+}
+
+// runSeed 初始化商品和供应商数据
+func runSeed(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("seed", "[-reset [-force]]")
+	reset := fs.Bool("reset", false, "清空所有数据后重新初始化（会删除订单等全部业务数据）")
+	force := fs.Bool("force", false, "允许在生产环境执行 -reset")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if *reset && config.GetConfig().App.IsProduction() && !*force {
+		fmt.Fprintln(os.Stderr, "生产环境不允许清空数据，确认要删除全部数据时加上 -force")
+		return 1
+	}
+
+	if !prepareDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+
+	if *reset {
+		if err := database.ResetData(); err != nil {
+			fmt.Fprintf(os.Stderr, "重置数据失败: %v\n", err)
+			return 1
+		}
+	} else {
+		database.SeedData()
+	}
+	return 0
+}
+
+// runImportProducts 从文件同步商品目录，与 API 导入使用相同的同步规则
+func runImportProducts(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("import-products", "[-dry-run] [-discontinue-missing] [-new-suppliers 供应商1,供应商2] <文件>")
+	dryRun := fs.Bool("dry-run", false, "只预览变化，不写入数据库")
+	newSuppliers := fs.String("new-suppliers", "", "确认新建的供应商，逗号分隔（CSV 和 Excel 文件中的未知供应商需要确认）")
+	discontinueMissing := fs.Bool("discontinue-missing", false, "停售文件中没有的商品（默认保留）")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	filename := fs.Arg(0)
+
+	if !prepareDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+	productService := services.NewProductService(database.DB, logger)
+
+	var summary *models.ProductSyncSummary
+	if strings.HasSuffix(strings.ToLower(filename), ".json") {
+		jsonProducts, err := database.LoadProductsFromJSON(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法读取JSON文件: %v\n", err)
+			return 1
+		}
+		items := make([]models.ImportProduct, 0, len(jsonProducts))
+		for _, jsonProduct := range jsonProducts {
+			items = append(items, database.ToImportProduct(jsonProduct))
+		}
+		summary, err = productService.SyncProducts(items, *discontinueMissing, *dryRun)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		format := services.ImportFileFormat(filename)
+		if format == "" {
+			fmt.Fprintln(os.Stderr, "只支持 .json、.csv 和 .xlsx 文件")
+			return 2
+		}
+		file, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+
+		var confirmed []string
+		if *newSuppliers != "" {
+			confirmed = strings.Split(*newSuppliers, ",")
+		}
+		report, err := productService.ImportProductsFile(file, format, nil, confirmed, *dryRun, *discontinueMissing)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, rowErr := range report.Errors {
+			fmt.Fprintf(os.Stderr, "第 %d 行: %s\n", rowErr.Row, rowErr.Message)
+		}
+		if len(report.Errors) > 0 {
+			if len(report.NewSuppliers) > 0 {
+				fmt.Fprintf(os.Stderr, "文件中的新供应商: %s（确认新建时用 -new-suppliers 列出）\n", strings.Join(report.NewSuppliers, ","))
+			}
+			fmt.Fprintf(os.Stderr, "%d 行有错误，未导入\n", len(report.Errors))
+			return 1
+		}
+		summary = report.Summary
+	}
+
+	for _, change := range summary.Changes {
+		detail := strings.Join(change.Fields, ",")
+		if change.Reason != "" {
+			detail = change.Reason
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", change.Action, change.Supplier, change.Name, detail)
+	}
+	prefix := ""
+	if *dryRun {
+		prefix = "（预览）"
+	}
+	if len(summary.NewSuppliers) > 0 {
+		fmt.Printf("%s新建供应商: %s\n", prefix, strings.Join(summary.NewSuppliers, ","))
+	}
+	fmt.Printf("%s新增 %d 种，更新 %d 种，停售 %d 种，未变化 %d 种，跳过已归档 %d 种\n",
+		prefix, summary.Added, summary.Updated, summary.Discontinued, summary.Unchanged, summary.Skipped)
+	return 0
+}
+
+// runExportOrders 导出订单明细到文件
+func runExportOrders(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("export-orders", "[-format csv|excel] [-from 日期] [-to 日期] [-supplier 供应商] [-store 门店] [-o 文件]")
+	var req models.ExportOrdersRequest
+	fs.StringVar(&req.Format, "format", "csv", "导出格式: csv 或 excel")
+	fs.StringVar(&req.DateFrom, "from", "", "开始日期 YYYY-MM-DD")
+	fs.StringVar(&req.DateTo, "to", "", "结束日期 YYYY-MM-DD（包含当天）")
+	fs.StringVar(&req.Supplier, "supplier", "", "供应商")
+	fs.StringVar(&req.Store, "store", "", "门店")
+	output := fs.String("o", "", "输出文件，默认为 orders_<时间>.<扩展名>")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if req.Format != "csv" && req.Format != "excel" {
+		fmt.Fprintln(os.Stderr, "导出格式应为 csv 或 excel")
+		return 2
+	}
+
+	if !prepareDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+	orderService := services.NewOrderService(database.DB, logger)
+	if err := orderService.ValidateOrderExport(req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	dest := *output
+	if dest == "" {
+		_, ext := services.ExportFileInfo(req.Format)
+		dest = fmt.Sprintf("orders_%s.%s", time.Now().Format("20060102150405"), ext)
+	}
+	file, err := os.Create(dest)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := orderService.WriteOrderExport(writer, req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := writer.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("已导出到 %s\n", dest)
+	return 0
+}
+
+// runBackup 在线备份 SQLite 数据库，服务运行时也可以执行
+func runBackup(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("backup", "[-o 文件]")
+	output := fs.String("o", "", "备份到指定文件；默认在 backup.dir 下创建带时间戳的备份并按 backup.retention 清理旧备份")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if !connectDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+
+	dest := *output
+	if dest == "" {
+		cfg := config.GetConfig().Backup
+		backup, err := database.Snapshot(database.DB, cfg.Dir, cfg.Retention)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "备份失败: %v\n", err)
+			return 1
+		}
+		dest = backup.Path
+	} else if err := database.Backup(database.DB, dest); err != nil {
+		fmt.Fprintf(os.Stderr, "备份失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("已备份到 %s\n", dest)
+	return 0
+}
+
+// runRestore 校验备份后替换数据库文件，可以指定备份文件或恢复到某个时间点之前最近的备份
+func runRestore(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("restore", "[-list] [-at 时间] [备份文件]")
+	list := fs.Bool("list", false, "列出 backup.dir 下的备份")
+	at := fs.String("at", "", "恢复到该时间之前最近的备份，格式 YYYY-MM-DD 或 \"YYYY-MM-DD HH:MM\"")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	dir := config.GetConfig().Backup.Dir
+	if *list {
+		backups, err := database.ListBackups(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, backup := range backups {
+			fmt.Printf("%s\t%s\t%d KB\n", backup.Time.Format("2006-01-02 15:04:05"), backup.Path, backup.Size/1024)
+		}
+		if len(backups) == 0 {
+			fmt.Printf("%s 下没有备份\n", dir)
+		}
+		return 0
+	}
+
+	var src string
+	switch {
+	case *at != "" && fs.NArg() == 0:
+		target, err := parseRestoreTime(*at)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		backup, err := database.FindBackup(dir, target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v: %s 之前\n", err, *at)
+			return 1
+		}
+		src = backup.Path
+	case *at == "" && fs.NArg() == 1:
+		src = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	cfg := config.GetConfig().Database
+	previous, err := database.Restore(cfg, src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "恢复失败: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("已从 %s 恢复数据库 %s\n", src, config.DatabaseDSN(cfg))
+	if previous != "" {
+		fmt.Printf("恢复前的数据库已保存为 %s\n", previous)
+	}
+	return 0
+}
+
+// parseRestoreTime 解析恢复时间点，只有日期时表示当天结束
+func parseRestoreTime(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间格式错误，应为 YYYY-MM-DD 或 \"YYYY-MM-DD HH:MM\": %s", value)
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// runCreateUser 创建用户，用于初始化店主和管理员账号
+func runCreateUser(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("create-user", "-openid <openId> [-name 昵称] [-role buyer|chef|owner|admin]")
+	var req models.CreateUserRequest
+	fs.StringVar(&req.OpenID, "openid", "", "微信 openId（必填）")
+	fs.StringVar(&req.NickName, "name", "", "昵称")
+	fs.StringVar(&req.Role, "role", "buyer", "角色: buyer、chef、owner 或 admin")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if req.OpenID == "" {
+		fs.Usage()
+		return 2
+	}
+
+	if !prepareDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+
+	user, err := services.NewApprovalService(database.DB, logger).CreateUser(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	token, err := services.NewApprovalService(database.DB, logger).IssueToken(user.OpenID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("已创建用户 %s（ID %d，角色 %s）\n", user.OpenID, user.ID, user.Role)
+	fmt.Printf("访问令牌: %s（只显示一次，审批等接口通过 Authorization: Bearer <令牌> 识别用户）\n", token)
+	return 0
+}
+
+// runIssueToken 为用户重新生成访问令牌，旧令牌失效
+func runIssueToken(logger *slog.Logger, args []string) int {
+	fs := newFlagSet("issue-token", "-openid <openId>")
+	openID := fs.String("openid", "", "微信 openId（必填）")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *openID == "" {
+		fs.Usage()
+		return 2
+	}
+
+	if !prepareDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+
+	token, err := services.NewApprovalService(database.DB, logger).IssueToken(*openID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("访问令牌: %s（只显示一次，旧令牌已失效）\n", token)
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestConfig 让命令使用临时目录中的 SQLite 数据库和备份目录，测试结束后恢复原配置
+func useTestConfig(t *testing.T, environment string) string {
+	dir := t.TempDir()
+	cfg := config.GetConfig()
+	database, app, backup := cfg.Database, cfg.App, cfg.Backup
+	t.Cleanup(func() {
+		cfg.Database, cfg.App, cfg.Backup = database, app, backup
+	})
+
+	cfg.Database = config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(dir, "purches.db"), MaxOpenConns: 5, MaxIdleConns: 2}
+	cfg.App.Environment = environment
+	cfg.Backup.Dir = filepath.Join(dir, "backups")
+	cfg.Backup.Retention = 5
+	return dir
+}
+
+// assertDatabaseClosed 断言命令结束后数据库连接已关闭
+func assertDatabaseClosed(t *testing.T) {
+	sqlDB, err := database.DB.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping(), "命令结束后应关闭数据库")
+}
+
+func TestRunSeed(t *testing.T) {
+	logger := slog.Default()
+
+	t.Run("生产环境不加 -force 时拒绝 -reset 且不连接数据库", func(t *testing.T) {
+		dir := useTestConfig(t, "production")
+
+		assert.Equal(t, 1, runSeed(logger, []string{"-reset"}))
+		_, err := os.Stat(filepath.Join(dir, "purches.db"))
+		assert.True(t, os.IsNotExist(err), "被拒绝的 -reset 不应创建数据库文件")
+	})
+
+	t.Run("生产环境加 -force 时清空并重新初始化", func(t *testing.T) {
+		useTestConfig(t, "production")
+		require.Equal(t, 0, runMigrate(logger, []string{"up"}))
+		assertDatabaseClosed(t)
+
+		require.Equal(t, 0, runSeed(logger, []string{"-reset", "-force"}))
+		assertDatabaseClosed(t)
+
+		db, err := database.Open(config.GetConfig().Database)
+		require.NoError(t, err)
+		var count int64
+		require.NoError(t, db.Model(&models.Product{}).Count(&count).Error)
+		assert.Positive(t, count)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	})
+
+	t.Run("非法参数", func(t *testing.T) {
+		useTestConfig(t, "development")
+		assert.Equal(t, 2, runSeed(logger, []string{"-unknown"}))
+	})
+}
+
+func TestRunRestore(t *testing.T) {
+	logger := slog.Default()
+
+	t.Run("参数检查", func(t *testing.T) {
+		dir := useTestConfig(t, "development")
+		backup := filepath.Join(dir, "backup.db")
+
+		tests := []struct {
+			name string
+			args []string
+			want int
+		}{
+			{"没有备份文件也没有 -at", nil, 2},
+			{"同时指定 -at 和备份文件", []string{"-at", "2026-01-02", backup}, 2},
+			{"多个备份文件", []string{backup, backup}, 2},
+			{"-at 格式错误", []string{"-at", "02/01/2026"}, 2},
+			{"-at 之前没有备份", []string{"-at", "2026-01-02"}, 1},
+			{"备份文件不存在", []string{filepath.Join(dir, "missing.db")}, 1},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.want, runRestore(logger, tt.args))
+			})
+		}
+		_, err := os.Stat(filepath.Join(dir, "purches.db"))
+		assert.True(t, os.IsNotExist(err), "参数错误时不应创建数据库文件")
+	})
+
+	t.Run("其他命令结束后释放数据库锁，可以恢复备份", func(t *testing.T) {
+		dir := useTestConfig(t, "development")
+		backup := filepath.Join(dir, "backup.db")
+
+		require.Equal(t, 0, runMigrate(logger, []string{"up"}))
+		require.Equal(t, 0, runSeed(logger, nil))
+		require.Equal(t, 0, runBackup(logger, []string{"-o", backup}))
+		assertDatabaseClosed(t)
+
+		assert.Equal(t, 0, runRestore(logger, []string{backup}))
+	})
+}
@@ -10,16 +10,21 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	App      AppConfig      `mapstructure:"app"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	App       AppConfig       `mapstructure:"app"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	Backup    BackupConfig    `mapstructure:"backup"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Health    HealthConfig    `mapstructure:"health"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port         string `mapstructure:"port"`
-	ReadTimeout  int    `mapstructure:"read_timeout"`
-	WriteTimeout int    `mapstructure:"write_timeout"`
+	Port            string `mapstructure:"port"`
+	ReadTimeout     int    `mapstructure:"read_timeout"`     // 读取请求超时（秒）
+	WriteTimeout    int    `mapstructure:"write_timeout"`    // 写入响应超时（秒）
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // 退出时等待进行中请求完成的最长时间（秒）
 }
 
 // DatabaseConfig 数据库配置
@@ -32,15 +37,53 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	SSLMode  string `mapstructure:"ssl_mode"`
 	FilePath string `mapstructure:"file_path"` // SQLite文件路径
+	DSN      string `mapstructure:"dsn"`       // 完整连接字符串，设置后忽略以上连接参数
+
+	// 连接池
+	MaxOpenConns    int `mapstructure:"max_open_conns"`    // 最大连接数，0 表示不限制
+	MaxIdleConns    int `mapstructure:"max_idle_conns"`    // 最大空闲连接数
+	ConnMaxLifetime int `mapstructure:"conn_max_lifetime"` // 连接最长使用时间（秒），0 表示不限制
 }
 
 // AppConfig 应用配置
 type AppConfig struct {
-	Name        string `mapstructure:"name"`
-	Version     string `mapstructure:"version"`
-	Environment string `mapstructure:"environment"`
-	LogLevel    string `mapstructure:"log_level"`
-	DefaultUser string `mapstructure:"default_user_id"`
+	Name         string `mapstructure:"name"`
+	Version      string `mapstructure:"version"`
+	Environment  string `mapstructure:"environment"`
+	LogLevel     string `mapstructure:"log_level"`
+	DefaultUser  string `mapstructure:"default_user_id"`
+	DefaultStore string `mapstructure:"default_store"`
+
+	// 开发工具接口（/dev）的管理员令牌，为空时不启用开发工具接口；生产环境始终不启用
+	DevAdminToken string `mapstructure:"dev_admin_token"`
+
+	// 用户管理接口（设置角色、生成用户令牌）的管理员令牌，为空时不启用这些接口
+	AdminToken string `mapstructure:"admin_token"`
+}
+
+// SchedulerConfig 定时任务配置
+type SchedulerConfig struct {
+	Enabled               bool `mapstructure:"enabled"`
+	StandingOrderInterval int  `mapstructure:"standing_order_interval"` // 检查常规订单的间隔（秒）
+}
+
+// BackupConfig SQLite 定时备份配置
+type BackupConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`   // 是否启用定时备份（需同时启用定时任务）
+	Dir       string `mapstructure:"dir"`       // 备份目录
+	Interval  int    `mapstructure:"interval"`  // 备份间隔（秒）
+	Retention int    `mapstructure:"retention"` // 保留最近几个备份，0 表示不清理
+}
+
+// MetricsConfig Prometheus 监控指标配置
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否暴露 /metrics
+	Token   string `mapstructure:"token"`   // 抓取令牌，设置后需要请求头 Authorization: Bearer <token>
+}
+
+// HealthConfig 就绪检查配置
+type HealthConfig struct {
+	MinFreeDiskMB int `mapstructure:"min_free_disk_mb"` // SQLite 文件所在磁盘的最小剩余空间（MB），低于时未就绪
 }
 
 var globalConfig *Config
@@ -98,6 +141,7 @@ func setDefaults() {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.read_timeout", 60)
 	viper.SetDefault("server.write_timeout", 60)
+	viper.SetDefault("server.shutdown_timeout", 30)
 
 	// 数据库配置
 	viper.SetDefault("database.type", "sqlite")
@@ -106,6 +150,9 @@ func setDefaults() {
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.name", "purches")
 	viper.SetDefault("database.ssl_mode", "disable")
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 10)
+	viper.SetDefault("database.conn_max_lifetime", 300)
 
 	// 应用配置
 	viper.SetDefault("app.name", "采购订单系统")
@@ -113,6 +160,22 @@ func setDefaults() {
 	viper.SetDefault("app.environment", getEnv("ENVIRONMENT", "development"))
 	viper.SetDefault("app.log_level", "info")
 	viper.SetDefault("app.default_user_id", "user_1")
+	viper.SetDefault("app.default_store", "store_1")
+	viper.SetDefault("app.dev_admin_token", "")
+	viper.SetDefault("app.admin_token", "")
+
+	// 定时任务配置
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.standing_order_interval", 60)
+
+	// 备份配置
+	viper.SetDefault("backup.enabled", true)
+	viper.SetDefault("backup.dir", "./backups")
+	viper.SetDefault("backup.interval", 21600)
+	viper.SetDefault("backup.retention", 28)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("metrics.token", "")
+	viper.SetDefault("health.min_free_disk_mb", 100)
 }
 
 // GetConfig 获取全局配置
@@ -143,12 +206,24 @@ func IsDevelopment() bool {
 
 // IsProduction 判断是否为生产环境
 func IsProduction() bool {
-	return GetConfig().App.Environment == "production"
+	return GetConfig().App.IsProduction()
+}
+
+// IsProduction 判断应用配置是否为生产环境
+func (a AppConfig) IsProduction() bool {
+	return a.Environment == "production"
 }
 
 // GetDatabaseDSN 获取数据库连接字符串
 func GetDatabaseDSN() string {
-	cfg := GetConfig().Database
+	return DatabaseDSN(GetConfig().Database)
+}
+
+// DatabaseDSN 根据数据库配置生成连接字符串
+func DatabaseDSN(cfg DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
 
 	switch cfg.Type {
 	case "sqlite":
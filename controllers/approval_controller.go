@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/logging"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentUserKey 已认证用户在 gin.Context 中的键
+const currentUserKey = "currentUser"
+
+type ApprovalController struct {
+	approvalService *services.ApprovalService
+}
+
+func NewApprovalController(approvalService *services.ApprovalService) *ApprovalController {
+	return &ApprovalController{
+		approvalService: approvalService,
+	}
+}
+
+// RequireUser 校验 Authorization: Bearer <token> 请求头中的用户令牌，
+// 通过后记录当前用户，审批人以令牌对应的用户为准
+func (ac *ApprovalController) RequireUser(c *gin.Context) {
+	token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	user, err := ac.approvalService.WithContext(c.Request.Context()).Authenticate(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			utils.ResponseError(c, 401, "需要用户令牌", "请在 Authorization 请求头中提供 Bearer 用户令牌")
+		} else {
+			utils.ResponseError(c, 500, "校验用户令牌失败", err.Error())
+		}
+		c.Abort()
+		return
+	}
+
+	c.Set(currentUserKey, user.OpenID)
+	c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), logging.Fields{User: user.OpenID}))
+	c.Next()
+}
+
+// RequireApprover 在 RequireUser 之后使用，只允许店主或管理员继续
+func (ac *ApprovalController) RequireApprover(c *gin.Context) {
+	if err := ac.approvalService.WithContext(c.Request.Context()).CheckApprover(c.GetString(currentUserKey)); err != nil {
+		utils.ResponseError(c, 403, "需要店主或管理员权限", err.Error())
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// GetRules 获取审批规则列表
+func (ac *ApprovalController) GetRules(c *gin.Context) {
+	rules, err := ac.approvalService.WithContext(c.Request.Context()).GetRules()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取审批规则失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", rules)
+}
+
+// CreateRule 创建审批规则
+func (ac *ApprovalController) CreateRule(c *gin.Context) {
+	var req models.ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	rule, err := ac.approvalService.WithContext(c.Request.Context()).CreateRule(req)
+	if err != nil {
+		utils.ResponseError(c, 500, "创建审批规则失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "创建成功", rule)
+}
+
+// UpdateRule 更新审批规则
+func (ac *ApprovalController) UpdateRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("ruleId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "规则ID格式错误", err.Error())
+		return
+	}
+
+	var req models.ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	rule, err := ac.approvalService.WithContext(c.Request.Context()).UpdateRule(ruleID, req)
+	if err != nil {
+		ac.handleError(c, "更新审批规则失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "更新成功", rule)
+}
+
+// DeleteRule 删除审批规则
+func (ac *ApprovalController) DeleteRule(c *gin.Context) {
+	ruleID, err := strconv.Atoi(c.Param("ruleId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "规则ID格式错误", err.Error())
+		return
+	}
+
+	if err := ac.approvalService.WithContext(c.Request.Context()).DeleteRule(ruleID); err != nil {
+		ac.handleError(c, "删除审批规则失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "删除成功", nil)
+}
+
+// GetPendingApprovals 获取待审批订单
+func (ac *ApprovalController) GetPendingApprovals(c *gin.Context) {
+	orders, err := ac.approvalService.WithContext(c.Request.Context()).GetPendingApprovals()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取待审批订单失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", orders)
+}
+
+// ApproveOrder 审批通过订单
+func (ac *ApprovalController) ApproveOrder(c *gin.Context) {
+	var req models.ApproveOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	order, err := ac.approvalService.WithContext(c.Request.Context()).ApproveOrder(c.Param("orderId"), c.GetString(currentUserKey), req)
+	if err != nil {
+		ac.handleError(c, "审批失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "审批通过", order)
+}
+
+// RejectOrder 驳回订单，需填写驳回原因
+func (ac *ApprovalController) RejectOrder(c *gin.Context) {
+	var req models.ApproveOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+	if req.Comment == "" {
+		utils.ResponseError(c, 400, "请求参数错误", "驳回时必须填写原因")
+		return
+	}
+
+	order, err := ac.approvalService.WithContext(c.Request.Context()).RejectOrder(c.Param("orderId"), c.GetString(currentUserKey), req)
+	if err != nil {
+		ac.handleError(c, "驳回失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已驳回", order)
+}
+
+// UpdateUserRole 设置用户角色
+func (ac *ApprovalController) UpdateUserRole(c *gin.Context) {
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	user, err := ac.approvalService.WithContext(c.Request.Context()).UpdateUserRole(c.Param("openId"), req.Role)
+	if err != nil {
+		ac.handleError(c, "设置角色失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "设置成功", user)
+}
+
+// IssueUserToken 为用户重新生成访问令牌，旧令牌失效。令牌只在响应中返回一次
+func (ac *ApprovalController) IssueUserToken(c *gin.Context) {
+	token, err := ac.approvalService.WithContext(c.Request.Context()).IssueToken(c.Param("openId"))
+	if err != nil {
+		ac.handleError(c, "生成令牌失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "生成成功", gin.H{"openId": c.Param("openId"), "token": token})
+}
+
+// handleError 根据错误类型返回对应状态码
+func (ac *ApprovalController) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ResponseError(c, 404, "记录不存在", err.Error())
+	case errors.Is(err, services.ErrNotApprover):
+		utils.ResponseError(c, 403, message, err.Error())
+	case errors.Is(err, services.ErrOrderNotAwaitingApproval):
+		utils.ResponseError(c, 409, message, err.Error())
+	default:
+		utils.ResponseError(c, 500, message, err.Error())
+	}
+}
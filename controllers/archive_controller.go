@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ArchiveController struct {
+	archiveService *services.ArchiveService
+}
+
+func NewArchiveController(archiveService *services.ArchiveService) *ArchiveController {
+	return &ArchiveController{
+		archiveService: archiveService,
+	}
+}
+
+// GetArchive 查看已归档的商品、供应商和订单
+func (ac *ArchiveController) GetArchive(c *gin.Context) {
+	var req models.ArchiveListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	archive, err := ac.archiveService.WithContext(c.Request.Context()).GetArchive(req)
+	if err != nil {
+		ac.handleError(c, "获取归档记录失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", archive)
+}
+
+// ArchiveProduct 归档商品
+func (ac *ArchiveController) ArchiveProduct(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "商品ID格式错误", err.Error())
+		return
+	}
+
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	if err := ac.archiveService.WithContext(c.Request.Context()).ArchiveProduct(productID, req.Operator); err != nil {
+		ac.handleError(c, "归档商品失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已归档", nil)
+}
+
+// RestoreProduct 恢复已归档的商品
+func (ac *ArchiveController) RestoreProduct(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "商品ID格式错误", err.Error())
+		return
+	}
+
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	product, err := ac.archiveService.WithContext(c.Request.Context()).RestoreProduct(productID, req.Operator)
+	if err != nil {
+		ac.handleError(c, "恢复商品失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已恢复", product)
+}
+
+// ArchiveSupplier 归档供应商及其商品
+func (ac *ArchiveController) ArchiveSupplier(c *gin.Context) {
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	if err := ac.archiveService.WithContext(c.Request.Context()).ArchiveSupplier(c.Param("supplierName"), req.Operator); err != nil {
+		ac.handleError(c, "归档供应商失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已归档", nil)
+}
+
+// RestoreSupplier 恢复已归档的供应商
+func (ac *ArchiveController) RestoreSupplier(c *gin.Context) {
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	supplier, err := ac.archiveService.WithContext(c.Request.Context()).RestoreSupplier(c.Param("supplierName"), req.Operator)
+	if err != nil {
+		ac.handleError(c, "恢复供应商失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已恢复", supplier)
+}
+
+// ArchiveOrder 归档已结束的订单
+func (ac *ArchiveController) ArchiveOrder(c *gin.Context) {
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	if err := ac.archiveService.WithContext(c.Request.Context()).ArchiveOrder(c.Param("orderId"), req.Operator); err != nil {
+		ac.handleError(c, "归档订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已归档", nil)
+}
+
+// RestoreOrder 恢复已归档的订单
+func (ac *ArchiveController) RestoreOrder(c *gin.Context) {
+	var req models.ArchiveRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	order, err := ac.archiveService.WithContext(c.Request.Context()).RestoreOrder(c.Param("orderId"), req.Operator)
+	if err != nil {
+		ac.handleError(c, "恢复订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "已恢复", order)
+}
+
+// handleError 根据错误类型返回对应状态码
+func (ac *ArchiveController) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ResponseError(c, 404, "记录不存在", err.Error())
+	case errors.Is(err, services.ErrNotAdmin):
+		utils.ResponseError(c, 403, message, err.Error())
+	case errors.Is(err, services.ErrOrderNotClosed),
+		errors.Is(err, services.ErrNotArchived),
+		errors.Is(err, services.ErrSupplierArchived):
+		utils.ResponseError(c, 409, message, err.Error())
+	default:
+		utils.ResponseError(c, 500, message, err.Error())
+	}
+}
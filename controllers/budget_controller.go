@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type BudgetController struct {
+	budgetService *services.BudgetService
+}
+
+func NewBudgetController(budgetService *services.BudgetService) *BudgetController {
+	return &BudgetController{
+		budgetService: budgetService,
+	}
+}
+
+// GetBudgets 获取预算及使用情况
+func (bc *BudgetController) GetBudgets(c *gin.Context) {
+	var req models.BudgetUsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	usages, err := bc.budgetService.WithContext(c.Request.Context()).GetBudgetUsage(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDate) {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "获取预算失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", usages)
+}
+
+// CreateBudget 创建预算
+func (bc *BudgetController) CreateBudget(c *gin.Context) {
+	var req models.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	budget, err := bc.budgetService.WithContext(c.Request.Context()).CreateBudget(req)
+	if err != nil {
+		utils.ResponseError(c, 500, "创建预算失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "创建成功", budget)
+}
+
+// UpdateBudget 更新预算
+func (bc *BudgetController) UpdateBudget(c *gin.Context) {
+	budgetID, err := strconv.Atoi(c.Param("budgetId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "预算ID格式错误", err.Error())
+		return
+	}
+
+	var req models.BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	budget, err := bc.budgetService.WithContext(c.Request.Context()).UpdateBudget(budgetID, req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "预算不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "更新预算失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "更新成功", budget)
+}
+
+// DeleteBudget 删除预算
+func (bc *BudgetController) DeleteBudget(c *gin.Context) {
+	budgetID, err := strconv.Atoi(c.Param("budgetId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "预算ID格式错误", err.Error())
+		return
+	}
+
+	if err := bc.budgetService.WithContext(c.Request.Context()).DeleteBudget(budgetID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "预算不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "删除预算失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "删除成功", nil)
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"purches-backend/models"
 	"purches-backend/services"
 	"purches-backend/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type CartController struct {
@@ -21,7 +24,7 @@ func NewCartController(cartService *services.CartService) *CartController {
 
 // GetCart 获取购物车
 func (cc *CartController) GetCart(c *gin.Context) {
-	response, err := cc.cartService.GetCart()
+	response, err := cc.cartService.WithContext(c.Request.Context()).GetCart()
 	if err != nil {
 		utils.ResponseError(c, 500, "获取购物车失败", err.Error())
 		return
@@ -38,7 +41,7 @@ func (cc *CartController) AddToCart(c *gin.Context) {
 		return
 	}
 
-	item, err := cc.cartService.AddToCart(req)
+	item, err := cc.cartService.WithContext(c.Request.Context()).AddToCart(req)
 	if err != nil {
 		utils.ResponseError(c, 500, "添加失败", err.Error())
 		return
@@ -62,7 +65,7 @@ func (cc *CartController) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
-	err = cc.cartService.UpdateCartItem(itemID, req.Count)
+	err = cc.cartService.WithContext(c.Request.Context()).UpdateCartItem(itemID, req.Count)
 	if err != nil {
 		utils.ResponseError(c, 500, "更新失败", err.Error())
 		return
@@ -80,7 +83,7 @@ func (cc *CartController) DeleteCartItem(c *gin.Context) {
 		return
 	}
 
-	err = cc.cartService.DeleteCartItem(itemID)
+	err = cc.cartService.WithContext(c.Request.Context()).DeleteCartItem(itemID)
 	if err != nil {
 		utils.ResponseError(c, 404, "购物车中没有该商品", err.Error())
 		return
@@ -91,7 +94,7 @@ func (cc *CartController) DeleteCartItem(c *gin.Context) {
 
 // ClearCart 清空购物车
 func (cc *CartController) ClearCart(c *gin.Context) {
-	err := cc.cartService.ClearCart()
+	err := cc.cartService.WithContext(c.Request.Context()).ClearCart()
 	if err != nil {
 		utils.ResponseError(c, 500, "清空失败", err.Error())
 		return
@@ -99,3 +102,44 @@ func (cc *CartController) ClearCart(c *gin.Context) {
 
 	utils.ResponseOK(c, "清空成功", nil)
 }
+
+// SuggestCart 根据标准库存生成建议采购并加入购物车
+func (cc *CartController) SuggestCart(c *gin.Context) {
+	var req models.SuggestCartRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+	}
+
+	weekday := int(time.Now().Weekday())
+	if req.Weekday != nil {
+		weekday = *req.Weekday
+	}
+
+	response, err := cc.cartService.WithContext(c.Request.Context()).SuggestCart(weekday)
+	if err != nil {
+		utils.ResponseError(c, 500, "生成建议采购失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "已生成建议采购", response)
+}
+
+// ReorderToCart 再来一单：将历史订单商品加入购物车
+func (cc *CartController) ReorderToCart(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	response, err := cc.cartService.WithContext(c.Request.Context()).ReorderToCart(orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "订单不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "再来一单失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "已加入购物车", response)
+}
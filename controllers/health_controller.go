@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+	"purches-backend/services"
+	"purches-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthService *services.HealthService
+}
+
+func NewHealthController(healthService *services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// Livez 存活检查，进程能处理请求即返回 200，不检查依赖
+func (hc *HealthController) Livez(c *gin.Context) {
+	utils.ResponseOK(c, "服务存活", "OK")
+}
+
+// Readyz 就绪检查，返回每项检查的结果，任一检查失败时返回 503
+func (hc *HealthController) Readyz(c *gin.Context) {
+	readiness := hc.healthService.WithContext(c.Request.Context()).Readiness()
+	if readiness.Status != "ready" {
+		utils.ResponseErrorData(c, http.StatusServiceUnavailable, "服务未就绪", readiness)
+		return
+	}
+
+	utils.ResponseOK(c, "服务就绪", readiness)
+}
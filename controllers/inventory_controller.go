@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type InventoryController struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryController(inventoryService *services.InventoryService) *InventoryController {
+	return &InventoryController{
+		inventoryService: inventoryService,
+	}
+}
+
+// GetInventory 获取库存列表
+func (ic *InventoryController) GetInventory(c *gin.Context) {
+	inventory, err := ic.inventoryService.WithContext(c.Request.Context()).GetInventory()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取库存失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", inventory)
+}
+
+// UpdateInventory 更新商品现有库存
+func (ic *InventoryController) UpdateInventory(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "商品ID格式错误", err.Error())
+		return
+	}
+
+	var req models.UpdateInventoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	inventory, err := ic.inventoryService.WithContext(c.Request.Context()).SetOnHand(productID, req.Quantity)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "商品不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "更新库存失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "更新成功", inventory)
+}
+
+// GetMovements 获取库存变动记录
+func (ic *InventoryController) GetMovements(c *gin.Context) {
+	productID, ok := queryProductID(c)
+	if !ok {
+		return
+	}
+
+	movements, err := ic.inventoryService.WithContext(c.Request.Context()).GetMovements(productID)
+	if err != nil {
+		utils.ResponseError(c, 500, "获取库存变动失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", movements)
+}
+
+// ConsumeStock 出库（先到期先出）
+func (ic *InventoryController) ConsumeStock(c *gin.Context) {
+	var req models.ConsumeStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	response, err := ic.inventoryService.WithContext(c.Request.Context()).ConsumeStock(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ResponseError(c, 404, "商品不存在", err.Error())
+		case errors.Is(err, services.ErrInsufficientStock):
+			utils.ResponseError(c, 409, "出库失败", err.Error())
+		default:
+			utils.ResponseError(c, 500, "出库失败", err.Error())
+		}
+		return
+	}
+
+	utils.ResponseOK(c, "出库成功", response)
+}
+
+// GetBatches 获取库存批次
+func (ic *InventoryController) GetBatches(c *gin.Context) {
+	productID, ok := queryProductID(c)
+	if !ok {
+		return
+	}
+
+	batches, err := ic.inventoryService.WithContext(c.Request.Context()).GetBatches(productID)
+	if err != nil {
+		utils.ResponseError(c, 500, "获取库存批次失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", batches)
+}
+
+// GetExpiringBatches 获取即将到期的批次
+func (ic *InventoryController) GetExpiringBatches(c *gin.Context) {
+	days := 2
+	if daysStr := c.Query("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 {
+			utils.ResponseError(c, 400, "天数格式错误", "days 必须是非负整数")
+			return
+		}
+		days = parsed
+	}
+
+	batches, err := ic.inventoryService.WithContext(c.Request.Context()).GetExpiringBatches(days)
+	if err != nil {
+		utils.ResponseError(c, 500, "获取即将到期批次失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", batches)
+}
+
+// GetParLevels 获取标准库存设置
+func (ic *InventoryController) GetParLevels(c *gin.Context) {
+	productID, ok := queryProductID(c)
+	if !ok {
+		return
+	}
+
+	levels, err := ic.inventoryService.WithContext(c.Request.Context()).GetParLevels(productID)
+	if err != nil {
+		utils.ResponseError(c, 500, "获取标准库存失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", levels)
+}
+
+// SetParLevels 设置商品标准库存
+func (ic *InventoryController) SetParLevels(c *gin.Context) {
+	var req models.SetParLevelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	levels, err := ic.inventoryService.WithContext(c.Request.Context()).SetParLevels(req)
+	if err != nil {
+		utils.ResponseError(c, 500, "设置标准库存失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "设置成功", levels)
+}
+
+// DeleteParLevel 删除标准库存设置
+func (ic *InventoryController) DeleteParLevel(c *gin.Context) {
+	parLevelID, err := strconv.Atoi(c.Param("parLevelId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "标准库存ID格式错误", err.Error())
+		return
+	}
+
+	if err := ic.inventoryService.WithContext(c.Request.Context()).DeleteParLevel(parLevelID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "标准库存不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "删除标准库存失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "删除成功", nil)
+}
+
+// queryProductID 解析可选的 productId 查询参数，未传时为 0（不筛选）。
+// 格式错误时返回 400 并返回 false
+func queryProductID(c *gin.Context) (int, bool) {
+	value := c.Query("productId")
+	if value == "" {
+		return 0, true
+	}
+	productID, err := strconv.Atoi(value)
+	if err != nil || productID <= 0 {
+		utils.ResponseError(c, 400, "商品ID格式错误", "productId 必须是正整数")
+		return 0, false
+	}
+	return productID, true
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotifications 获取通知列表
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, err := nc.notificationService.WithContext(c.Request.Context()).GetNotifications(unreadOnly)
+	if err != nil {
+		utils.ResponseError(c, 500, "获取通知失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", notifications)
+}
+
+// MarkRead 标记通知为已读
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notificationId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "通知ID格式错误", err.Error())
+		return
+	}
+
+	if err := nc.notificationService.WithContext(c.Request.Context()).MarkRead(notificationID); err != nil {
+		utils.ResponseError(c, 404, "通知不存在", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "已读", nil)
+}
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"purches-backend/models"
 	"purches-backend/services"
 	"purches-backend/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type OrderController struct {
@@ -26,8 +31,12 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	createdOrders, err := oc.orderService.CreateOrder(req)
+	createdOrders, err := oc.orderService.WithContext(c.Request.Context()).CreateOrder(req)
 	if err != nil {
+		if errors.Is(err, services.ErrBudgetExceeded) {
+			utils.ResponseError(c, 409, "创建订单失败", err.Error())
+			return
+		}
 		utils.ResponseError(c, 500, "创建订单失败", err.Error())
 		return
 	}
@@ -55,7 +64,7 @@ func (oc *OrderController) GetOrders(c *gin.Context) {
 		req.Limit = 20
 	}
 
-	response, err := oc.orderService.GetOrders(req)
+	response, err := oc.orderService.WithContext(c.Request.Context()).GetOrders(req)
 	if err != nil {
 		utils.ResponseError(c, 500, "获取订单列表失败", err.Error())
 		return
@@ -68,7 +77,7 @@ func (oc *OrderController) GetOrders(c *gin.Context) {
 func (oc *OrderController) GetOrder(c *gin.Context) {
 	orderID := c.Param("orderId")
 
-	order, err := oc.orderService.GetOrderByID(orderID)
+	order, err := oc.orderService.WithContext(c.Request.Context()).GetOrderByID(orderID)
 	if err != nil {
 		utils.ResponseError(c, 404, "订单不存在", err.Error())
 		return
@@ -87,8 +96,12 @@ func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	err := oc.orderService.UpdateOrderStatus(orderID, req)
+	err := oc.orderService.WithContext(c.Request.Context()).UpdateOrderStatus(orderID, req)
 	if err != nil {
+		if errors.Is(err, services.ErrOrderAwaitingApproval) || errors.Is(err, services.ErrOrderStatusTransition) {
+			utils.ResponseError(c, 409, "更新失败", err.Error())
+			return
+		}
 		utils.ResponseError(c, 500, "更新失败", err.Error())
 		return
 	}
@@ -106,7 +119,7 @@ func (oc *OrderController) UpdateOrderFinalPrice(c *gin.Context) {
 		return
 	}
 
-	err := oc.orderService.UpdateOrderFinalPrice(orderID, req.FinalPrice)
+	err := oc.orderService.WithContext(c.Request.Context()).UpdateOrderFinalPrice(orderID, req.FinalPrice)
 	if err != nil {
 		utils.ResponseError(c, 500, "更新失败", err.Error())
 		return
@@ -115,6 +128,80 @@ func (oc *OrderController) UpdateOrderFinalPrice(c *gin.Context) {
 	utils.ResponseOK(c, "价格更新成功", nil)
 }
 
+// ReceiveOrder 订单收货入库
+func (oc *OrderController) ReceiveOrder(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	var req models.ReceiveOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+	}
+
+	response, err := oc.orderService.WithContext(c.Request.Context()).ReceiveOrder(orderID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ResponseError(c, 404, "订单不存在", err.Error())
+		case errors.Is(err, services.ErrOrderNotReceivable):
+			utils.ResponseError(c, 409, "收货失败", err.Error())
+		case errors.Is(err, services.ErrReceiveItemNotInOrder):
+			utils.ResponseError(c, 400, "收货失败", err.Error())
+		default:
+			utils.ResponseError(c, 500, "收货失败", err.Error())
+		}
+		return
+	}
+
+	utils.ResponseOK(c, "收货成功", response)
+}
+
+// UpdateDraftOrderItems 修改草稿订单商品
+func (oc *OrderController) UpdateDraftOrderItems(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	var req models.UpdateOrderItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	order, err := oc.orderService.WithContext(c.Request.Context()).UpdateDraftOrderItems(orderID, req)
+	if err != nil {
+		oc.handleDraftError(c, "修改订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "修改成功", order)
+}
+
+// SubmitDraftOrder 提交草稿订单
+func (oc *OrderController) SubmitDraftOrder(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	order, err := oc.orderService.WithContext(c.Request.Context()).SubmitDraftOrder(orderID)
+	if err != nil {
+		oc.handleDraftError(c, "提交订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "订单提交成功", order)
+}
+
+// handleDraftError 草稿订单操作的错误响应
+func (oc *OrderController) handleDraftError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ResponseError(c, 404, "订单不存在", err.Error())
+	case errors.Is(err, services.ErrOrderNotDraft), errors.Is(err, services.ErrBudgetExceeded):
+		utils.ResponseError(c, 409, message, err.Error())
+	default:
+		utils.ResponseError(c, 500, message, err.Error())
+	}
+}
+
 // ExportOrders 导出订单数据
 func (oc *OrderController) ExportOrders(c *gin.Context) {
 	var req models.ExportOrdersRequest
@@ -123,11 +210,82 @@ func (oc *OrderController) ExportOrders(c *gin.Context) {
 		return
 	}
 
-	orders, err := oc.orderService.ExportOrders(req)
-	if err != nil {
-		utils.ResponseError(c, 500, "导出失败", err.Error())
+	if err := oc.orderService.WithContext(c.Request.Context()).ValidateOrderExport(req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	// 直接流式写入响应，不在内存中缓存整个文件
+	contentType, ext := services.ExportFileInfo(req.Format)
+	filename := fmt.Sprintf("orders_%s.%s", time.Now().Format("20060102150405"), ext)
+	w := &downloadWriter{c: c, contentType: contentType, filename: filename}
+
+	if err := oc.orderService.WithContext(c.Request.Context()).WriteOrderExport(w, req); err != nil {
+		if !c.Writer.Written() {
+			// 尚未写出内容时还没有设置文件下载的响应头，仍可返回 JSON 错误
+			utils.ResponseError(c, 500, "导出失败", err.Error())
+			return
+		}
+		// 已开始传输，只能中断连接
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// downloadWriter 第一次写入时才设置文件下载的响应头，写入前出错时响应仍是 JSON
+type downloadWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, w.filename))
+		w.c.Status(200)
+	}
+	return w.c.Writer.Write(p)
+}
+
+// GetPurchaseOrderPDF 下载订单采购单 PDF
+func (oc *OrderController) GetPurchaseOrderPDF(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	var buf bytes.Buffer
+	if err := oc.orderService.WithContext(c.Request.Context()).WritePurchaseOrderPDF(&buf, orderID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ResponseError(c, 404, "订单不存在", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "生成采购单失败", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="PO_%s.pdf"`, orderID))
+	c.Data(200, "application/pdf", buf.Bytes())
+}
+
+// GetDailyPurchaseOrdersPDF 下载当天所有供应商的采购单合集 PDF
+func (oc *OrderController) GetDailyPurchaseOrdersPDF(c *gin.Context) {
+	var req models.DailyPurchaseOrdersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := oc.orderService.WithContext(c.Request.Context()).WriteDailyPurchaseOrdersPDF(&buf, req); err != nil {
+		if errors.Is(err, services.ErrInvalidDate) {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "生成采购单失败", err.Error())
 		return
 	}
 
-	utils.ResponseOK(c, "导出成功", orders)
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="PO_%s.pdf"`, req.Date))
+	c.Data(200, "application/pdf", buf.Bytes())
 }
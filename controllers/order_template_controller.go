@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrderTemplateController struct {
+	templateService *services.OrderTemplateService
+}
+
+func NewOrderTemplateController(templateService *services.OrderTemplateService) *OrderTemplateController {
+	return &OrderTemplateController{
+		templateService: templateService,
+	}
+}
+
+// CreateTemplate 创建订单模板
+func (tc *OrderTemplateController) CreateTemplate(c *gin.Context) {
+	var req models.OrderTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	template, err := tc.templateService.WithContext(c.Request.Context()).CreateTemplate(req)
+	if err != nil {
+		utils.ResponseError(c, 500, "创建模板失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "创建成功", template)
+}
+
+// GetTemplates 获取订单模板列表
+func (tc *OrderTemplateController) GetTemplates(c *gin.Context) {
+	templates, err := tc.templateService.WithContext(c.Request.Context()).GetTemplates()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取模板列表失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", templates)
+}
+
+// GetTemplate 获取订单模板详情
+func (tc *OrderTemplateController) GetTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "模板ID格式错误", err.Error())
+		return
+	}
+
+	template, err := tc.templateService.WithContext(c.Request.Context()).GetTemplate(templateID)
+	if err != nil {
+		utils.ResponseError(c, 404, "模板不存在", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", template)
+}
+
+// UpdateTemplate 更新订单模板
+func (tc *OrderTemplateController) UpdateTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "模板ID格式错误", err.Error())
+		return
+	}
+
+	var req models.OrderTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	template, err := tc.templateService.WithContext(c.Request.Context()).UpdateTemplate(templateID, req)
+	if err != nil {
+		tc.handleError(c, "更新模板失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "更新成功", template)
+}
+
+// DeleteTemplate 删除订单模板
+func (tc *OrderTemplateController) DeleteTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "模板ID格式错误", err.Error())
+		return
+	}
+
+	if err := tc.templateService.WithContext(c.Request.Context()).DeleteTemplate(templateID); err != nil {
+		tc.handleError(c, "删除模板失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "删除成功", nil)
+}
+
+// CreateOrdersFromTemplate 按模板立即下单
+func (tc *OrderTemplateController) CreateOrdersFromTemplate(c *gin.Context) {
+	templateID, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "模板ID格式错误", err.Error())
+		return
+	}
+
+	result, err := tc.templateService.WithContext(c.Request.Context()).CreateOrdersFromTemplate(templateID)
+	if err != nil {
+		tc.handleError(c, "创建订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "订单提交成功", result)
+}
+
+// CreateStandingOrder 创建常规订单
+func (tc *OrderTemplateController) CreateStandingOrder(c *gin.Context) {
+	var req models.StandingOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	standingOrder, err := tc.templateService.WithContext(c.Request.Context()).CreateStandingOrder(req)
+	if err != nil {
+		tc.handleError(c, "创建常规订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "创建成功", standingOrder)
+}
+
+// GetStandingOrders 获取常规订单列表
+func (tc *OrderTemplateController) GetStandingOrders(c *gin.Context) {
+	standingOrders, err := tc.templateService.WithContext(c.Request.Context()).GetStandingOrders()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取常规订单失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", standingOrders)
+}
+
+// UpdateStandingOrder 更新常规订单
+func (tc *OrderTemplateController) UpdateStandingOrder(c *gin.Context) {
+	standingOrderID, err := strconv.Atoi(c.Param("standingOrderId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "常规订单ID格式错误", err.Error())
+		return
+	}
+
+	var req models.StandingOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	standingOrder, err := tc.templateService.WithContext(c.Request.Context()).UpdateStandingOrder(standingOrderID, req)
+	if err != nil {
+		tc.handleError(c, "更新常规订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "更新成功", standingOrder)
+}
+
+// DeleteStandingOrder 删除常规订单
+func (tc *OrderTemplateController) DeleteStandingOrder(c *gin.Context) {
+	standingOrderID, err := strconv.Atoi(c.Param("standingOrderId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "常规订单ID格式错误", err.Error())
+		return
+	}
+
+	if err := tc.templateService.WithContext(c.Request.Context()).DeleteStandingOrder(standingOrderID); err != nil {
+		tc.handleError(c, "删除常规订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "删除成功", nil)
+}
+
+// RunStandingOrder 立即执行一次常规订单
+func (tc *OrderTemplateController) RunStandingOrder(c *gin.Context) {
+	standingOrderID, err := strconv.Atoi(c.Param("standingOrderId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "常规订单ID格式错误", err.Error())
+		return
+	}
+
+	result, err := tc.templateService.WithContext(c.Request.Context()).RunStandingOrder(standingOrderID)
+	if err != nil {
+		tc.handleError(c, "生成订单失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "订单已生成", result)
+}
+
+// handleError 根据错误类型返回对应状态码
+func (tc *OrderTemplateController) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ResponseError(c, 404, "模板或常规订单不存在", err.Error())
+	case errors.Is(err, services.ErrInvalidSchedule):
+		utils.ResponseError(c, 400, message, err.Error())
+	case errors.Is(err, services.ErrBudgetExceeded):
+		utils.ResponseError(c, 409, message, err.Error())
+	default:
+		utils.ResponseError(c, 500, message, err.Error())
+	}
+}
@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
 	"purches-backend/models"
 	"purches-backend/services"
 	"purches-backend/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -36,7 +41,7 @@ func (pc *ProductController) GetProducts(c *gin.Context) {
 		req.Limit = 50
 	}
 
-	products, total, err := pc.productService.GetProducts(req)
+	products, total, err := pc.productService.WithContext(c.Request.Context()).GetProducts(req)
 	if err != nil {
 		utils.ResponseError(c, 500, "获取商品列表失败", err.Error())
 		return
@@ -67,7 +72,7 @@ func (pc *ProductController) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := pc.productService.GetProductByID(id)
+	product, err := pc.productService.WithContext(c.Request.Context()).GetProductByID(id)
 	if err != nil {
 		utils.ResponseError(c, 404, "商品不存在", err.Error())
 		return
@@ -76,6 +81,88 @@ func (pc *ProductController) GetProduct(c *gin.Context) {
 	utils.ResponseOK(c, "获取成功", product)
 }
 
+// ExportProducts 导出商品目录，导出的文件可修改后重新导入
+func (pc *ProductController) ExportProducts(c *gin.Context) {
+	var req models.ExportProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	// 商品目录不大，先完整生成，出错时仍可返回 JSON 错误
+	var buf bytes.Buffer
+	if err := pc.productService.WithContext(c.Request.Context()).WriteProductExport(&buf, req); err != nil {
+		utils.ResponseError(c, 500, "导出失败", err.Error())
+		return
+	}
+
+	contentType, ext := services.ProductExportFileInfo(req.Format)
+	filename := fmt.Sprintf("products_%s.%s", time.Now().Format("20060102150405"), ext)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(200, contentType, buf.Bytes())
+}
+
+// ImportProductsFile 从 CSV 或 Excel 文件导入商品
+func (pc *ProductController) ImportProductsFile(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ResponseError(c, 400, "请上传文件", err.Error())
+		return
+	}
+
+	format := services.ImportFileFormat(fileHeader.Filename)
+	if format == "" {
+		utils.ResponseError(c, 400, "请求参数错误", "只支持 .csv 和 .xlsx 文件")
+		return
+	}
+
+	// 列映射，如 {"name":"品名","price":"单价"}
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			utils.ResponseError(c, 400, "列映射格式错误", err.Error())
+			return
+		}
+	}
+	// 确认新建的供应商，如 ["F35","快驴"]
+	var newSuppliers []string
+	if raw := c.PostForm("newSuppliers"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &newSuppliers); err != nil {
+			utils.ResponseError(c, 400, "新供应商格式错误", err.Error())
+			return
+		}
+	}
+	dryRun := c.PostForm("dryRun") == "true"
+	// 文件可能只是某个供应商的价目表，默认保留文件中没有的商品，与 JSON 导入一致
+	discontinueMissing := c.PostForm("discontinueMissing") == "true"
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ResponseError(c, 500, "读取文件失败", err.Error())
+		return
+	}
+	defer file.Close()
+
+	report, err := pc.productService.WithContext(c.Request.Context()).ImportProductsFile(file, format, mapping, newSuppliers, dryRun, discontinueMissing)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImportInvalid):
+			utils.ResponseErrorData(c, 400, "导入数据校验未通过，未导入任何商品", report)
+		case errors.Is(err, services.ErrImportColumnsMissing), errors.Is(err, services.ErrImportFileUnreadable):
+			utils.ResponseError(c, 400, "文件格式错误", err.Error())
+		default:
+			utils.ResponseError(c, 500, "导入失败", err.Error())
+		}
+		return
+	}
+
+	if dryRun {
+		utils.ResponseOK(c, "校验完成", report)
+		return
+	}
+	utils.ResponseOK(c, "导入成功", report)
+}
+
 // ImportProducts 批量导入商品
 func (pc *ProductController) ImportProducts(c *gin.Context) {
 	var req models.ImportProductsRequest
@@ -84,14 +171,15 @@ func (pc *ProductController) ImportProducts(c *gin.Context) {
 		return
 	}
 
-	createdProducts, err := pc.productService.ImportProducts(req.Products)
+	summary, err := pc.productService.WithContext(c.Request.Context()).SyncProducts(req.Products, req.DiscontinueMissing, req.DryRun)
 	if err != nil {
 		utils.ResponseError(c, 500, "导入失败", err.Error())
 		return
 	}
 
-	utils.ResponseOK(c, "导入成功", gin.H{
-		"count":    len(createdProducts),
-		"products": createdProducts,
-	})
+	if req.DryRun {
+		utils.ResponseOK(c, "预览完成", summary)
+		return
+	}
+	utils.ResponseOK(c, "导入成功", summary)
 }
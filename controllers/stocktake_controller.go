@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StocktakeController struct {
+	stocktakeService *services.StocktakeService
+}
+
+func NewStocktakeController(stocktakeService *services.StocktakeService) *StocktakeController {
+	return &StocktakeController{
+		stocktakeService: stocktakeService,
+	}
+}
+
+// CreateStocktake 开始盘点
+func (sc *StocktakeController) CreateStocktake(c *gin.Context) {
+	var req models.CreateStocktakeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+	}
+
+	session, err := sc.stocktakeService.WithContext(c.Request.Context()).CreateSession(req)
+	if err != nil {
+		if errors.Is(err, services.ErrStocktakeInProgress) {
+			utils.ResponseError(c, 409, "创建盘点单失败", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "创建盘点单失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "盘点已开始", session)
+}
+
+// GetStocktakes 获取盘点单列表
+func (sc *StocktakeController) GetStocktakes(c *gin.Context) {
+	sessions, err := sc.stocktakeService.WithContext(c.Request.Context()).GetSessions()
+	if err != nil {
+		utils.ResponseError(c, 500, "获取盘点单失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", sessions)
+}
+
+// GetStocktake 获取盘点单详情
+func (sc *StocktakeController) GetStocktake(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("stocktakeId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "盘点单ID格式错误", err.Error())
+		return
+	}
+
+	session, err := sc.stocktakeService.WithContext(c.Request.Context()).GetSession(sessionID)
+	if err != nil {
+		utils.ResponseError(c, 404, "盘点单不存在", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", session)
+}
+
+// SubmitCounts 提交盘点数量
+func (sc *StocktakeController) SubmitCounts(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("stocktakeId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "盘点单ID格式错误", err.Error())
+		return
+	}
+
+	var req models.SubmitStocktakeCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	session, err := sc.stocktakeService.WithContext(c.Request.Context()).SubmitCounts(sessionID, req)
+	if err != nil {
+		sc.handleError(c, "提交盘点数量失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "提交成功", session)
+}
+
+// GetVariances 获取盘点差异
+func (sc *StocktakeController) GetVariances(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("stocktakeId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "盘点单ID格式错误", err.Error())
+		return
+	}
+
+	report, err := sc.stocktakeService.WithContext(c.Request.Context()).GetVariances(sessionID)
+	if err != nil {
+		sc.handleError(c, "获取盘点差异失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", report)
+}
+
+// CloseStocktake 结束盘点并调整库存
+func (sc *StocktakeController) CloseStocktake(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("stocktakeId"))
+	if err != nil {
+		utils.ResponseError(c, 400, "盘点单ID格式错误", err.Error())
+		return
+	}
+
+	var req models.CloseStocktakeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+	}
+
+	report, err := sc.stocktakeService.WithContext(c.Request.Context()).CloseSession(sessionID, req)
+	if err != nil {
+		sc.handleError(c, "结束盘点失败", err)
+		return
+	}
+
+	utils.ResponseOK(c, "盘点已结束，库存已调整", report)
+}
+
+// handleError 根据错误类型返回对应状态码
+func (sc *StocktakeController) handleError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ResponseError(c, 404, "盘点单不存在", err.Error())
+	case errors.Is(err, services.ErrStocktakeClosed), errors.Is(err, services.ErrStocktakeDisputed):
+		utils.ResponseError(c, 409, message, err.Error())
+	default:
+		utils.ResponseError(c, 500, message, err.Error())
+	}
+}
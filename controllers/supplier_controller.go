@@ -19,7 +19,7 @@ func NewSupplierController(supplierService *services.SupplierService) *SupplierC
 
 // GetSuppliers 获取供应商列表
 func (sc *SupplierController) GetSuppliers(c *gin.Context) {
-	response, err := sc.supplierService.GetSuppliers()
+	response, err := sc.supplierService.WithContext(c.Request.Context()).GetSuppliers()
 	if err != nil {
 		utils.ResponseError(c, 500, "获取供应商列表失败", err.Error())
 		return
@@ -32,7 +32,7 @@ func (sc *SupplierController) GetSuppliers(c *gin.Context) {
 func (sc *SupplierController) GetSupplier(c *gin.Context) {
 	supplierName := c.Param("supplierName")
 
-	response, err := sc.supplierService.GetSupplierDetail(supplierName)
+	response, err := sc.supplierService.WithContext(c.Request.Context()).GetSupplierDetail(supplierName)
 	if err != nil {
 		utils.ResponseError(c, 404, "供应商不存在", err.Error())
 		return
@@ -45,7 +45,7 @@ func (sc *SupplierController) GetSupplier(c *gin.Context) {
 func (sc *SupplierController) GetSupplierProducts(c *gin.Context) {
 	supplierName := c.Param("supplierName")
 
-	products, err := sc.supplierService.GetSupplierProducts(supplierName)
+	products, err := sc.supplierService.WithContext(c.Request.Context()).GetSupplierProducts(supplierName)
 	if err != nil {
 		utils.ResponseError(c, 500, "获取商品列表失败", err.Error())
 		return
@@ -58,7 +58,7 @@ func (sc *SupplierController) GetSupplierProducts(c *gin.Context) {
 func (sc *SupplierController) GetSupplierOrders(c *gin.Context) {
 	supplierName := c.Param("supplierName")
 
-	orders, err := sc.supplierService.GetSupplierOrders(supplierName)
+	orders, err := sc.supplierService.WithContext(c.Request.Context()).GetSupplierOrders(supplierName)
 	if err != nil {
 		utils.ResponseError(c, 500, "获取订单列表失败", err.Error())
 		return
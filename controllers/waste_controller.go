@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WasteController struct {
+	wasteService *services.WasteService
+}
+
+func NewWasteController(wasteService *services.WasteService) *WasteController {
+	return &WasteController{
+		wasteService: wasteService,
+	}
+}
+
+// LogWaste 登记报损
+func (wc *WasteController) LogWaste(c *gin.Context) {
+	var req models.CreateWasteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	record, err := wc.wasteService.WithContext(c.Request.Context()).LogWaste(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.ResponseError(c, 404, "商品不存在", err.Error())
+		case errors.Is(err, services.ErrInsufficientStock):
+			utils.ResponseError(c, 409, "报损失败", err.Error())
+		default:
+			utils.ResponseError(c, 500, "报损失败", err.Error())
+		}
+		return
+	}
+
+	utils.ResponseOK(c, "报损成功", record)
+}
+
+// GetWasteRecords 获取报损记录
+func (wc *WasteController) GetWasteRecords(c *gin.Context) {
+	var req models.WasteListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	records, err := wc.wasteService.WithContext(c.Request.Context()).GetWasteRecords(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDate) {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "获取报损记录失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", records)
+}
+
+// GetWasteReport 获取报损成本报表
+func (wc *WasteController) GetWasteReport(c *gin.Context) {
+	var req models.WasteReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ResponseError(c, 400, "请求参数错误", err.Error())
+		return
+	}
+
+	report, err := wc.wasteService.WithContext(c.Request.Context()).GetWasteReport(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDate) {
+			utils.ResponseError(c, 400, "请求参数错误", err.Error())
+			return
+		}
+		utils.ResponseError(c, 500, "获取报损报表失败", err.Error())
+		return
+	}
+
+	utils.ResponseOK(c, "获取成功", report)
+}
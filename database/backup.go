@@ -0,0 +1,303 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"purches-backend/config"
+	"purches-backend/migrations"
+	"purches-backend/models"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrBackupUnsupported 当前数据库不支持内置备份
+var ErrBackupUnsupported = errors.New("仅 SQLite 数据库支持内置备份和恢复，其他数据库请使用数据库自带的备份工具")
+
+// ErrNoBackup 没有符合条件的备份
+var ErrNoBackup = errors.New("没有找到符合条件的备份")
+
+// ErrDatabaseInUse 数据库正在被服务或其他命令使用
+var ErrDatabaseInUse = errors.New("数据库正在使用中，请先停止服务和其他命令")
+
+// 定时备份的文件名为 purches_<时间>.db，按文件名中的时间排序和清理。
+// 文件名中的时间精确到微秒，同一秒内的多次备份不会重名；解析时小数秒可以省略，
+// 早期只精确到秒的备份仍然识别
+const (
+	backupPrefix     = "purches_"
+	backupSuffix     = ".db"
+	backupTimeLayout = "20060102_150405"
+	backupNameLayout = "20060102_150405.000000"
+)
+
+// BackupInfo 备份文件信息
+type BackupInfo struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// Backup 在线备份 SQLite 数据库到 dest，备份期间服务可以继续读写
+func Backup(db *gorm.DB, dest string) error {
+	if db.Dialector.Name() != "sqlite" {
+		return ErrBackupUnsupported
+	}
+
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("备份文件已存在: %s", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	return db.Exec("VACUUM INTO ?", dest).Error
+}
+
+// Snapshot 在 dir 下创建带时间戳的备份，并只保留最近 retention 个（retention 为 0 时不清理）
+func Snapshot(db *gorm.DB, dir string, retention int) (*BackupInfo, error) {
+	now := time.Now().Truncate(time.Microsecond)
+	dest := filepath.Join(dir, backupPrefix+now.Format(backupNameLayout)+backupSuffix)
+	if err := Backup(db, dest); err != nil {
+		return nil, err
+	}
+
+	if _, err := PruneBackups(dir, retention); err != nil {
+		return nil, fmt.Errorf("清理旧备份失败: %w", err)
+	}
+
+	stat, err := os.Stat(dest)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupInfo{Path: dest, Time: now, Size: stat.Size()}, nil
+}
+
+// ListBackups 列出 dir 下的定时备份，按时间从早到晚排列；目录不存在时返回空
+func ListBackups(dir string) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []BackupInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		backupTime, err := time.ParseInLocation(backupTimeLayout,
+			strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix), time.Local)
+		if err != nil {
+			continue // 不是定时备份的文件
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, BackupInfo{Path: filepath.Join(dir, name), Time: backupTime, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.Before(backups[j].Time) })
+	return backups, nil
+}
+
+// FindBackup 查找 at 时刻之前（含）最近的一个备份，用于恢复到指定时间点
+func FindBackup(dir string, at time.Time) (*BackupInfo, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !backups[i].Time.After(at) {
+			return &backups[i], nil
+		}
+	}
+	return nil, ErrNoBackup
+}
+
+// PruneBackups 删除最早的定时备份，只保留最近 keep 个，返回删除的文件；keep 为 0 时不清理
+func PruneBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i := 0; i < len(backups)-keep; i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backups[i].Path)
+	}
+	return removed, nil
+}
+
+// ValidateBackup 以只读方式打开备份，检查文件完整性、是否为本系统的数据库，
+// 以及迁移版本是否不高于当前程序
+func ValidateBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	uri, err := readOnlyURI(path)
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(sqlite.Open(uri), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("无法读取备份: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return fmt.Errorf("无法读取备份: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("备份文件已损坏: %s", result)
+	}
+
+	for _, table := range []interface{}{&migrations.SchemaMigration{}, &models.Product{}, &models.Order{}} {
+		if !db.Migrator().HasTable(table) {
+			return errors.New("备份中缺少数据表，不是本系统的数据库备份")
+		}
+	}
+
+	known := make(map[int64]bool)
+	for _, migration := range migrations.All() {
+		known[migration.Version] = true
+	}
+	var records []migrations.SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !known[record.Version] {
+			return fmt.Errorf("%w: %d（备份来自更新版本的程序）", migrations.ErrUnknownMigration, record.Version)
+		}
+	}
+	return nil
+}
+
+// readOnlyURI 以只读方式打开文件的 SQLite URI。使用绝对路径，路径中的 ?、# 和 % 需要转义
+func readOnlyURI(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	uri := url.URL{Scheme: "file", Path: "/" + strings.TrimPrefix(filepath.ToSlash(abs), "/"), RawQuery: "mode=ro"}
+	return uri.String(), nil
+}
+
+// checkNotInUse 用 BEGIN EXCLUSIVE 检查是否有其他连接正在读写数据库，不等待
+func checkNotInUse(db *gorm.DB) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("PRAGMA busy_timeout = 0").Error; err != nil {
+			return err
+		}
+		if err := conn.Exec("BEGIN EXCLUSIVE").Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrDatabaseInUse, err)
+		}
+		return conn.Exec("ROLLBACK").Error
+	})
+}
+
+// Restore 校验备份后替换 SQLite 数据库文件，需要先停止服务，数据库正在使用时返回 ErrDatabaseInUse。
+// 替换前将当前数据库备份到同目录，返回该文件路径（原数据库不存在时为空）
+func Restore(cfg config.DatabaseConfig, src string) (string, error) {
+	if cfg.Type != "" && cfg.Type != "sqlite" {
+		return "", ErrBackupUnsupported
+	}
+	if err := ValidateBackup(src); err != nil {
+		return "", err
+	}
+
+	target := config.DatabaseDSN(cfg)
+
+	// 服务和其他命令连接数据库时持有共享锁，恢复期间持有排他锁，避免替换正在使用的数据库
+	if path := lockPath(cfg); path != "" {
+		lock, err := lockFile(path, true)
+		if err != nil {
+			return "", err
+		}
+		if lock != nil {
+			defer lock.Close()
+		}
+	}
+
+	// 保留当前数据库，恢复错了还能换回来（包含 WAL 中尚未合并的数据）
+	var previous string
+	if _, err := os.Stat(target); err == nil {
+		previous = target + ".before-restore-" + time.Now().Format(backupNameLayout)
+		db, err := Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			return "", err
+		}
+		// 不支持文件锁的系统，或有其他工具打开了数据库时，由 BEGIN EXCLUSIVE 检查发现
+		err = checkNotInUse(db)
+		if err == nil {
+			err = Backup(db, previous)
+		}
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		if errors.Is(err, ErrDatabaseInUse) {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("备份当前数据库失败: %w", err)
+		}
+	}
+
+	// 先复制到同目录的临时文件再重命名，复制失败时不影响原数据库
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".restore-*")
+	if err != nil {
+		return previous, err
+	}
+	defer os.Remove(tmp.Name())
+
+	in, err := os.Open(src)
+	if err != nil {
+		tmp.Close()
+		return previous, err
+	}
+	defer in.Close()
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return previous, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return previous, err
+	}
+	if err := tmp.Close(); err != nil {
+		return previous, err
+	}
+
+	// 旧数据库的 WAL 文件不属于备份，留下会被应用到恢复后的数据库上
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(target + suffix); err != nil && !os.IsNotExist(err) {
+			return previous, err
+		}
+	}
+
+	return previous, os.Rename(tmp.Name(), target)
+}
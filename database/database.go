@@ -2,56 +2,253 @@ package database
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"os"
+	"purches-backend/config"
+	"purches-backend/logging"
+	"purches-backend/migrations"
 	"purches-backend/models"
+	"strconv"
+	"strings"
+	"time"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-// InitDatabase 初始化数据库连接
-func InitDatabase() {
-	var err error
+// dbLogger 数据库层的日志，Connect 时设置
+var dbLogger *slog.Logger
 
-	// 连接SQLite数据库
-	DB, err = gorm.Open(sqlite.Open("purches.db"), &gorm.Config{})
+// dbLock 连接期间对 SQLite 数据库持有的共享锁，恢复数据库时据此判断是否有进程在使用
+var dbLock *os.File
+
+// InitDatabase 按配置连接数据库、迁移数据表并初始化数据
+func InitDatabase(logger *slog.Logger) error {
+	if err := Prepare(logger); err != nil {
+		return err
+	}
+
+	logger.Info("数据库表结构已是最新")
+
+	// 初始化测试数据
+	SeedData()
+	return nil
+}
+
+// Prepare 连接数据库并检查迁移，不初始化数据（供服务启动和命令行工具使用）
+func Prepare(logger *slog.Logger) error {
+	if err := Connect(logger); err != nil {
+		return fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	logger.Info("数据库已连接", "type", config.GetConfig().Database.Type)
+
+	// 检查数据库迁移
+	return prepareSchema(DB)
+}
+
+// Connect 按配置连接数据库，不执行迁移和数据初始化（供命令行工具使用），SQL 日志和数据库层的日志输出到 logger
+func Connect(logger *slog.Logger) error {
+	dbLogger = logger
+	cfg := config.GetConfig().Database
+	if path := lockPath(cfg); path != "" {
+		lock, err := lockFile(path, false)
+		if errors.Is(err, ErrDatabaseInUse) {
+			return fmt.Errorf("%w: 数据库正在恢复", err)
+		}
+		if err != nil {
+			return fmt.Errorf("无法锁定数据库文件: %w", err)
+		}
+		dbLock = lock
+	}
+
+	db, err := Open(cfg, &gorm.Config{Logger: logging.NewGormLogger(logger)})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return err
+	}
+	DB = db
+	return nil
+}
+
+// log 数据库层的日志，没有通过 Connect 连接时（如测试中直接设置 DB）使用默认日志
+func log() *slog.Logger {
+	if dbLogger == nil {
+		return slog.Default()
 	}
+	return dbLogger
+}
 
-	fmt.Println("Database connected successfully!")
+// lockPath SQLite 数据库对应的锁文件，其他数据库和内存数据库为空
+func lockPath(cfg config.DatabaseConfig) string {
+	if cfg.Type != "" && cfg.Type != "sqlite" {
+		return ""
+	}
+	dsn := config.DatabaseDSN(cfg)
+	if dsn == "" || strings.HasPrefix(dsn, ":memory:") || strings.HasPrefix(dsn, "file:") {
+		return ""
+	}
+	return dsn + ".lock"
+}
 
-	// 自动迁移数据表
-	err = DB.AutoMigrate(
-		&models.Product{},
-		&models.Supplier{},
-		&models.User{},
-		&models.CartItem{},
-		&models.Order{},
-		&models.OrderItem{},
-	)
+// Close 关闭数据库连接并释放数据库文件锁
+func Close() error {
+	if dbLock != nil {
+		defer func() {
+			dbLock.Close()
+			dbLock = nil
+		}()
+	}
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return err
 	}
+	return sqlDB.Close()
+}
 
-	fmt.Println("Database migration completed!")
+// Dialector 根据数据库类型选择驱动
+func Dialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	dsn := config.DatabaseDSN(cfg)
+	switch cfg.Type {
+	case "", "sqlite":
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	case "postgres":
+		return postgres.Open(dsn), nil
+	case "mysql":
+		return mysql.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Type)
+	}
+}
 
-	// 初始化测试数据
-	SeedData()
+// sqliteParams 每个 SQLite 连接的参数：WAL 模式下读写互不阻塞；写锁被占用时最多等待 5 秒，
+// 而不是立即返回 database is locked；事务开始时就获取写锁，避免两个事务同时由读升级为写时互相等待
+var sqliteParams = []string{"_journal_mode=WAL", "_busy_timeout=5000", "_txlock=immediate"}
+
+// sqliteDSN 在连接字符串中补充 sqliteParams，已配置的参数不覆盖
+func sqliteDSN(dsn string) string {
+	for _, param := range sqliteParams {
+		key := param[:strings.Index(param, "=")+1]
+		if strings.Contains(dsn, key) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + param
+		} else {
+			dsn += "?" + param
+		}
+	}
+	return dsn
+}
+
+// Open 连接数据库并设置连接池
+func Open(cfg config.DatabaseConfig, opts ...gorm.Option) (*gorm.DB, error) {
+	dialector, err := Dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+
+	return db, nil
+}
+
+// Migrate 执行所有未执行的数据库迁移
+func Migrate(db *gorm.DB) error {
+	_, err := migrations.Up(db, 0)
+	return err
+}
+
+// prepareSchema 开发和测试环境自动执行未执行的迁移；生产环境存在未执行的迁移时拒绝启动，
+// 需要先运行 migrate up
+func prepareSchema(db *gorm.DB) error {
+	pending, err := migrations.Pending(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if config.IsProduction() {
+		return fmt.Errorf("存在 %d 个未执行的数据库迁移（最早为 %d_%s），请先执行 migrate up",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	done, err := migrations.Up(db, 0)
+	for _, migration := range done {
+		log().Info("已执行迁移", "version", migration.Version, "name", migration.Name)
+	}
+	return err
 }
 
-// ImportProductFromJSON 从JSON导入商品的结构
+// ImportProductFromJSON 从JSON导入商品的结构，兼容商品目录导出的文件
 type ImportProductFromJSON struct {
 	ID          int     `json:"id"`
+	ExternalID  *string `json:"externalId"` // 没有该键时使用 id（docs/products.json 的格式）；导出的目录总是带有该键
 	Name        string  `json:"name"`
 	Price       float64 `json:"price"`
 	Unit        string  `json:"unit"`
-	Description string  `json:"description"`
+	Description *string `json:"description"` // 可选字段，JSON 中没有该键时为 nil，同步时不修改
 	Supplier    string  `json:"supplier"`
+	Category    *string `json:"category"`
+	ShelfLife   *int    `json:"shelfLife"`
+	Status      string  `json:"status"`
+}
+
+// DescriptionValue 商品描述，JSON 中没有时为空
+func (p ImportProductFromJSON) DescriptionValue() string {
+	if p.Description == nil {
+		return ""
+	}
+	return *p.Description
+}
+
+// ExternalIDValue 外部编号。JSON 中没有 externalId 键时使用 id；有该键时即使为空也不使用 id，
+// 因为导出的目录中 id 是本系统的商品ID，不是外部编号
+func (p ImportProductFromJSON) ExternalIDValue() string {
+	if p.ExternalID != nil {
+		return *p.ExternalID
+	}
+	if p.ID == 0 {
+		return ""
+	}
+	return strconv.Itoa(p.ID)
+}
+
+// ToImportProduct 转换为导入商品
+func ToImportProduct(jsonProduct ImportProductFromJSON) models.ImportProduct {
+	return models.ImportProduct{
+		ExternalID:  jsonProduct.ExternalIDValue(),
+		Name:        jsonProduct.Name,
+		Price:       jsonProduct.Price,
+		Unit:        jsonProduct.Unit,
+		Description: jsonProduct.Description,
+		Supplier:    jsonProduct.Supplier,
+		Category:    jsonProduct.Category,
+		ShelfLife:   jsonProduct.ShelfLife,
+		Status:      jsonProduct.Status,
+	}
 }
 
 // LoadProductsFromJSON 从JSON文件加载商品数据
@@ -76,41 +273,40 @@ func SeedData() {
 	var productCount int64
 	DB.Model(&models.Product{}).Count(&productCount)
 	if productCount > 0 {
-		fmt.Printf("Data already exists (%d products), skipping seed...\n", productCount)
+		log().Info("已有商品数据，跳过初始化", "products", productCount)
 		return
 	}
 
 	// 尝试从JSON文件导入真实数据
-	fmt.Println("尝试从 docs/products.json 导入真实商品数据...")
+	log().Info("尝试从 docs/products.json 导入真实商品数据")
 	jsonProducts, err := LoadProductsFromJSON("docs/products.json")
 	if err != nil {
-		fmt.Printf("无法读取JSON文件，使用默认测试数据: %v\n", err)
+		log().Warn("无法读取JSON文件，使用默认测试数据", "error", err)
 		SeedDefaultData()
 		return
 	}
 
 	// 先创建供应商
-	fmt.Println("创建供应商数据...")
 	CreateSuppliersFromProducts(jsonProducts)
 
 	// 创建商品
-	fmt.Println("导入商品数据...")
 	for _, jsonProduct := range jsonProducts {
 		product := models.Product{
+			ExternalID:  jsonProduct.ExternalIDValue(),
 			Name:        jsonProduct.Name,
 			Price:       jsonProduct.Price,
 			Unit:        jsonProduct.Unit,
-			Description: jsonProduct.Description,
+			Description: jsonProduct.DescriptionValue(),
 			Supplier:    jsonProduct.Supplier,
 			Status:      "available",
 		}
 
 		if err := DB.Create(&product).Error; err != nil {
-			fmt.Printf("导入商品失败 %s: %v\n", jsonProduct.Name, err)
+			log().Error("导入商品失败", "product", jsonProduct.Name, "error", err)
 		}
 	}
 
-	fmt.Printf("成功导入 %d 种商品数据!\n", len(jsonProducts))
+	log().Info("商品数据导入完成", "products", len(jsonProducts))
 }
 
 // CreateSuppliersFromProducts 从商品数据中提取并创建供应商
@@ -195,12 +391,12 @@ func CreateSuppliersFromProducts(products []ImportProductFromJSON) {
 			}
 		}
 
-		if err := DB.Create(&supplier).Error; err != nil {
-			fmt.Printf("创建供应商失败 %s: %v\n", supplierName, err)
+		if err := DB.Unscoped().Where("name = ?", supplierName).FirstOrCreate(&supplier).Error; err != nil {
+			log().Error("创建供应商失败", "supplier", supplierName, "error", err)
 		}
 	}
 
-	fmt.Printf("成功创建 %d 个供应商\n", len(supplierMap))
+	log().Info("供应商数据创建完成", "suppliers", len(supplierMap))
 }
 
 // SeedDefaultData 使用默认测试数据（当JSON文件不可用时）
@@ -236,25 +432,55 @@ func SeedDefaultData() {
 		DB.Create(&product)
 	}
 
-	fmt.Println("Default test data inserted successfully!")
+	log().Info("默认测试数据已插入")
+}
+
+// resetTables 重置数据时清空的数据表
+var resetTables = []string{
+	"products",
+	"suppliers",
+	"users",
+	"cart_items",
+	"orders",
+	"order_items",
+	"inventories",
+	"par_levels",
+	"stock_movements",
+	"stock_batches",
+	"waste_records",
+	"stocktake_sessions",
+	"stocktake_counts",
+	"order_templates",
+	"order_template_items",
+	"standing_orders",
+	"notifications",
+	"approval_rules",
+	"order_approvals",
+	"budgets",
 }
 
-// ResetData 重新初始化数据（清空并重新插入）
-func ResetData() {
-	fmt.Println("开始重置数据...")
+// ResetData 重新初始化数据（清空并重新插入）。清空在一个事务中执行，任一数据表失败时不做修改并返回错误
+func ResetData() error {
+	log().Warn("开始重置数据")
 
 	// 删除所有现有数据
-	DB.Exec("DELETE FROM products")
-	DB.Exec("DELETE FROM suppliers")
-	DB.Exec("DELETE FROM users")
-	DB.Exec("DELETE FROM cart_items")
-	DB.Exec("DELETE FROM orders")
-	DB.Exec("DELETE FROM order_items")
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		for _, table := range resetTables {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return fmt.Errorf("清空数据表 %s 失败: %w", table, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("已清空所有数据")
+	log().Warn("已清空所有数据")
 
 	// 重新插入数据
 	SeedDataForce()
+	return nil
 }
 
 // SeedDataForce 强制插入数据（不检查是否已存在）
@@ -262,7 +488,7 @@ func SeedDataForce() {
 	// 尝试从JSON文件导入
 	jsonProducts, err := LoadProductsFromJSON("docs/products.json")
 	if err != nil {
-		fmt.Printf("无法读取JSON文件，使用默认测试数据: %v\n", err)
+		log().Warn("无法读取JSON文件，使用默认测试数据", "error", err)
 		SeedDefaultData()
 		return
 	}
@@ -272,15 +498,16 @@ func SeedDataForce() {
 
 	for _, jsonProduct := range jsonProducts {
 		product := models.Product{
+			ExternalID:  jsonProduct.ExternalIDValue(),
 			Name:        jsonProduct.Name,
 			Price:       jsonProduct.Price,
 			Unit:        jsonProduct.Unit,
-			Description: jsonProduct.Description,
+			Description: jsonProduct.DescriptionValue(),
 			Supplier:    jsonProduct.Supplier,
 			Status:      "available",
 		}
 		DB.Create(&product)
 	}
 
-	fmt.Printf("强制插入 %d 种商品数据完成!\n", len(jsonProducts))
+	log().Info("强制插入商品数据完成", "products", len(jsonProducts))
 }
@@ -0,0 +1,31 @@
+//go:build linux || darwin || freebsd
+
+package database
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile 对 path 加文件锁（不等待），exclusive 为 false 时为共享锁。
+// 已被其他进程以冲突的方式锁住时返回 ErrDatabaseInUse
+func lockFile(path string, exclusive bool) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrDatabaseInUse
+		}
+		return nil, err
+	}
+	return file, nil
+}
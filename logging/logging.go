@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"purches-backend/config"
+	"strings"
+)
+
+// Fields 请求相关的日志字段，放在 context 中，使用该 context 记录的每条日志都会带上
+type Fields struct {
+	RequestID string
+	User      string
+	Store     string
+	OrderID   string
+}
+
+type fieldsKey struct{}
+
+// WithFields 返回带有日志字段的 context，非空字段覆盖 context 中已有的值
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	merged := FieldsFrom(ctx)
+	if fields.RequestID != "" {
+		merged.RequestID = fields.RequestID
+	}
+	if fields.User != "" {
+		merged.User = fields.User
+	}
+	if fields.Store != "" {
+		merged.Store = fields.Store
+	}
+	if fields.OrderID != "" {
+		merged.OrderID = fields.OrderID
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFrom 读取 context 中的日志字段
+func FieldsFrom(ctx context.Context) Fields {
+	if ctx == nil {
+		return Fields{}
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// ParseLevel 解析日志级别（debug, info, warn, error），未知值按 info 处理
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// New 按应用配置创建日志：生产环境输出 JSON，其他环境输出文本，级别为 app.log_level
+func New(w io.Writer, cfg config.AppConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.LogLevel)}
+
+	var handler slog.Handler
+	if cfg.IsProduction() {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup 创建输出到标准输出的日志并设为默认日志
+func Setup(cfg config.AppConfig) *slog.Logger {
+	logger := New(os.Stdout, cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// contextHandler 把 context 中的日志字段加到每条日志上
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := FieldsFrom(ctx)
+	if fields.RequestID != "" {
+		record.AddAttrs(slog.String("request_id", fields.RequestID))
+	}
+	if fields.User != "" {
+		record.AddAttrs(slog.String("user", fields.User))
+	}
+	if fields.Store != "" {
+		record.AddAttrs(slog.String("store", fields.Store))
+	}
+	if fields.OrderID != "" {
+		record.AddAttrs(slog.String("order_id", fields.OrderID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
 	"purches-backend/config"
 	"purches-backend/controllers"
 	"purches-backend/database"
+	"purches-backend/logging"
+	"purches-backend/metrics"
 	"purches-backend/middleware"
+	"purches-backend/routes"
+	"purches-backend/scheduler"
+	"purches-backend/server"
 	"purches-backend/services"
-	"purches-backend/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -19,115 +30,171 @@ func main() {
 		panic(fmt.Sprintf("配置加载失败: %v", err))
 	}
 
+	// 结构化日志，生产环境输出 JSON
+	logger := logging.Setup(cfg.App)
+
+	os.Exit(runCommand(logger, os.Args[1:]))
+}
+
+// runServe 启动 HTTP 服务
+func runServe(logger *slog.Logger, args []string) int {
+	if code, ok := parseFlags(newFlagSet("serve", ""), args); !ok {
+		return code
+	}
+	cfg := config.GetConfig()
+
 	// 设置Gin模式
 	if config.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	// 初始化数据库
-	database.InitDatabase()
-
-	// 创建Gin实例
-	r := gin.Default()
+	if err := database.InitDatabase(logger); err != nil {
+		logger.Error("数据库初始化失败", "error", err)
+		return 1
+	}
 
-	// 设置中间件
-	r.Use(middleware.CORS())
+	// 监控指标：SQL 耗时和连接池状态
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterDB(database.DB); err != nil {
+			logger.Error("注册数据库监控指标失败", "error", err)
+			return 1
+		}
+	}
 
 	// 初始化服务层
-	productService := services.NewProductService(database.DB)
-	cartService := services.NewCartService(database.DB)
-	orderService := services.NewOrderService(database.DB)
-	supplierService := services.NewSupplierService(database.DB)
+	productService := services.NewProductService(database.DB, logger)
+	cartService := services.NewCartService(database.DB, logger)
+	orderService := services.NewOrderService(database.DB, logger)
+	supplierService := services.NewSupplierService(database.DB, logger)
+	inventoryService := services.NewInventoryService(database.DB, logger)
+	stocktakeService := services.NewStocktakeService(database.DB, logger)
+	wasteService := services.NewWasteService(database.DB, logger)
+	templateService := services.NewOrderTemplateService(database.DB, logger)
+	notificationService := services.NewNotificationService(database.DB, logger)
+	approvalService := services.NewApprovalService(database.DB, logger)
+	budgetService := services.NewBudgetService(database.DB, logger)
+	archiveService := services.NewArchiveService(database.DB, logger)
+
+	// 创建Gin实例，访问日志和 panic 输出到结构化日志
+	r := gin.New()
+
+	// 设置中间件，请求 ID 需要在访问日志之前生成，访问日志按用户令牌记录请求用户
+	r.Use(middleware.RequestID(), middleware.RequestLogger(logger, cfg.App.DefaultUser, approvalService.ResolveUser), middleware.Recovery(logger))
+	r.Use(middleware.CORS())
+	if cfg.Metrics.Enabled {
+		r.Use(middleware.Metrics())
+	}
 
 	// 初始化控制器层
 	productController := controllers.NewProductController(productService)
 	cartController := controllers.NewCartController(cartService)
 	orderController := controllers.NewOrderController(orderService)
 	supplierController := controllers.NewSupplierController(supplierService)
+	inventoryController := controllers.NewInventoryController(inventoryService)
+	stocktakeController := controllers.NewStocktakeController(stocktakeService)
+	wasteController := controllers.NewWasteController(wasteService)
+	templateController := controllers.NewOrderTemplateController(templateService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	approvalController := controllers.NewApprovalController(approvalService)
+	budgetController := controllers.NewBudgetController(budgetService)
+	archiveController := controllers.NewArchiveController(archiveService)
 
 	// 设置路由
-	setupRoutes(r, productController, cartController, orderController, supplierController, productService)
+	routes.SetupRoutes(r, productController, cartController, orderController, supplierController, inventoryController, stocktakeController, wasteController, templateController, notificationController, approvalController, budgetController, archiveController)
+	devEnabled := routes.SetupDevRoutes(r, cfg.App, productService)
+	adminEnabled := routes.SetupAdminRoutes(r, cfg.App, approvalController)
+	metricsEnabled := routes.SetupMetricsRoutes(r, cfg.Metrics)
+
+	// 启动定时任务
+	jobs := setupScheduler(logger, cfg, templateService, database.DB)
+
+	// 存活和就绪检查
+	healthController := controllers.NewHealthController(services.NewHealthService(database.DB, cfg, jobs))
+	routes.SetupHealthRoutes(r, healthController)
 
 	// 启动信息
-	fmt.Printf("🚀 %s 启动成功!\n", cfg.App.Name)
-	fmt.Printf("📦 版本: %s\n", cfg.App.Version)
-	fmt.Printf("🔧 环境: %s\n", cfg.App.Environment)
-	fmt.Printf("💾 数据库: %s\n", cfg.Database.Type)
-	fmt.Printf("🔗 后端地址: http://localhost:%s\n", cfg.Server.Port)
-	fmt.Printf("🔍 健康检查: http://localhost:%s/v1/health\n", cfg.Server.Port)
-	fmt.Println("📖 API文档: 根据 docs/API_接口文档.md")
+	logger.Info("服务启动",
+		"name", cfg.App.Name,
+		"version", cfg.App.Version,
+		"environment", cfg.App.Environment,
+		"database", cfg.Database.Type,
+		"addr", "http://localhost:"+cfg.Server.Port,
+		"health", "/readyz",
+		"dev_routes", devEnabled,
+		"admin_routes", adminEnabled,
+		"metrics", metricsEnabled)
 
 	// 启动服务器
-	r.Run(":" + cfg.Server.Port)
-}
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      r,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+	cleanup := func() error {
+		// 等待正在运行的定时任务结束，之后才能关闭数据库
+		jobs.Stop()
+		if err := database.Close(); err != nil {
+			return fmt.Errorf("关闭数据库失败: %w", err)
+		}
+		return nil
+	}
 
-// setupRoutes 设置路由
-func setupRoutes(
-	r *gin.Engine,
-	productController *controllers.ProductController,
-	cartController *controllers.CartController,
-	orderController *controllers.OrderController,
-	supplierController *controllers.SupplierController,
-	productService *services.ProductService,
-) {
-	// API路由组
-	v1 := r.Group("/v1")
-	{
-		// 商品管理 API
-		v1.GET("/products", productController.GetProducts)
-		v1.GET("/products/:productId", productController.GetProduct)
-		v1.POST("/products/import", productController.ImportProducts)
-
-		// 购物车管理 API
-		v1.GET("/cart", cartController.GetCart)
-		v1.POST("/cart/items", cartController.AddToCart)
-		v1.PUT("/cart/items/:itemId", cartController.UpdateCartItem)
-		v1.DELETE("/cart/items/:itemId", cartController.DeleteCartItem)
-		v1.DELETE("/cart", cartController.ClearCart)
-
-		// 订单管理 API
-		v1.POST("/orders", orderController.CreateOrder)
-		v1.GET("/orders", orderController.GetOrders)
-		v1.GET("/orders/:orderId", orderController.GetOrder)
-		v1.PUT("/orders/:orderId/status", orderController.UpdateOrderStatus)
-		v1.PUT("/orders/:orderId/final-price", orderController.UpdateOrderFinalPrice)
-		v1.GET("/orders/export", orderController.ExportOrders)
-
-		// 供应商管理 API
-		v1.GET("/suppliers", supplierController.GetSuppliers)
-		v1.GET("/suppliers/:supplierName", supplierController.GetSupplier)
-		v1.GET("/suppliers/:supplierName/products", supplierController.GetSupplierProducts)
-		v1.GET("/suppliers/:supplierName/orders", supplierController.GetSupplierOrders)
-
-		// 健康检查
-		v1.GET("/health", func(c *gin.Context) {
-			utils.ResponseOK(c, "服务正常", "OK")
-		})
-
-		// 开发工具接口
-		setupDevRoutes(v1, productService)
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Error("服务启动失败", "error", err)
+		cleanup()
+		return 1
 	}
+	return server.ServeUntilSignal(logger, srv, ln, time.Duration(cfg.Server.ShutdownTimeout)*time.Second, cleanup)
 }
 
-// setupDevRoutes 设置开发工具路由
-func setupDevRoutes(v1 *gin.RouterGroup, productService *services.ProductService) {
-	// 重置数据（仅用于开发测试）
-	v1.POST("/reset-data", func(c *gin.Context) {
-		database.ResetData()
-		utils.ResponseOK(c, "数据重置完成", "重新初始化了采购订单系统测试数据")
-	})
-
-	// 导入JSON数据（仅用于开发测试）
-	v1.POST("/import-products-json", func(c *gin.Context) {
-		count, err := productService.ImportProductsFromJSON("docs/products.json")
-		if err != nil {
-			utils.ResponseError(c, 500, "无法读取JSON文件", err.Error())
-			return
+// setupScheduler 注册并启动定时任务
+func setupScheduler(logger *slog.Logger, cfg *config.Config, templateService *services.OrderTemplateService, db *gorm.DB) *scheduler.Scheduler {
+	jobs := scheduler.New(logger)
+	if !cfg.Scheduler.Enabled {
+		return jobs
+	}
+
+	// 生成到期的常规订单，单个常规订单失败不影响其他常规订单，失败原因由调度器记录
+	interval := intervalSeconds(logger, "scheduler.standing_order_interval", cfg.Scheduler.StandingOrderInterval, 60)
+	if err := jobs.Every("standing_orders", interval, func(ctx context.Context) error {
+		results, err := templateService.WithContext(ctx).RunDueStandingOrders(time.Now())
+		for _, result := range results {
+			if result.Error == "" {
+				logger.InfoContext(ctx, "常规订单已生成", "standing_order_id", result.StandingOrderID, "orders", len(result.Orders))
+			}
 		}
+		return err
+	}); err != nil {
+		logger.Error("注册定时任务失败", "error", err)
+	}
+
+	// SQLite 定时备份
+	if cfg.Backup.Enabled && db.Dialector.Name() == "sqlite" {
+		backupInterval := intervalSeconds(logger, "backup.interval", cfg.Backup.Interval, 21600)
+		if err := jobs.Every("backup", backupInterval, func(ctx context.Context) error {
+			backup, err := database.Snapshot(db.WithContext(ctx), cfg.Backup.Dir, cfg.Backup.Retention)
+			if err != nil {
+				return err
+			}
+			logger.InfoContext(ctx, "数据库已备份", "path", backup.Path, "size", backup.Size)
+			return nil
+		}); err != nil {
+			logger.Error("注册定时任务失败", "error", err)
+		}
+	}
 
-		utils.ResponseOK(c, "JSON数据导入完成", gin.H{
-			"count":   count,
-			"message": fmt.Sprintf("成功导入 %d 种商品", count),
-		})
-	})
+	jobs.Start(context.Background())
+	return jobs
+}
+
+// intervalSeconds 将配置的间隔秒数转换为时长，不大于 0 时使用默认值并记录警告
+func intervalSeconds(logger *slog.Logger, key string, seconds, fallback int) time.Duration {
+	if seconds <= 0 {
+		logger.Warn("定时任务间隔必须大于 0，使用默认值", "config", key, "value", seconds, "default", fallback)
+		seconds = fallback
+	}
+	return time.Duration(seconds) * time.Second
 }
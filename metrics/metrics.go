@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "purches"
+
+// Registry 本服务的指标注册表，只包含下面定义的指标和 Go 运行时、进程指标
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP 请求数",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP 请求处理耗时（秒）",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	ordersCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_created_total",
+		Help:      "新建订单数，source 为下单来源（cart、template、standing_order）",
+	}, []string{"supplier", "source"})
+
+	cartItemsAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cart_items_added_total",
+		Help:      "加入购物车次数",
+	}, []string{"supplier"})
+
+	productImports = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "product_imports_total",
+		Help:      "商品目录同步次数（不含预览）",
+	})
+
+	productImportChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "product_import_changes_total",
+		Help:      "商品目录同步中新增、更新、停售的商品数",
+	}, []string{"change"})
+
+	finalPriceOverrides = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "order_final_price_overrides_total",
+		Help:      "修改订单最终价格的次数",
+	}, []string{"supplier"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM 查询耗时（秒）",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"operation", "table"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		ordersCreated,
+		cartItemsAdded,
+		productImports,
+		productImportChanges,
+		finalPriceOverrides,
+		dbQueryDuration,
+	)
+}
+
+// Handler 以 Prometheus 文本格式输出指标
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest 记录一次 HTTP 请求，route 为路由模板（如 /v1/orders/:orderId），避免按实际路径产生过多序列
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// OrderCreated 记录新建的订单
+func OrderCreated(supplier, source string) {
+	ordersCreated.WithLabelValues(supplier, source).Inc()
+}
+
+// CartItemAdded 记录加入购物车
+func CartItemAdded(supplier string) {
+	cartItemsAdded.WithLabelValues(supplier).Inc()
+}
+
+// ProductsImported 记录一次商品目录同步
+func ProductsImported(added, updated, discontinued int) {
+	productImports.Inc()
+	productImportChanges.WithLabelValues("added").Add(float64(added))
+	productImportChanges.WithLabelValues("updated").Add(float64(updated))
+	productImportChanges.WithLabelValues("discontinued").Add(float64(discontinued))
+}
+
+// FinalPriceOverridden 记录修改订单最终价格
+func FinalPriceOverridden(supplier string) {
+	finalPriceOverrides.WithLabelValues(supplier).Inc()
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"purches-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader 管理员令牌请求头
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminToken 校验请求头中的管理员令牌，setting 为令牌对应的配置项，用于错误提示
+func AdminToken(token, setting string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader(AdminTokenHeader)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			utils.ResponseError(c, http.StatusUnauthorized, "需要管理员令牌", "请在 "+AdminTokenHeader+" 请求头中提供 "+setting)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
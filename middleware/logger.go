@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"purches-backend/logging"
+	"purches-backend/utils"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserResolver 根据 Bearer 用户令牌查找用户的 openId，令牌无效时返回错误
+type UserResolver func(ctx context.Context, token string) (string, error)
+
+// RequestLogger 为请求 context 设置日志字段（用户、门店、订单号），请求结束后记录一条访问日志。
+// 带有效用户令牌的请求记录令牌对应的用户；没有令牌或令牌无效时使用路径中的 openId 或 operator 参数，
+// 都没有时使用默认用户 defaultUser。resolveUser 为 nil 时不解析令牌
+func RequestLogger(logger *slog.Logger, defaultUser string, resolveUser UserResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var user string
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" && resolveUser != nil {
+			// 令牌无效时由 RequireUser 拒绝，这里只影响日志
+			if openID, err := resolveUser(c.Request.Context(), token); err == nil {
+				user = openID
+			}
+		}
+		if user == "" {
+			user = c.Param("openId")
+		}
+		if user == "" {
+			user = c.Query("operator")
+		}
+		if user == "" {
+			user = defaultUser
+		}
+		ctx := logging.WithFields(c.Request.Context(), logging.Fields{
+			User:    user,
+			Store:   c.Query("store"),
+			OrderID: c.Param("orderId"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "HTTP 请求", attrs...)
+	}
+}
+
+// Recovery 捕获处理请求时的 panic，记录错误日志和调用栈并返回 500
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err interface{}) {
+		logger.ErrorContext(c.Request.Context(), "请求处理异常", "error", err, "stack", string(debug.Stack()))
+		utils.ResponseError(c, http.StatusInternalServerError, "服务器内部错误", "")
+		c.Abort()
+	})
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"purches-backend/metrics"
+	"purches-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由模板和状态码统计请求数和耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		metrics.ObserveHTTPRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// BearerToken 校验 Authorization: Bearer <token> 请求头，Prometheus 抓取时通过 authorization 配置提供
+func BearerToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			utils.ResponseError(c, http.StatusUnauthorized, "需要抓取令牌", "请在 Authorization 请求头中提供 Bearer metrics.token")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"purches-backend/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 请求头，客户端或网关可以传入，响应中原样返回
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入请求 ID 的最大长度
+const maxRequestIDLength = 64
+
+// RequestID 读取请求头中的请求 ID，没有或不合法时生成一个新的。
+// 请求 ID 写入请求 context（日志、业务事件和响应体中的 requestId 都从这里读取）并在响应头中返回，
+// 需要注册在 RequestLogger 之前
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		ctx := logging.WithFields(c.Request.Context(), logging.Fields{RequestID: requestID})
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID 只接受长度有限的字母、数字和 - _ . : ，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成 32 位十六进制随机请求 ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoWriteTimeout 取消当前响应的写入超时（server.write_timeout），用于流式导出和 PDF 等耗时
+// 随数据量增长的下载接口，避免响应写到一半被断开。服务停止时仍受 server.shutdown_timeout 限制
+func NoWriteTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 失败时仍继续处理请求，错误记录在访问日志中
+		err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			c.Error(err)
+		}
+
+		c.Next()
+	}
+}
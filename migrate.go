@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"purches-backend/database"
+	"purches-backend/migrations"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `用法: purches-backend migrate <命令>
+
+命令:
+  up [n]       执行未执行的迁移（默认全部，n 为执行个数）
+  down [n|all] 回滚已执行的迁移（默认回滚最近 1 个）
+  status       查看迁移状态`
+
+// runMigrate 执行 migrate 子命令，返回进程退出码
+func runMigrate(logger *slog.Logger, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if !connectDatabase(logger) {
+		return 1
+	}
+	defer closeDatabase()
+
+	switch args[0] {
+	case "up":
+		steps, err := migrateSteps(args[1:], 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		done, err := migrations.Up(database.DB, steps)
+		for _, migration := range done {
+			fmt.Printf("已执行 %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("没有未执行的迁移")
+		}
+
+	case "down":
+		steps, err := migrateSteps(args[1:], 1)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		done, err := migrations.Down(database.DB, steps)
+		for _, migration := range done {
+			fmt.Printf("已回滚 %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+
+	case "status":
+		statuses, err := migrations.Status(database.DB)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, status := range statuses {
+			state, appliedAt := "未执行", ""
+			if status.Applied {
+				state, appliedAt = "已执行", status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// migrateSteps 解析迁移个数参数，all 表示全部（0）
+func migrateSteps(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+	if args[0] == "all" {
+		return 0, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("迁移个数应为正整数或 all: %s", args[0])
+	}
+	return steps, nil
+}
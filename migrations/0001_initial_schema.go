@@ -0,0 +1,333 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 基线迁移的表结构快照。与 models 中的模型相互独立，模型之后的修改不会改变基线迁移创建的表，
+// 表结构变更需要新增迁移
+
+type v1Product struct {
+	ID          int     `gorm:"primary_key"`
+	ExternalID  string  `gorm:"index"`
+	Name        string  `gorm:"not null"`
+	Price       float64 `gorm:"type:decimal(10,2);not null"`
+	Unit        string  `gorm:"not null"`
+	Description string
+	Supplier    string `gorm:"not null"`
+	Category    string `gorm:"index"`
+	Status      string `gorm:"default:available"`
+	ShelfLife   int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Product) TableName() string { return "products" }
+
+type v1Supplier struct {
+	Name          string `gorm:"primary_key"`
+	ContactPerson string
+	Phone         string
+	Address       string
+	Status        string `gorm:"default:'active'"`
+	CreatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Supplier) TableName() string { return "suppliers" }
+
+type v1User struct {
+	ID        uint   `gorm:"primary_key"`
+	OpenID    string `gorm:"unique;not null"`
+	NickName  string
+	AvatarURL string
+	Role      string `gorm:"default:'buyer'"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1CartItem struct {
+	ID         int  `gorm:"primary_key"`
+	UserID     uint `gorm:"not null"`
+	ProductID  int  `gorm:"not null"`
+	Name       string
+	Count      int `gorm:"not null"`
+	ShopName   string
+	Price      float64   `gorm:"type:decimal(10,2);not null"`
+	TotalPrice float64   `gorm:"type:decimal(10,2);not null"`
+	User       v1User    `gorm:"foreignkey:UserID"`
+	Product    v1Product `gorm:"foreignkey:ProductID"`
+	AddedAt    time.Time
+}
+
+func (v1CartItem) TableName() string { return "cart_items" }
+
+type v1Order struct {
+	ID             string   `gorm:"primary_key"`
+	UserID         uint     `gorm:"not null"`
+	Store          string   `gorm:"index"`
+	Supplier       string   `gorm:"not null"`
+	TotalPrice     float64  `gorm:"type:decimal(10,2);not null"`
+	FinalPrice     *float64 `gorm:"type:decimal(10,2)"`
+	Status         string   `gorm:"default:'pending'"`
+	Notes          string
+	ApprovalReason string
+	User           v1User            `gorm:"foreignkey:UserID"`
+	Products       []v1OrderItem     `gorm:"foreignkey:OrderID"`
+	Approvals      []v1OrderApproval `gorm:"foreignkey:OrderID"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Order) TableName() string { return "orders" }
+
+type v1OrderItem struct {
+	ID          int    `gorm:"primary_key"`
+	OrderID     string `gorm:"not null"`
+	ProductID   int    `gorm:"not null"`
+	Name        string
+	Description string
+	Count       int `gorm:"not null"`
+	Unit        string
+	Price       float64   `gorm:"type:decimal(10,2);not null"`
+	TotalPrice  float64   `gorm:"type:decimal(10,2);not null"`
+	Product     v1Product `gorm:"foreignkey:ProductID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1OrderItem) TableName() string { return "order_items" }
+
+type v1Inventory struct {
+	ProductID int       `gorm:"primary_key;autoIncrement:false"`
+	Quantity  float64   `gorm:"type:decimal(10,2);not null;default:0"`
+	Product   v1Product `gorm:"foreignkey:ProductID"`
+	UpdatedAt time.Time
+}
+
+func (v1Inventory) TableName() string { return "inventories" }
+
+type v1ParLevel struct {
+	ID        int       `gorm:"primary_key"`
+	ProductID int       `gorm:"not null;uniqueIndex:idx_par_levels_product_weekday"`
+	Weekday   int       `gorm:"not null;uniqueIndex:idx_par_levels_product_weekday"`
+	Quantity  float64   `gorm:"type:decimal(10,2);not null"`
+	Product   v1Product `gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1ParLevel) TableName() string { return "par_levels" }
+
+type v1StockMovement struct {
+	ID        int     `gorm:"primary_key"`
+	ProductID int     `gorm:"not null;index"`
+	Type      string  `gorm:"not null"`
+	Quantity  float64 `gorm:"type:decimal(10,2);not null"`
+	Reference string
+	Operator  string
+	CreatedAt time.Time
+}
+
+func (v1StockMovement) TableName() string { return "stock_movements" }
+
+type v1StockBatch struct {
+	ID         int        `gorm:"primary_key"`
+	ProductID  int        `gorm:"not null;index"`
+	OrderID    string     `gorm:"index"`
+	Quantity   float64    `gorm:"type:decimal(10,2);not null"`
+	Remaining  float64    `gorm:"type:decimal(10,2);not null"`
+	ReceivedAt time.Time  `gorm:"not null"`
+	ExpiresAt  *time.Time `gorm:"index"`
+	Product    v1Product  `gorm:"foreignkey:ProductID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (v1StockBatch) TableName() string { return "stock_batches" }
+
+type v1WasteRecord struct {
+	ID        int `gorm:"primary_key"`
+	ProductID int `gorm:"not null;index"`
+	Name      string
+	Unit      string
+	Supplier  string  `gorm:"index"`
+	Quantity  float64 `gorm:"type:decimal(10,2);not null"`
+	Reason    string  `gorm:"not null"`
+	PhotoRef  string
+	LoggedBy  string
+	UnitCost  float64 `gorm:"type:decimal(10,2)"`
+	TotalCost float64 `gorm:"type:decimal(10,2)"`
+	Notes     string
+	Product   v1Product `gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (v1WasteRecord) TableName() string { return "waste_records" }
+
+type v1StocktakeSession struct {
+	ID        int    `gorm:"primary_key"`
+	Status    string `gorm:"default:'open'"`
+	Notes     string
+	CreatedBy string
+	ClosedBy  string
+	ClosedAt  *time.Time
+	Counts    []v1StocktakeCount `gorm:"foreignkey:SessionID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1StocktakeSession) TableName() string { return "stocktake_sessions" }
+
+type v1StocktakeCount struct {
+	ID        int       `gorm:"primary_key"`
+	SessionID int       `gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	ProductID int       `gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	CountedBy string    `gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	Quantity  float64   `gorm:"type:decimal(10,2);not null"`
+	Product   v1Product `gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1StocktakeCount) TableName() string { return "stocktake_counts" }
+
+type v1OrderTemplate struct {
+	ID        int    `gorm:"primary_key"`
+	UserID    uint   `gorm:"not null"`
+	Name      string `gorm:"not null"`
+	Notes     string
+	Items     []v1OrderTemplateItem `gorm:"foreignkey:TemplateID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1OrderTemplate) TableName() string { return "order_templates" }
+
+type v1OrderTemplateItem struct {
+	ID         int       `gorm:"primary_key"`
+	TemplateID int       `gorm:"not null;index"`
+	ProductID  int       `gorm:"not null"`
+	Count      int       `gorm:"not null"`
+	Product    v1Product `gorm:"foreignkey:ProductID"`
+}
+
+func (v1OrderTemplateItem) TableName() string { return "order_template_items" }
+
+type v1StandingOrder struct {
+	ID           int    `gorm:"primary_key"`
+	TemplateID   int    `gorm:"not null;index"`
+	Frequency    string `gorm:"not null"`
+	Weekdays     string
+	IntervalDays int
+	RunAt        string `gorm:"not null"`
+	StartDate    time.Time
+	OrderStatus  string `gorm:"default:'draft'"`
+	Active       bool   `gorm:"not null"`
+	LastRunAt    *time.Time
+	NextRunAt    time.Time       `gorm:"index"`
+	Template     v1OrderTemplate `gorm:"foreignkey:TemplateID"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v1StandingOrder) TableName() string { return "standing_orders" }
+
+type v1Notification struct {
+	ID        int    `gorm:"primary_key"`
+	UserID    uint   `gorm:"not null;index"`
+	Type      string `gorm:"not null"`
+	Title     string
+	Content   string
+	Reference string
+	Read      bool `gorm:"column:is_read;default:false"`
+	CreatedAt time.Time
+}
+
+func (v1Notification) TableName() string { return "notifications" }
+
+type v1ApprovalRule struct {
+	ID            int     `gorm:"primary_key"`
+	Name          string  `gorm:"not null"`
+	MinAmount     float64 `gorm:"type:decimal(10,2)"`
+	Supplier      string
+	Category      string
+	RequesterRole string
+	Active        bool `gorm:"not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (v1ApprovalRule) TableName() string { return "approval_rules" }
+
+type v1OrderApproval struct {
+	ID        int    `gorm:"primary_key"`
+	OrderID   string `gorm:"not null;index"`
+	Action    string `gorm:"not null"`
+	Approver  string `gorm:"not null"`
+	Comment   string
+	CreatedAt time.Time
+}
+
+func (v1OrderApproval) TableName() string { return "order_approvals" }
+
+type v1Budget struct {
+	ID          int    `gorm:"primary_key"`
+	Store       string `gorm:"not null;index"`
+	Category    string
+	Period      string  `gorm:"not null"`
+	Amount      float64 `gorm:"type:decimal(10,2);not null"`
+	Enforcement string  `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1Budget) TableName() string { return "budgets" }
+
+// initialModels 基线迁移创建的数据表
+var initialModels = []interface{}{
+	&v1Product{},
+	&v1Supplier{},
+	&v1User{},
+	&v1CartItem{},
+	&v1Order{},
+	&v1OrderItem{},
+	&v1Inventory{},
+	&v1ParLevel{},
+	&v1StockMovement{},
+	&v1StockBatch{},
+	&v1WasteRecord{},
+	&v1StocktakeSession{},
+	&v1StocktakeCount{},
+	&v1OrderTemplate{},
+	&v1OrderTemplateItem{},
+	&v1StandingOrder{},
+	&v1Notification{},
+	&v1ApprovalRule{},
+	&v1OrderApproval{},
+	&v1Budget{},
+}
+
+// initialSchema 基线迁移：创建引入版本化迁移之前由 AutoMigrate 维护的全部数据表。
+// 对已有数据库执行时只补齐缺少的表和列，不影响已有数据
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(initialModels...)
+	},
+	Down: func(tx *gorm.DB) error {
+		for i := len(initialModels) - 1; i >= 0; i-- {
+			if err := tx.Migrator().DropTable(initialModels[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
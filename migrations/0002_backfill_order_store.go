@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"purches-backend/config"
+
+	"gorm.io/gorm"
+)
+
+// backfillOrderStore 门店字段加入之前创建的订单归属默认门店，使其计入门店预算。
+// 回滚时无法区分原本就属于默认门店的订单，因此不做修改
+var backfillOrderStore = Migration{
+	Version: 2,
+	Name:    "backfill_order_store",
+	Up: func(tx *gorm.DB) error {
+		return tx.Exec("UPDATE orders SET store = ? WHERE store IS NULL OR store = ''", config.GetConfig().App.DefaultStore).Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return nil
+	},
+}
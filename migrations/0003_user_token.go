@@ -0,0 +1,34 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// v3User 加入访问令牌摘要后的用户表
+type v3User struct {
+	TokenHash string `gorm:"index"`
+}
+
+func (v3User) TableName() string { return "users" }
+
+// addUserToken 用户表增加访问令牌摘要，审批等操作按令牌识别用户
+var addUserToken = Migration{
+	Version: 3,
+	Name:    "add_user_token",
+	Up: func(tx *gorm.DB) error {
+		// 与基线迁移一样只补齐缺少的列和索引
+		if !tx.Migrator().HasColumn(&v3User{}, "TokenHash") {
+			if err := tx.Migrator().AddColumn(&v3User{}, "TokenHash"); err != nil {
+				return err
+			}
+		}
+		if tx.Migrator().HasIndex(&v3User{}, "TokenHash") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&v3User{}, "TokenHash")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&v3User{}, "TokenHash"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&v3User{}, "TokenHash")
+	},
+}
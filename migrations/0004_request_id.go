@@ -0,0 +1,50 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// v4OrderApproval 加入请求ID后的审批记录表
+type v4OrderApproval struct {
+	RequestID string
+}
+
+func (v4OrderApproval) TableName() string { return "order_approvals" }
+
+// v4StockMovement 加入请求ID后的库存变动表
+type v4StockMovement struct {
+	RequestID string
+}
+
+func (v4StockMovement) TableName() string { return "stock_movements" }
+
+// v4Notification 加入请求ID后的通知表
+type v4Notification struct {
+	RequestID string
+}
+
+func (v4Notification) TableName() string { return "notifications" }
+
+// addRequestID 审批记录、库存变动和通知记录产生它们的请求ID，便于和访问日志对应
+var addRequestID = Migration{
+	Version: 4,
+	Name:    "add_request_id",
+	Up: func(tx *gorm.DB) error {
+		// 与基线迁移一样只补齐缺少的列
+		for _, table := range []interface{}{&v4OrderApproval{}, &v4StockMovement{}, &v4Notification{}} {
+			if tx.Migrator().HasColumn(table, "RequestID") {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(table, "RequestID"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&v4OrderApproval{}, &v4StockMovement{}, &v4Notification{}} {
+			if err := tx.Migrator().DropColumn(table, "RequestID"); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,194 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownMigration 数据库中记录了代码中不存在的迁移（通常是回退到了旧版本程序）
+var ErrUnknownMigration = errors.New("数据库中存在未知的迁移版本")
+
+// Migration 一个版本化的数据库迁移，Up 和 Down 在同一个事务中执行并记录版本
+// （MySQL 的 DDL 会隐式提交，失败时需要手动处理）
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt"`
+}
+
+// all 所有迁移，按版本号递增排列；新迁移追加在末尾，已发布的迁移不要修改
+var all = []Migration{
+	initialSchema,
+	backfillOrderStore,
+	addUserToken,
+	addRequestID,
+	addStocktakeExpected,
+}
+
+// All 返回所有迁移
+func All() []Migration {
+	migrations := make([]Migration, len(all))
+	copy(migrations, all)
+	return migrations
+}
+
+// Status 返回每个迁移的执行状态
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range all {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的迁移
+func Pending(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range all {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up 按版本顺序执行未执行的迁移，steps 为 0 时全部执行
+func Up(db *gorm.DB, steps int) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	if len(pending) > 0 {
+		if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+			return nil, err
+		}
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("迁移 %d_%s 执行失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚已执行的迁移，steps 为 0 时全部回滚
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if steps > 0 && steps < len(versions) {
+		versions = versions[:steps]
+	}
+
+	var done []Migration
+	for _, version := range versions {
+		migration, ok := find(version)
+		if !ok {
+			return done, fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("迁移 %d_%s 回滚失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// appliedMigrations 读取已执行的迁移，只读；迁移记录表不存在时视为没有执行过迁移
+func appliedMigrations(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int64]SchemaMigration{}, nil
+	}
+
+	var records []SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// find 按版本号查找迁移
+func find(version int64) (Migration, bool) {
+	for _, migration := range all {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
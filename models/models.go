@@ -2,29 +2,36 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Product 商品模型
 type Product struct {
-	ID          int       `json:"id" gorm:"primary_key"`
-	Name        string    `json:"name" gorm:"not null"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	Unit        string    `json:"unit" gorm:"not null"`
-	Description string    `json:"description"`
-	Supplier    string    `json:"supplier" gorm:"not null"`
-	Status      string    `json:"status" gorm:"default:available"` // available, unavailable, discontinued
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          int            `json:"id" gorm:"primary_key"`
+	ExternalID  string         `json:"externalId" gorm:"index"` // 外部编号（如价目表中的编号），导入时用于匹配
+	Name        string         `json:"name" gorm:"not null"`
+	Price       float64        `json:"price" gorm:"type:decimal(10,2);not null"`
+	Unit        string         `json:"unit" gorm:"not null"`
+	Description string         `json:"description"`
+	Supplier    string         `json:"supplier" gorm:"not null"`
+	Category    string         `json:"category" gorm:"index"`           // 商品分类，如 水产、蔬菜、蛋类
+	Status      string         `json:"status" gorm:"default:available"` // available, unavailable, discontinued
+	ShelfLife   int            `json:"shelfLife"`                       // 保质期（天），0表示不跟踪
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"deletedAt" gorm:"index"` // 归档时间，归档后不在列表中显示
 }
 
 // Supplier 供应商模型
 type Supplier struct {
-	Name          string    `json:"name" gorm:"primary_key"`
-	ContactPerson string    `json:"contactPerson"`
-	Phone         string    `json:"phone"`
-	Address       string    `json:"address"`
-	Status        string    `json:"status" gorm:"default:'active'"` // active, inactive
-	CreatedAt     time.Time `json:"createdAt"`
+	Name          string         `json:"name" gorm:"primary_key"`
+	ContactPerson string         `json:"contactPerson"`
+	Phone         string         `json:"phone"`
+	Address       string         `json:"address"`
+	Status        string         `json:"status" gorm:"default:'active'"` // active, inactive
+	CreatedAt     time.Time      `json:"createdAt"`
+	DeletedAt     gorm.DeletedAt `json:"deletedAt" gorm:"index"` // 归档时间
 }
 
 // User 用户模型
@@ -33,6 +40,8 @@ type User struct {
 	OpenID    string    `json:"openId" gorm:"unique;not null"`
 	NickName  string    `json:"nickName"`
 	AvatarURL string    `json:"avatarUrl"`
+	Role      string    `json:"role" gorm:"default:'buyer'"` // buyer, chef, owner, admin
+	TokenHash string    `json:"-" gorm:"index"`              // 访问令牌的 SHA-256 摘要
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -54,17 +63,22 @@ type CartItem struct {
 
 // Order 订单模型
 type Order struct {
-	ID         string      `json:"id" gorm:"primary_key"`
-	UserID     uint        `json:"userId" gorm:"not null"`
-	Supplier   string      `json:"supplier" gorm:"not null"`
-	TotalPrice float64     `json:"totalPrice" gorm:"type:decimal(10,2);not null"`
-	FinalPrice *float64    `json:"finalPrice" gorm:"type:decimal(10,2)"` // 可调整的最终价格
-	Status     string      `json:"status" gorm:"default:'pending'"`      // pending, confirmed, delivering, completed, cancelled
-	Notes      string      `json:"notes"`
-	User       User        `json:"user" gorm:"foreignkey:UserID"`
-	Products   []OrderItem `json:"products" gorm:"foreignkey:OrderID"`
-	CreatedAt  time.Time   `json:"createdAt"`
-	UpdatedAt  time.Time   `json:"updatedAt"`
+	ID             string          `json:"id" gorm:"primary_key"`
+	UserID         uint            `json:"userId" gorm:"not null"`
+	Store          string          `json:"store" gorm:"index"` // 下单门店
+	Supplier       string          `json:"supplier" gorm:"not null"`
+	TotalPrice     float64         `json:"totalPrice" gorm:"type:decimal(10,2);not null"`
+	FinalPrice     *float64        `json:"finalPrice" gorm:"type:decimal(10,2)"` // 可调整的最终价格
+	Status         string          `json:"status" gorm:"default:'pending'"`      // draft, awaiting_approval, rejected, pending, confirmed, delivering, completed, cancelled
+	Notes          string          `json:"notes"`
+	ApprovalReason string          `json:"approvalReason"` // 需要审批的原因
+	User           User            `json:"user" gorm:"foreignkey:UserID"`
+	Products       []OrderItem     `json:"products" gorm:"foreignkey:OrderID"`
+	Approvals      []OrderApproval `json:"approvals,omitempty" gorm:"foreignkey:OrderID"`
+	BudgetWarnings []string        `json:"budgetWarnings,omitempty" gorm:"-"` // 超出预算提醒（不入库）
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt  `json:"deletedAt" gorm:"index"` // 归档时间
 }
 
 // OrderItem 订单商品模型
@@ -84,12 +98,189 @@ type OrderItem struct {
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
+// Inventory 商品现有库存
+type Inventory struct {
+	ProductID int       `json:"productId" gorm:"primary_key;autoIncrement:false"`
+	Quantity  float64   `json:"quantity" gorm:"type:decimal(10,2);not null;default:0"`
+	Product   Product   `json:"product" gorm:"foreignkey:ProductID"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ParLevel 商品标准库存（按星期设置）
+type ParLevel struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	ProductID int       `json:"productId" gorm:"not null;uniqueIndex:idx_par_levels_product_weekday"`
+	Weekday   int       `json:"weekday" gorm:"not null;uniqueIndex:idx_par_levels_product_weekday"` // 0=周日, 1=周一 ... 6=周六
+	Quantity  float64   `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	Product   Product   `json:"product" gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// StockMovement 库存变动记录
+type StockMovement struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	ProductID int       `json:"productId" gorm:"not null;index"`
+	Type      string    `json:"type" gorm:"not null"`                        // stocktake, manual
+	Quantity  float64   `json:"quantity" gorm:"type:decimal(10,2);not null"` // 变动数量，负数为减少
+	Reference string    `json:"reference"`                                   // 关联单据，如盘点ID
+	Operator  string    `json:"operator"`
+	RequestID string    `json:"requestId,omitempty"` // 产生变动的请求ID，与日志中的 request_id 对应
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// StockBatch 库存批次（按到货批次记录保质期）
+type StockBatch struct {
+	ID         int        `json:"id" gorm:"primary_key"`
+	ProductID  int        `json:"productId" gorm:"not null;index"`
+	OrderID    string     `json:"orderId" gorm:"index"`
+	Quantity   float64    `json:"quantity" gorm:"type:decimal(10,2);not null"`  // 到货数量
+	Remaining  float64    `json:"remaining" gorm:"type:decimal(10,2);not null"` // 剩余数量
+	ReceivedAt time.Time  `json:"receivedAt" gorm:"not null"`
+	ExpiresAt  *time.Time `json:"expiresAt" gorm:"index"`
+	Product    Product    `json:"product" gorm:"foreignkey:ProductID"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// WasteRecord 报损记录
+type WasteRecord struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	ProductID int       `json:"productId" gorm:"not null;index"`
+	Name      string    `json:"name"`
+	Unit      string    `json:"unit"`
+	Supplier  string    `json:"supplier" gorm:"index"`
+	Quantity  float64   `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	Reason    string    `json:"reason" gorm:"not null"` // spoiled, expired, damaged, overproduction, other
+	PhotoRef  string    `json:"photoRef"`
+	LoggedBy  string    `json:"loggedBy"`
+	UnitCost  float64   `json:"unitCost" gorm:"type:decimal(10,2)"` // 最近一次采购价
+	TotalCost float64   `json:"totalCost" gorm:"type:decimal(10,2)"`
+	Notes     string    `json:"notes"`
+	Product   Product   `json:"product" gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+}
+
+// StocktakeSession 盘点单
+type StocktakeSession struct {
+	ID        int              `json:"id" gorm:"primary_key"`
+	Status    string           `json:"status" gorm:"default:'open'"` // open, closed
+	Notes     string           `json:"notes"`
+	CreatedBy string           `json:"createdBy"`
+	ClosedBy  string           `json:"closedBy"`
+	ClosedAt  *time.Time       `json:"closedAt"`
+	Counts    []StocktakeCount `json:"counts" gorm:"foreignkey:SessionID"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// StocktakeCount 盘点录入（每人每个商品一条）
+type StocktakeCount struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	SessionID int       `json:"sessionId" gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	ProductID int       `json:"productId" gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	CountedBy string    `json:"countedBy" gorm:"not null;uniqueIndex:idx_stocktake_counts_entry"`
+	Quantity  float64   `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	Expected  float64   `json:"expected" gorm:"type:decimal(10,2);not null;default:0"` // 提交时的账面库存
+	Product   Product   `json:"product" gorm:"foreignkey:ProductID"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// OrderTemplate 订单模板
+type OrderTemplate struct {
+	ID        int                 `json:"id" gorm:"primary_key"`
+	UserID    uint                `json:"userId" gorm:"not null"`
+	Name      string              `json:"name" gorm:"not null"`
+	Notes     string              `json:"notes"`
+	Items     []OrderTemplateItem `json:"items" gorm:"foreignkey:TemplateID"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// OrderTemplateItem 订单模板商品
+type OrderTemplateItem struct {
+	ID         int     `json:"id" gorm:"primary_key"`
+	TemplateID int     `json:"templateId" gorm:"not null;index"`
+	ProductID  int     `json:"productId" gorm:"not null"`
+	Count      int     `json:"count" gorm:"not null"`
+	Product    Product `json:"product" gorm:"foreignkey:ProductID"`
+}
+
+// StandingOrder 常规订单（按计划自动生成订单）
+type StandingOrder struct {
+	ID           int           `json:"id" gorm:"primary_key"`
+	TemplateID   int           `json:"templateId" gorm:"not null;index"`
+	Frequency    string        `json:"frequency" gorm:"not null"` // daily, weekly, interval
+	Weekdays     string        `json:"weekdays"`                  // weekly 时使用，如 "1,3,5"（0=周日）
+	IntervalDays int           `json:"intervalDays"`              // interval 时使用，每N天
+	RunAt        string        `json:"runAt" gorm:"not null"`     // 生成时间，如 "06:00"
+	StartDate    time.Time     `json:"startDate"`
+	OrderStatus  string        `json:"orderStatus" gorm:"default:'draft'"` // 生成订单的状态: draft, pending
+	Active       bool          `json:"active" gorm:"not null"`
+	LastRunAt    *time.Time    `json:"lastRunAt"`
+	NextRunAt    time.Time     `json:"nextRunAt" gorm:"index"`
+	Template     OrderTemplate `json:"template" gorm:"foreignkey:TemplateID"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// Notification 用户通知
+type Notification struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	UserID    uint      `json:"userId" gorm:"not null;index"`
+	Type      string    `json:"type" gorm:"not null"` // standing_order, standing_order_failed, approval
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Reference string    `json:"reference"` // 关联单据，如订单ID
+	Read      bool      `json:"read" gorm:"column:is_read;default:false"`
+	RequestID string    `json:"requestId,omitempty"` // 产生通知的请求ID，定时任务产生的为空
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ApprovalRule 采购审批规则，设置的条件需全部满足才触发审批
+type ApprovalRule struct {
+	ID            int       `json:"id" gorm:"primary_key"`
+	Name          string    `json:"name" gorm:"not null"`
+	MinAmount     float64   `json:"minAmount" gorm:"type:decimal(10,2)"` // 订单金额达到此值，0表示不限
+	Supplier      string    `json:"supplier"`                            // 指定供应商，空表示不限
+	Category      string    `json:"category"`                            // 订单包含此分类的商品，空表示不限
+	RequesterRole string    `json:"requesterRole"`                       // 下单人角色，空表示不限
+	Active        bool      `json:"active" gorm:"not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// OrderApproval 订单审批记录
+type OrderApproval struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	OrderID   string    `json:"orderId" gorm:"not null;index"`
+	Action    string    `json:"action" gorm:"not null"` // approved, rejected
+	Approver  string    `json:"approver" gorm:"not null"`
+	Comment   string    `json:"comment"`
+	RequestID string    `json:"requestId,omitempty"` // 审批请求的ID，与日志中的 request_id 对应
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Budget 门店采购预算
+type Budget struct {
+	ID          int       `json:"id" gorm:"primary_key"`
+	Store       string    `json:"store" gorm:"not null;index"`
+	Category    string    `json:"category"`               // 为空表示全部分类
+	Period      string    `json:"period" gorm:"not null"` // weekly, monthly
+	Amount      float64   `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Enforcement string    `json:"enforcement" gorm:"not null"` // warn(提醒), block(禁止下单)
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
 // APIResponse 统一API响应格式
 type APIResponse struct {
 	Code      int         `json:"code"`
 	Message   string      `json:"message"`
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
+	RequestID string      `json:"requestId,omitempty"` // 请求 ID，与响应头 X-Request-ID 一致，排查问题时用于查找日志
 }
 
 // 请求/响应模型
@@ -103,6 +294,28 @@ type ProductListRequest struct {
 	Status   string `form:"status"`
 }
 
+// ExportProductsRequest 导出商品目录请求，筛选条件同商品列表
+type ExportProductsRequest struct {
+	Format   string `form:"format" binding:"omitempty,oneof=json csv excel"` // 默认 json
+	Supplier string `form:"supplier"`
+	Search   string `form:"search"`
+	Status   string `form:"status"`
+}
+
+// ProductExportItem 导出的商品，格式与 docs/products.json 一致，可直接作为导入数据
+type ProductExportItem struct {
+	ID          int     `json:"id"`
+	ExternalID  string  `json:"externalId"`
+	Name        string  `json:"name"`
+	Price       float64 `json:"price"`
+	Unit        string  `json:"unit"`
+	Description string  `json:"description"`
+	Supplier    string  `json:"supplier"`
+	Category    string  `json:"category"`
+	ShelfLife   int     `json:"shelfLife"`
+	Status      string  `json:"status"`
+}
+
 // ProductListResponse 商品列表响应
 type ProductListResponse struct {
 	Products   []Product          `json:"products"`
@@ -145,6 +358,7 @@ type UpdateCartRequest struct {
 type CreateOrderRequest struct {
 	Items []OrderItemRequest `json:"items"`
 	Notes string             `json:"notes"`
+	Store string             `json:"store"` // 为空时使用默认门店
 }
 
 // OrderItemRequest 订单商品请求
@@ -164,6 +378,7 @@ type OrderListRequest struct {
 	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
 	Supplier string `form:"supplier"`
 	Status   string `form:"status"`
+	Store    string `form:"store"`
 }
 
 // OrderListResponse 订单列表响应
@@ -182,7 +397,7 @@ type SupplierSummary struct {
 
 // UpdateOrderStatusRequest 更新订单状态请求
 type UpdateOrderStatusRequest struct {
-	Status string `json:"status" binding:"required"`
+	Status string `json:"status" binding:"required,oneof=pending confirmed delivering completed cancelled"`
 	Notes  string `json:"notes"`
 }
 
@@ -224,22 +439,394 @@ type SupplierStatistics struct {
 
 // ImportProductsRequest 批量导入商品请求
 type ImportProductsRequest struct {
-	Products []ImportProduct `json:"products" binding:"required"`
+	Products           []ImportProduct `json:"products" binding:"required"`
+	DiscontinueMissing bool            `json:"discontinueMissing"` // 列表中没有的商品标记为停售
+	DryRun             bool            `json:"dryRun"`
 }
 
 // ImportProduct 导入商品
 type ImportProduct struct {
+	ExternalID  string  `json:"externalId"`
 	Name        string  `json:"name" binding:"required"`
 	Price       float64 `json:"price" binding:"required,min=0"`
 	Unit        string  `json:"unit" binding:"required"`
-	Description string  `json:"description"`
+	Description *string `json:"description"` // 以下可选字段为 nil（文件中没有该列或 JSON 中没有该键）时不修改
 	Supplier    string  `json:"supplier" binding:"required"`
+	Category    *string `json:"category"`
+	ShelfLife   *int    `json:"shelfLife" binding:"omitempty,min=0"`
+	Status      string  `json:"status" binding:"omitempty,oneof=available unavailable discontinued"` // 为空时不修改
+}
+
+// ProductImportRowError 导入文件的行校验错误
+type ProductImportRowError struct {
+	Row     int    `json:"row"` // 文件中的行号（表头为第1行）
+	Field   string `json:"field"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// ProductImportReport 商品导入报告
+type ProductImportReport struct {
+	DryRun    bool                    `json:"dryRun"`
+	TotalRows int                     `json:"totalRows"`
+	ValidRows int                     `json:"validRows"`
+	Errors    []ProductImportRowError `json:"errors"`
+	// 文件中尚不存在的供应商。需要在 newSuppliers 参数中确认后才会创建，未确认的作为行错误报告
+	NewSuppliers []string            `json:"newSuppliers"`
+	Imported     int                 `json:"imported"`
+	Summary      *ProductSyncSummary `json:"summary,omitempty"` // 校验通过时的同步差异（试运行时为预览）
+}
+
+// ProductSyncSummary 商品目录同步差异
+type ProductSyncSummary struct {
+	Added        int                 `json:"added"`
+	Updated      int                 `json:"updated"`
+	Discontinued int                 `json:"discontinued"`
+	Unchanged    int                 `json:"unchanged"`
+	Skipped      int                 `json:"skipped"`                // 商品或供应商已归档而跳过，需要先恢复
+	NewSuppliers []string            `json:"newSuppliers,omitempty"` // 新建的供应商（试运行时为将要新建的供应商）
+	Changes      []ProductSyncChange `json:"changes"`                // 不包含未变化的商品
+}
+
+// ProductSyncChange 单个商品的变化
+type ProductSyncChange struct {
+	ProductID int      `json:"productId"`
+	Name      string   `json:"name"`
+	Supplier  string   `json:"supplier"`
+	Action    string   `json:"action"`           // added, updated, discontinued, skipped
+	Fields    []string `json:"fields,omitempty"` // 更新的字段
+	Reason    string   `json:"reason,omitempty"` // 跳过的原因
 }
 
 // ExportOrdersRequest 导出订单请求
 type ExportOrdersRequest struct {
 	Format   string `form:"format" binding:"required,oneof=excel csv"`
+	DateFrom string `form:"dateFrom"` // YYYY-MM-DD
+	DateTo   string `form:"dateTo"`   // YYYY-MM-DD，包含当天
+	Supplier string `form:"supplier"`
+	Store    string `form:"store"`
+}
+
+// UpdateInventoryRequest 更新库存请求
+type UpdateInventoryRequest struct {
+	Quantity float64 `json:"quantity" binding:"min=0"`
+}
+
+// SetParLevelsRequest 设置商品标准库存请求
+type SetParLevelsRequest struct {
+	ProductID int               `json:"productId" binding:"required"`
+	Levels    []ParLevelRequest `json:"levels" binding:"required,dive"`
+}
+
+// ParLevelRequest 单日标准库存
+type ParLevelRequest struct {
+	Weekday  int     `json:"weekday" binding:"min=0,max=6"`
+	Quantity float64 `json:"quantity" binding:"min=0"`
+}
+
+// SuggestCartRequest 生成建议采购请求
+type SuggestCartRequest struct {
+	Weekday *int `json:"weekday" binding:"omitempty,min=0,max=6"` // 默认当天
+}
+
+// SuggestCartResponse 建议采购响应（按供应商分组）
+type SuggestCartResponse struct {
+	Weekday   int                   `json:"weekday"`
+	Suppliers []SuggestedOrderGroup `json:"suppliers"`
+	Cart      *CartResponse         `json:"cart"`
+}
+
+// SuggestedOrderGroup 单个供应商的建议采购
+type SuggestedOrderGroup struct {
+	Supplier   string          `json:"supplier"`
+	Items      []SuggestedItem `json:"items"`
+	TotalPrice float64         `json:"totalPrice"`
+}
+
+// SuggestedItem 建议采购商品
+type SuggestedItem struct {
+	ProductID  int     `json:"productId"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	ParLevel   float64 `json:"parLevel"`
+	OnHand     float64 `json:"onHand"`
+	OnOrder    float64 `json:"onOrder"`
+	Count      int     `json:"count"`
+	Price      float64 `json:"price"`
+	TotalPrice float64 `json:"totalPrice"`
+}
+
+// CreateStocktakeRequest 创建盘点单请求
+type CreateStocktakeRequest struct {
+	Notes     string `json:"notes"`
+	CreatedBy string `json:"createdBy"`
+}
+
+// SubmitStocktakeCountsRequest 提交盘点数量请求
+type SubmitStocktakeCountsRequest struct {
+	CountedBy string                  `json:"countedBy" binding:"required"`
+	Counts    []StocktakeCountRequest `json:"counts" binding:"required,dive"`
+}
+
+// StocktakeCountRequest 单个商品盘点数量
+type StocktakeCountRequest struct {
+	ProductID int     `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"min=0"`
+}
+
+// CloseStocktakeRequest 结束盘点请求
+type CloseStocktakeRequest struct {
+	ClosedBy string `json:"closedBy"`
+	Force    bool   `json:"force"` // 有数量不一致的商品时仍然结束，按最近一次的数量调整
+}
+
+// StocktakeVarianceResponse 盘点差异报告
+type StocktakeVarianceResponse struct {
+	Session            StocktakeSession    `json:"session"`
+	Items              []StocktakeVariance `json:"items"`
+	TotalVarianceValue float64             `json:"totalVarianceValue"`
+}
+
+// StocktakeVariance 单个商品盘点差异
+type StocktakeVariance struct {
+	ProductID     int                `json:"productId"`
+	Name          string             `json:"name"`
+	Unit          string             `json:"unit"`
+	Supplier      string             `json:"supplier"`
+	Expected      float64            `json:"expected"` // 最近一次提交时的账面库存
+	Counted       float64            `json:"counted"`  // 最近一次提交的盘点数量
+	Variance      float64            `json:"variance"`
+	UnitPrice     float64            `json:"unitPrice"` // 最近一次采购价
+	VarianceValue float64            `json:"varianceValue"`
+	CountedBy     []string           `json:"countedBy"` // 按提交时间排列
+	Counts        map[string]float64 `json:"counts"`    // 每人提交的数量
+	Disputed      bool               `json:"disputed"`  // 多人盘点的数量不一致，需要复核
+}
+
+// ReceiveOrderRequest 订单收货请求
+type ReceiveOrderRequest struct {
+	ReceivedBy string               `json:"receivedBy"`
+	Items      []ReceiveItemRequest `json:"items" binding:"omitempty,dive"` // 为空时按订单数量全部收货
+}
+
+// ReceiveItemRequest 收货商品
+type ReceiveItemRequest struct {
+	ProductID int        `json:"productId" binding:"required"`
+	Quantity  float64    `json:"quantity" binding:"min=0"`
+	ExpiresAt *time.Time `json:"expiresAt"` // 为空时按商品保质期计算
+}
+
+// ReceiveOrderResponse 订单收货响应
+type ReceiveOrderResponse struct {
+	Order   Order        `json:"order"`
+	Batches []StockBatch `json:"batches"`
+}
+
+// ConsumeStockRequest 出库请求
+type ConsumeStockRequest struct {
+	ProductID int     `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	Operator  string  `json:"operator"`
+}
+
+// ConsumeStockResponse 出库响应
+type ConsumeStockResponse struct {
+	Inventory   Inventory         `json:"inventory"`
+	Allocations []BatchAllocation `json:"allocations"`
+}
+
+// BatchAllocation 批次扣减明细
+type BatchAllocation struct {
+	BatchID   int        `json:"batchId"`
+	Quantity  float64    `json:"quantity"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// CreateWasteRequest 报损请求
+type CreateWasteRequest struct {
+	ProductID int     `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	Reason    string  `json:"reason" binding:"required,oneof=spoiled expired damaged overproduction other"`
+	PhotoRef  string  `json:"photoRef"`
+	LoggedBy  string  `json:"loggedBy" binding:"required"`
+	Notes     string  `json:"notes"`
+}
+
+// WasteListRequest 报损记录查询请求
+type WasteListRequest struct {
+	ProductID int    `form:"productId"`
+	Supplier  string `form:"supplier"`
+	Reason    string `form:"reason"`
+	DateFrom  string `form:"dateFrom"`
+	DateTo    string `form:"dateTo"`
+}
+
+// WasteReportRequest 报损成本报表请求
+type WasteReportRequest struct {
+	GroupBy  string `form:"groupBy" binding:"omitempty,oneof=product supplier week"`
+	Supplier string `form:"supplier"`
 	DateFrom string `form:"dateFrom"`
 	DateTo   string `form:"dateTo"`
-	Supplier string `form:"supplier"`
+}
+
+// WasteReportResponse 报损成本报表
+type WasteReportResponse struct {
+	GroupBy   string           `json:"groupBy"`
+	Rows      []WasteReportRow `json:"rows"`
+	TotalCost float64          `json:"totalCost"`
+}
+
+// WasteReportRow 报损成本汇总行
+type WasteReportRow struct {
+	Key         string  `json:"key"` // 商品ID、供应商名称或周（如 2025-W37）
+	Name        string  `json:"name"`
+	Supplier    string  `json:"supplier,omitempty"`
+	Unit        string  `json:"unit,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	RecordCount int     `json:"recordCount"`
+	TotalCost   float64 `json:"totalCost"`
+}
+
+// UpdateOrderItemsRequest 修改订单商品请求（仅草稿订单）
+type UpdateOrderItemsRequest struct {
+	Items []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// OrderTemplateRequest 创建/更新订单模板请求
+type OrderTemplateRequest struct {
+	Name  string             `json:"name" binding:"required"`
+	Notes string             `json:"notes"`
+	Items []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// StandingOrderRequest 创建/更新常规订单请求
+type StandingOrderRequest struct {
+	TemplateID   int    `json:"templateId" binding:"required"`
+	Frequency    string `json:"frequency" binding:"required,oneof=daily weekly interval"`
+	Weekdays     []int  `json:"weekdays" binding:"omitempty,dive,min=0,max=6"`
+	IntervalDays int    `json:"intervalDays" binding:"omitempty,min=1"`
+	RunAt        string `json:"runAt" binding:"required"`
+	StartDate    string `json:"startDate"` // YYYY-MM-DD，默认今天
+	OrderStatus  string `json:"orderStatus" binding:"omitempty,oneof=draft pending"`
+	Active       *bool  `json:"active"`
+}
+
+// StandingOrderRunResult 常规订单生成结果
+type StandingOrderRunResult struct {
+	StandingOrderID int      `json:"standingOrderId"`
+	Orders          []Order  `json:"orders"`
+	Skipped         []string `json:"skipped"`         // 已下架而跳过的商品
+	Error           string   `json:"error,omitempty"` // 生成失败的原因，失败时不生成订单
+}
+
+// ReorderResponse 再来一单响应
+type ReorderResponse struct {
+	OrderID         string        `json:"orderId"`
+	Added           []ReorderItem `json:"added"`
+	Skipped         []ReorderItem `json:"skipped"`
+	PriceDifference float64       `json:"priceDifference"` // 按当前价格与原订单相比的总差额
+	Cart            *CartResponse `json:"cart"`
+}
+
+// ReorderItem 再来一单商品
+type ReorderItem struct {
+	ProductID     int     `json:"productId"`
+	Name          string  `json:"name"`
+	Count         int     `json:"count"`
+	Unit          string  `json:"unit"`
+	OriginalPrice float64 `json:"originalPrice"`
+	CurrentPrice  float64 `json:"currentPrice"`
+	PriceChange   float64 `json:"priceChange"` // 单价变化
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// ApprovalRuleRequest 创建/更新审批规则请求
+type ApprovalRuleRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	MinAmount     float64 `json:"minAmount" binding:"min=0"`
+	Supplier      string  `json:"supplier"`
+	Category      string  `json:"category"`
+	RequesterRole string  `json:"requesterRole"`
+	Active        *bool   `json:"active"`
+}
+
+// ApproveOrderRequest 审批订单请求，审批人为令牌对应的用户
+type ApproveOrderRequest struct {
+	Comment string `json:"comment"`
+}
+
+// UpdateUserRoleRequest 设置用户角色请求
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=buyer chef owner admin"`
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	OpenID   string `json:"openId" binding:"required"`
+	NickName string `json:"nickName"`
+	Role     string `json:"role" binding:"omitempty,oneof=buyer chef owner admin"` // 为空时为 buyer
+}
+
+// DailyPurchaseOrdersRequest 每日采购单合集请求
+type DailyPurchaseOrdersRequest struct {
+	Date  string `form:"date" binding:"required"` // YYYY-MM-DD
+	Store string `form:"store"`
+}
+
+// BudgetRequest 创建/更新预算请求
+type BudgetRequest struct {
+	Store       string  `json:"store"` // 为空时使用默认门店
+	Category    string  `json:"category"`
+	Period      string  `json:"period" binding:"required,oneof=weekly monthly"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Enforcement string  `json:"enforcement" binding:"omitempty,oneof=warn block"`
+}
+
+// BudgetUsageRequest 预算使用情况查询请求
+type BudgetUsageRequest struct {
+	Store string `form:"store"`
+	Date  string `form:"date"` // 统计该日期所在周期，默认今天
+}
+
+// BudgetUsage 预算使用情况
+type BudgetUsage struct {
+	Budget      Budget    `json:"budget"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	Committed   float64   `json:"committed"`   // 在途订单金额
+	Actual      float64   `json:"actual"`      // 已收货订单金额（有最终价格时按最终价格）
+	Remaining   float64   `json:"remaining"`   // 剩余预算
+	Utilization float64   `json:"utilization"` // 使用率（%）
+	Exceeded    bool      `json:"exceeded"`
+}
+
+// ArchiveRequest 归档/恢复请求，操作人必须是店主或管理员
+type ArchiveRequest struct {
+	Operator string `form:"operator" binding:"required"` // 操作人 openId
+}
+
+// ArchiveListRequest 归档记录查询请求
+type ArchiveListRequest struct {
+	Operator string `form:"operator" binding:"required"`
+	Type     string `form:"type" binding:"omitempty,oneof=products suppliers orders"` // 为空时返回全部
+}
+
+// ArchiveResponse 已归档的记录，按归档时间倒序
+type ArchiveResponse struct {
+	Products  []Product  `json:"products"`
+	Suppliers []Supplier `json:"suppliers"`
+	Orders    []Order    `json:"orders"`
+}
+
+// HealthCheck 就绪检查中的单项检查结果
+type HealthCheck struct {
+	Status  string      `json:"status"` // ok, warn（不影响就绪）, fail, skipped
+	Message string      `json:"message,omitempty"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ReadinessResponse 就绪检查结果，任一检查失败时为 degraded
+type ReadinessResponse struct {
+	Status string                 `json:"status"` // ready, degraded
+	Checks map[string]HealthCheck `json:"checks"`
 }
@@ -0,0 +1,169 @@
+// Package pdf 生成简单的中文 PDF 文档（纯 Go 实现，不嵌入字体）。
+//
+// 文字使用 PDF 阅读器自带的 STSong-Light（宋体）CJK 字体和 UniGB-UCS2-H 编码，
+// 只支持基本多文种平面内的字符。
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 纸张尺寸（单位：点）
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+// Document PDF 文档
+type Document struct {
+	pages []*Page
+}
+
+// Page PDF 页面，坐标原点在左上角，y 向下增加
+type Page struct {
+	content bytes.Buffer
+}
+
+// New 创建空白文档
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage 添加新页面
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// PageCount 页数
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// Text 在 (x, y) 处写入一行文字，y 为文字基线位置
+func (p *Page) Text(x, y, size float64, text string) {
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td <%s> Tj ET\n",
+		formatNumber(size), formatNumber(x), formatNumber(PageHeight-y), encodeText(text))
+}
+
+// TextRight 文字右对齐到 x
+func (p *Page) TextRight(x, y, size float64, text string) {
+	p.Text(x-TextWidth(text, size), y, size, text)
+}
+
+// Line 画线
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		formatNumber(width), formatNumber(x1), formatNumber(PageHeight-y1), formatNumber(x2), formatNumber(PageHeight-y2))
+}
+
+// TextWidth 计算文字宽度：ASCII 字符半角，其余全角
+func TextWidth(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width * size
+}
+
+// WrapText 按宽度折行
+func WrapText(text string, size, maxWidth float64) []string {
+	var lines []string
+	var line []rune
+	var width float64
+
+	for _, r := range text {
+		if r == '\n' {
+			lines = append(lines, string(line))
+			line, width = line[:0], 0
+			continue
+		}
+		w := runeWidth(r) * size
+		if width+w > maxWidth && len(line) > 0 {
+			lines = append(lines, string(line))
+			line, width = line[:0], 0
+		}
+		line = append(line, r)
+		width += w
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// runeWidth 字符宽度（em）
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+// encodeText 将文字编码为 UTF-16BE 十六进制字符串
+func encodeText(text string) string {
+	var buf bytes.Buffer
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&buf, "%04X", unit)
+	}
+	return buf.String()
+}
+
+// formatNumber 格式化坐标
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// WriteTo 输出 PDF 文件
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	addObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// 对象 1-5：目录、页面树、字体；之后每页两个对象（页面和内容流）
+	const firstPageObject = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObject+i*2)
+	}
+
+	addObject("<< /Type /Catalog /Pages 2 0 R >>")
+	addObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	addObject("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	addObject("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+		"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500 814 939 500 7716 7810 500] >>")
+	addObject("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, page := range d.pages {
+		addObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			formatNumber(PageWidth), formatNumber(PageHeight), firstPageObject+i*2+1))
+		addObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
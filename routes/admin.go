@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"purches-backend/config"
+	"purches-backend/controllers"
+	"purches-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes 注册用户管理路由，需要 X-Admin-Token 请求头。未配置 app.admin_token 时不注册，返回是否已注册
+func SetupAdminRoutes(r *gin.Engine, cfg config.AppConfig, approvalController *controllers.ApprovalController) bool {
+	if cfg.AdminToken == "" {
+		return false
+	}
+
+	admin := r.Group("/v1/users", middleware.AdminToken(cfg.AdminToken, "app.admin_token"))
+	{
+		admin.PUT("/:openId/role", approvalController.UpdateUserRole)
+		admin.POST("/:openId/token", approvalController.IssueUserToken)
+	}
+	return true
+}
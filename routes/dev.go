@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"fmt"
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/middleware"
+	"purches-backend/services"
+	"purches-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDevRoutes 注册开发工具路由。生产环境或未配置 app.dev_admin_token 时不注册，返回是否已注册
+func SetupDevRoutes(r *gin.Engine, cfg config.AppConfig, productService *services.ProductService) bool {
+	if cfg.IsProduction() || cfg.DevAdminToken == "" {
+		return false
+	}
+
+	dev := r.Group("/dev", middleware.AdminToken(cfg.DevAdminToken, "app.dev_admin_token"))
+	{
+		// 重置数据（清空所有数据表）
+		dev.POST("/reset-data", func(c *gin.Context) {
+			if err := database.ResetData(); err != nil {
+				utils.ResponseError(c, 500, "数据重置失败", err.Error())
+				return
+			}
+			utils.ResponseOK(c, "数据重置完成", "重新初始化了采购订单系统测试数据")
+		})
+
+		// 从 docs/products.json 同步商品目录
+		dev.POST("/import-products-json", func(c *gin.Context) {
+			summary, err := productService.ImportProductsFromJSON("docs/products.json")
+			if err != nil {
+				utils.ResponseError(c, 500, "无法读取JSON文件", err.Error())
+				return
+			}
+
+			utils.ResponseOK(c, "JSON数据导入完成", gin.H{
+				"summary": summary,
+				"message": fmt.Sprintf("新增 %d 种，更新 %d 种，停售 %d 种，未变化 %d 种，跳过已归档 %d 种",
+					summary.Added, summary.Updated, summary.Discontinued, summary.Unchanged, summary.Skipped),
+			})
+		})
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"purches-backend/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthRoutes 注册存活检查 /livez 和就绪检查 /readyz，不带 /v1 前缀，供 systemd、负载均衡或监控探测
+func SetupHealthRoutes(r *gin.Engine, healthController *controllers.HealthController) {
+	r.GET("/livez", healthController.Livez)
+	r.GET("/readyz", healthController.Readyz)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"purches-backend/config"
+	"purches-backend/metrics"
+	"purches-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMetricsRoutes 注册 Prometheus 指标接口 /metrics，未启用时不注册，返回是否已注册。
+// 配置了 metrics.token 时需要 Bearer 令牌
+func SetupMetricsRoutes(r *gin.Engine, cfg config.MetricsConfig) bool {
+	if !cfg.Enabled {
+		return false
+	}
+
+	handlers := []gin.HandlerFunc{gin.WrapH(metrics.Handler())}
+	if cfg.Token != "" {
+		handlers = append([]gin.HandlerFunc{middleware.BearerToken(cfg.Token)}, handlers...)
+	}
+	r.GET("/metrics", handlers...)
+	return true
+}
@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"purches-backend/controllers"
+	"purches-backend/middleware"
+	"purches-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRoutes 注册业务 API 路由
+func SetupRoutes(
+	r *gin.Engine,
+	productController *controllers.ProductController,
+	cartController *controllers.CartController,
+	orderController *controllers.OrderController,
+	supplierController *controllers.SupplierController,
+	inventoryController *controllers.InventoryController,
+	stocktakeController *controllers.StocktakeController,
+	wasteController *controllers.WasteController,
+	templateController *controllers.OrderTemplateController,
+	notificationController *controllers.NotificationController,
+	approvalController *controllers.ApprovalController,
+	budgetController *controllers.BudgetController,
+	archiveController *controllers.ArchiveController,
+) {
+	// API路由组
+	v1 := r.Group("/v1")
+	{
+		// 商品管理 API
+		v1.GET("/products", productController.GetProducts)
+		v1.GET("/products/export", middleware.NoWriteTimeout(), productController.ExportProducts)
+		v1.GET("/products/:productId", productController.GetProduct)
+		v1.POST("/products/import", productController.ImportProducts)
+		v1.POST("/products/import/file", productController.ImportProductsFile)
+
+		// 购物车管理 API
+		v1.GET("/cart", cartController.GetCart)
+		v1.POST("/cart/items", cartController.AddToCart)
+		v1.PUT("/cart/items/:itemId", cartController.UpdateCartItem)
+		v1.DELETE("/cart/items/:itemId", cartController.DeleteCartItem)
+		v1.DELETE("/cart", cartController.ClearCart)
+		v1.POST("/cart/suggest", cartController.SuggestCart)
+
+		// 订单管理 API
+		v1.POST("/orders", orderController.CreateOrder)
+		v1.GET("/orders", orderController.GetOrders)
+		v1.GET("/orders/:orderId", orderController.GetOrder)
+		v1.PUT("/orders/:orderId/status", orderController.UpdateOrderStatus)
+		v1.PUT("/orders/:orderId/final-price", orderController.UpdateOrderFinalPrice)
+		v1.POST("/orders/:orderId/receive", orderController.ReceiveOrder)
+		v1.PUT("/orders/:orderId/items", orderController.UpdateDraftOrderItems)
+		v1.POST("/orders/:orderId/submit", orderController.SubmitDraftOrder)
+		v1.POST("/orders/:orderId/reorder", cartController.ReorderToCart)
+		// 导出和 PDF 的生成时间随数据量增长，不受 server.write_timeout 限制
+		v1.GET("/orders/export", middleware.NoWriteTimeout(), orderController.ExportOrders)
+		v1.GET("/orders/daily-pdf", middleware.NoWriteTimeout(), orderController.GetDailyPurchaseOrdersPDF)
+		v1.GET("/orders/:orderId/pdf", middleware.NoWriteTimeout(), orderController.GetPurchaseOrderPDF)
+
+		// 供应商管理 API
+		v1.GET("/suppliers", supplierController.GetSuppliers)
+		v1.GET("/suppliers/:supplierName", supplierController.GetSupplier)
+		v1.GET("/suppliers/:supplierName/products", supplierController.GetSupplierProducts)
+		v1.GET("/suppliers/:supplierName/orders", supplierController.GetSupplierOrders)
+
+		// 库存管理 API
+		v1.GET("/inventory", inventoryController.GetInventory)
+		v1.PUT("/inventory/:productId", inventoryController.UpdateInventory)
+		v1.GET("/inventory/movements", inventoryController.GetMovements)
+		v1.GET("/inventory/batches", inventoryController.GetBatches)
+		v1.GET("/inventory/expiring", inventoryController.GetExpiringBatches)
+		v1.POST("/inventory/consume", inventoryController.ConsumeStock)
+		v1.GET("/par-levels", inventoryController.GetParLevels)
+		v1.PUT("/par-levels", inventoryController.SetParLevels)
+		v1.DELETE("/par-levels/:parLevelId", inventoryController.DeleteParLevel)
+
+		// 盘点 API
+		v1.POST("/stocktakes", stocktakeController.CreateStocktake)
+		v1.GET("/stocktakes", stocktakeController.GetStocktakes)
+		v1.GET("/stocktakes/:stocktakeId", stocktakeController.GetStocktake)
+		v1.POST("/stocktakes/:stocktakeId/counts", stocktakeController.SubmitCounts)
+		v1.GET("/stocktakes/:stocktakeId/variances", stocktakeController.GetVariances)
+		v1.POST("/stocktakes/:stocktakeId/close", stocktakeController.CloseStocktake)
+
+		// 报损 API
+		v1.POST("/waste", wasteController.LogWaste)
+		v1.GET("/waste", wasteController.GetWasteRecords)
+		v1.GET("/waste/report", wasteController.GetWasteReport)
+
+		// 订单模板与常规订单 API
+		v1.POST("/order-templates", templateController.CreateTemplate)
+		v1.GET("/order-templates", templateController.GetTemplates)
+		v1.GET("/order-templates/:templateId", templateController.GetTemplate)
+		v1.PUT("/order-templates/:templateId", templateController.UpdateTemplate)
+		v1.DELETE("/order-templates/:templateId", templateController.DeleteTemplate)
+		v1.POST("/order-templates/:templateId/orders", templateController.CreateOrdersFromTemplate)
+		v1.POST("/standing-orders", templateController.CreateStandingOrder)
+		v1.GET("/standing-orders", templateController.GetStandingOrders)
+		v1.PUT("/standing-orders/:standingOrderId", templateController.UpdateStandingOrder)
+		v1.DELETE("/standing-orders/:standingOrderId", templateController.DeleteStandingOrder)
+		v1.POST("/standing-orders/:standingOrderId/run", templateController.RunStandingOrder)
+
+		// 通知 API
+		v1.GET("/notifications", notificationController.GetNotifications)
+		v1.PUT("/notifications/:notificationId/read", notificationController.MarkRead)
+
+		// 审批 API
+		v1.POST("/orders/:orderId/approve", approvalController.RequireUser, approvalController.ApproveOrder)
+		v1.POST("/orders/:orderId/reject", approvalController.RequireUser, approvalController.RejectOrder)
+		v1.GET("/approvals/pending", approvalController.GetPendingApprovals)
+		v1.GET("/approval-rules", approvalController.GetRules)
+		// 审批规则和预算决定订单是否需要审批、是否被拦截，只有店主或管理员可以修改
+		approverOnly := v1.Group("", approvalController.RequireUser, approvalController.RequireApprover)
+		approverOnly.POST("/approval-rules", approvalController.CreateRule)
+		approverOnly.PUT("/approval-rules/:ruleId", approvalController.UpdateRule)
+		approverOnly.DELETE("/approval-rules/:ruleId", approvalController.DeleteRule)
+
+		// 预算 API
+		v1.GET("/budgets", budgetController.GetBudgets)
+		approverOnly.POST("/budgets", budgetController.CreateBudget)
+		approverOnly.PUT("/budgets/:budgetId", budgetController.UpdateBudget)
+		approverOnly.DELETE("/budgets/:budgetId", budgetController.DeleteBudget)
+
+		// 归档 API
+		v1.GET("/archive", archiveController.GetArchive)
+		v1.DELETE("/products/:productId", archiveController.ArchiveProduct)
+		v1.POST("/products/:productId/restore", archiveController.RestoreProduct)
+		v1.DELETE("/suppliers/:supplierName", archiveController.ArchiveSupplier)
+		v1.POST("/suppliers/:supplierName/restore", archiveController.RestoreSupplier)
+		v1.DELETE("/orders/:orderId", archiveController.ArchiveOrder)
+		v1.POST("/orders/:orderId/restore", archiveController.RestoreOrder)
+
+		// 健康检查
+		v1.GET("/health", func(c *gin.Context) {
+			utils.ResponseOK(c, "服务正常", "OK")
+		})
+	}
+}
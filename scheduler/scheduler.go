@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrInvalidInterval 任务间隔必须大于 0
+var ErrInvalidInterval = errors.New("定时任务间隔必须大于 0")
+
+// JobFunc 定时任务函数
+type JobFunc func(ctx context.Context) error
+
+// JobStatus 定时任务运行状态
+type JobStatus struct {
+	Name            string     `json:"name"`
+	Interval        string     `json:"interval"`
+	IntervalSeconds float64    `json:"intervalSeconds"`
+	StartedAt       *time.Time `json:"startedAt"` // 调度器启动时间，任务还没有运行完成时用于判断是否卡住
+	LastRunAt       *time.Time `json:"lastRunAt"` // 最近一次运行完成的时间
+	LastError       string     `json:"lastError"`
+	RunCount        int        `json:"runCount"`
+}
+
+// Overdue 任务距离上次运行完成（从未完成时从调度器启动）超过两个间隔，
+// 说明任务卡住或调度停止
+func (js JobStatus) Overdue(now time.Time) bool {
+	last := js.LastRunAt
+	if last == nil {
+		last = js.StartedAt
+	}
+	if last == nil || js.IntervalSeconds <= 0 {
+		return false
+	}
+	interval := time.Duration(js.IntervalSeconds * float64(time.Second))
+	return now.Sub(*last) > 2*interval
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+}
+
+// Scheduler 进程内定时任务调度器
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []job
+	status  map[string]*JobStatus
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running bool
+	logger  *slog.Logger
+}
+
+// New 创建调度器
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		status: make(map[string]*JobStatus),
+		logger: logger,
+	}
+}
+
+// Every 注册按固定间隔运行的任务，需在 Start 之前调用。间隔不大于 0 时不注册并返回 ErrInvalidInterval
+func (s *Scheduler) Every(name string, interval time.Duration, fn JobFunc) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w: %s 为 %s", ErrInvalidInterval, name, interval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, job{name: name, interval: interval, fn: fn})
+	s.status[name] = &JobStatus{Name: name, Interval: interval.String(), IntervalSeconds: interval.Seconds()}
+	return nil
+}
+
+// Start 启动所有任务，每个任务启动时先运行一次
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.running = true
+
+	now := time.Now()
+	for _, j := range s.jobs {
+		s.status[j.name].StartedAt = &now
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Stop 停止调度并等待正在运行的任务结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.running = false
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// Running 调度器是否在运行
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Status 获取所有任务的运行状态
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.status))
+	for _, status := range s.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// loop 按间隔循环运行任务
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		s.run(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run 运行一次任务并记录结果，任务 panic 不会影响调度器
+func (s *Scheduler) run(ctx context.Context, j job) {
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = j.fn(ctx)
+	}()
+
+	now := time.Now()
+	s.mu.Lock()
+	status := s.status[j.name]
+	status.LastRunAt = &now
+	status.RunCount++
+	status.LastError = ""
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "定时任务执行失败", "job", j.name, "error", err)
+	}
+}
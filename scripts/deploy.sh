@@ -18,6 +18,7 @@ cd "$(dirname "$0")/.."
 
 # 项目配置
 SERVICE_PORT="8080"
+SHUTDOWN_TIMEOUT=30
 LOG_FILE="server.log"
 
 # 1. 检查是否在正确目录
@@ -26,48 +27,59 @@ if [ ! -f "main.go" ]; then
     exit 1
 fi
 
-# 2. 拉取最新代码
+# 2. 备份数据库（在操作 Git 之前，服务运行时在线备份）
+if [ -f "purches.db" ]; then
+    echo -e "${YELLOW}💾 备份数据库...${NC}"
+    if [ -x "purches-backend" ]; then
+        ./purches-backend backup
+    else
+        mkdir -p backups
+        cp purches.db "backups/purches_$(date +%Y%m%d_%H%M%S).db"
+    fi
+fi
+
+# 3. 拉取最新代码
 echo -e "${YELLOW}📦 拉取最新代码...${NC}"
 git fetch origin
 
-# 清理Git状态，避免pull失败
+# 清理Git状态，避免pull失败（数据库和备份不参与 stash，否则会被回退到仓库中的版本）
 echo -e "${YELLOW}🧹 清理Git状态...${NC}"
-git add . 2>/dev/null || true
-git stash 2>/dev/null || true
+git stash push --include-untracked -- . ':(exclude)purches.db*' ':(exclude)backups' 2>/dev/null || true
 
 git pull origin main
 
-# 3. 停止旧的服务
+# 4. 停止旧的服务
 echo -e "${YELLOW}⏹️  停止旧服务...${NC}"
 pkill -f "go run main.go" || echo "没有运行中的go run服务"
-pkill -f "purches-backend" || echo "没有运行中的二进制服务"
+pkill -TERM -x "purches-backend" || echo "没有运行中的二进制服务"
 
-# 处理端口冲突
+# 等待进行中的请求完成后进程自行退出（与 server.shutdown_timeout 一致）
+for i in $(seq 1 ${SHUTDOWN_TIMEOUT}); do
+    pgrep -x "purches-backend" > /dev/null || break
+    sleep 1
+done
+
+# 处理端口冲突（超时仍未退出时强制结束）
 echo -e "${YELLOW}🔓 释放端口${SERVICE_PORT}...${NC}"
 fuser -k ${SERVICE_PORT}/tcp 2>/dev/null || echo "端口${SERVICE_PORT}未被占用"
 
-# 等待进程完全停止
-sleep 2
-
-# 4. 清理旧的编译文件
+# 5. 清理旧的编译文件
 echo -e "${YELLOW}🧹 清理旧文件...${NC}"
 rm -f purches-backend
 
-# 5. 安装/更新依赖
+# 6. 安装/更新依赖
 echo -e "${YELLOW}📚 更新依赖...${NC}"
 go mod tidy
 
-# 6. 编译项目
+# 7. 编译项目
 echo -e "${YELLOW}🔨 编译项目...${NC}"
-go build -o purches-backend main.go
+go build -o purches-backend .
 
-# 7. 备份数据库（如果存在）
-if [ -f "purches.db" ]; then
-    echo -e "${YELLOW}💾 备份数据库...${NC}"
-    cp purches.db "purches.db.backup.$(date +%Y%m%d_%H%M%S)"
-fi
+# 8. 执行数据库迁移（生产环境存在未执行的迁移时服务拒绝启动）
+echo -e "${YELLOW}🗄️  执行数据库迁移...${NC}"
+./purches-backend migrate up
 
-# 8. 启动新服务
+# 9. 启动新服务
 echo -e "${YELLOW}🚀 启动新服务...${NC}"
 nohup ./purches-backend > $LOG_FILE 2>&1 &
 
@@ -82,7 +94,7 @@ else
     exit 1
 fi
 
-# 9. 健康检查
+# 10. 健康检查
 echo -e "${YELLOW}🏥 健康检查...${NC}"
 sleep 3
 
@@ -94,24 +106,26 @@ else
     exit 1
 fi
 
-# 检查API是否正常
+# 检查服务是否就绪（数据库、迁移、磁盘空间、定时任务）
 echo -e "${YELLOW}🏥 检查本地API...${NC}"
-if curl -f -s http://localhost:$SERVICE_PORT/api/health > /dev/null; then
-    echo -e "${GREEN}✅ 本地API健康检查通过${NC}"
+if curl -f -s http://localhost:$SERVICE_PORT/readyz > /dev/null; then
+    echo -e "${GREEN}✅ 本地API就绪检查通过${NC}"
     
     # 检查外网API
     echo -e "${YELLOW}🌐 检查外网API...${NC}"
-    if curl -f -s https://www.ency.asia/api/health > /dev/null; then
+    if curl -f -s https://www.ency.asia/v1/health > /dev/null; then
         echo -e "${GREEN}✅ 外网API健康检查通过${NC}"
     else
         echo -e "${YELLOW}⚠️ 外网API检查失败，可能需要等待反向代理更新${NC}"
     fi
 else
-    echo -e "${RED}❌ 本地API健康检查失败${NC}"
+    echo -e "${RED}❌ 本地API就绪检查失败${NC}"
+    curl -s http://localhost:$SERVICE_PORT/readyz
+    echo
     exit 1
 fi
 
-# 10. 显示部署信息
+# 11. 显示部署信息
 echo -e "${GREEN}"
 echo "========================================"
 echo "🎉 部署完成!"
@@ -122,6 +136,6 @@ echo "日志文件: $LOG_FILE"
 echo "========================================"
 echo -e "${NC}"
 
-# 11. 显示最新日志
+# 12. 显示最新日志
 echo -e "${YELLOW}📝 最新日志:${NC}"
 tail -10 $LOG_FILE 
\ No newline at end of file
@@ -32,4 +32,4 @@ echo ==================================
 echo.
 
 REM 启动并实时显示日志
-go run main.go 
\ No newline at end of file
+go run . 
\ No newline at end of file
@@ -35,4 +35,4 @@ echo -e "${YELLOW}按 Ctrl+C 停止服务${NC}"
 echo "=================================="
 
 # 启动并实时显示日志
-go run main.go 
\ No newline at end of file
+go run . 
\ No newline at end of file
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ServeUntilSignal 在 ln 上运行 HTTP 服务直到收到 SIGINT/SIGTERM，然后停止接收新请求，
+// 在 drain 时间内等待进行中的请求完成，再调用 cleanup 停止定时任务、关闭数据库等。返回进程退出码，日志输出到 logger
+func ServeUntilSignal(logger *slog.Logger, srv *http.Server, ln net.Listener, drain time.Duration, cleanup func() error) int {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	code := 0
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("服务运行失败", "error", err)
+			code = 1
+		}
+	case <-ctx.Done():
+		// 再次收到信号时按默认方式立即退出
+		stop()
+		logger.Info("收到退出信号，等待进行中的请求完成", "drain", drain.String())
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("等待请求完成超时，强制关闭", "error", err)
+			srv.Close()
+			code = 1
+		}
+	}
+
+	if err := cleanup(); err != nil {
+		logger.Error("停止服务失败", "error", err)
+		code = 1
+	}
+
+	logger.Info("服务已停止")
+	return code
+}
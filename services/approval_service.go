@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"purches-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOrderNotAwaitingApproval 订单不在待审批状态
+	ErrOrderNotAwaitingApproval = errors.New("订单不在待审批状态")
+	// ErrNotApprover 审批人没有审批权限
+	ErrNotApprover = errors.New("只有店主或管理员可以审批订单、修改审批规则和预算")
+	// ErrUserExists 用户已存在
+	ErrUserExists = errors.New("用户已存在")
+	// ErrInvalidRole 未知的用户角色
+	ErrInvalidRole = errors.New("用户角色应为 buyer、chef、owner 或 admin")
+	// ErrInvalidToken 用户令牌缺失或无效
+	ErrInvalidToken = errors.New("用户令牌无效")
+)
+
+// approverRoles 可以审批订单的角色
+var approverRoles = map[string]bool{"owner": true, "admin": true}
+
+// userRoles 所有用户角色
+var userRoles = map[string]bool{"buyer": true, "chef": true, "owner": true, "admin": true}
+
+type ApprovalService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewApprovalService(db *gorm.DB, logger *slog.Logger) *ApprovalService {
+	return &ApprovalService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (as *ApprovalService) WithContext(ctx context.Context) *ApprovalService {
+	return &ApprovalService{db: as.db.WithContext(ctx), logger: as.logger}
+}
+
+// GetRules 获取审批规则列表
+func (as *ApprovalService) GetRules() ([]models.ApprovalRule, error) {
+	var rules []models.ApprovalRule
+	err := as.db.Order("id").Find(&rules).Error
+	return rules, err
+}
+
+// CreateRule 创建审批规则
+func (as *ApprovalService) CreateRule(req models.ApprovalRuleRequest) (*models.ApprovalRule, error) {
+	rule := models.ApprovalRule{Active: true}
+	applyApprovalRuleRequest(&rule, req)
+
+	if err := as.db.Create(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateRule 更新审批规则
+func (as *ApprovalService) UpdateRule(ruleID int, req models.ApprovalRuleRequest) (*models.ApprovalRule, error) {
+	var rule models.ApprovalRule
+	if err := as.db.First(&rule, ruleID).Error; err != nil {
+		return nil, err
+	}
+
+	applyApprovalRuleRequest(&rule, req)
+	if err := as.db.Save(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule 删除审批规则
+func (as *ApprovalService) DeleteRule(ruleID int) error {
+	result := as.db.Delete(&models.ApprovalRule{}, ruleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPendingApprovals 获取待审批订单
+func (as *ApprovalService) GetPendingApprovals() ([]models.Order, error) {
+	var orders []models.Order
+	err := as.db.Preload("Products").
+		Where("status = ?", "awaiting_approval").
+		Order("created_at").
+		Find(&orders).Error
+	return orders, err
+}
+
+// ApproveOrder 审批通过，订单进入待处理状态。approverID 为已通过令牌认证的审批人 openId
+func (as *ApprovalService) ApproveOrder(orderID, approverID string, req models.ApproveOrderRequest) (*models.Order, error) {
+	return as.decide(orderID, approverID, req, "approved", "pending")
+}
+
+// RejectOrder 审批驳回
+func (as *ApprovalService) RejectOrder(orderID, approverID string, req models.ApproveOrderRequest) (*models.Order, error) {
+	return as.decide(orderID, approverID, req, "rejected", "rejected")
+}
+
+// decide 记录审批结果、更新订单状态并通知下单人
+// CheckApprover 确认用户是店主或管理员，审批订单、修改审批规则和预算都需要该角色
+func (as *ApprovalService) CheckApprover(openID string) error {
+	var user models.User
+	if err := as.db.Where("open_id = ?", openID).First(&user).Error; err != nil || !approverRoles[user.Role] {
+		return ErrNotApprover
+	}
+	return nil
+}
+
+func (as *ApprovalService) decide(orderID, approverID string, req models.ApproveOrderRequest, action, status string) (*models.Order, error) {
+	var order models.Order
+	if err := as.db.First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	db := withOrderFields(as.db, &order)
+	if order.Status != "awaiting_approval" {
+		return nil, ErrOrderNotAwaitingApproval
+	}
+
+	if err := as.CheckApprover(approverID); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		approval := models.OrderApproval{
+			OrderID:   order.ID,
+			Action:    action,
+			Approver:  approverID,
+			Comment:   req.Comment,
+			RequestID: requestID(tx),
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Create(&approval).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		title := "订单审批通过"
+		content := fmt.Sprintf("%s 的订单 %s（¥%.2f）已由 %s 审批通过", order.Supplier, order.ID, order.TotalPrice, approverID)
+		if action == "rejected" {
+			title = "订单被驳回"
+			content = fmt.Sprintf("%s 的订单 %s（¥%.2f）被 %s 驳回", order.Supplier, order.ID, order.TotalPrice, approverID)
+		}
+		if req.Comment != "" {
+			content += "：" + req.Comment
+		}
+		return notify(tx, order.UserID, "approval", title, content, order.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	logEvent(as.logger, db, "订单已审批", "action", action, "approver", approverID)
+
+	var updated models.Order
+	if err := db.Preload("Products").Preload("Approvals").First(&updated, "id = ?", order.ID).Error; err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// applyApprovalRuleRequest 将请求内容写入审批规则
+func applyApprovalRuleRequest(rule *models.ApprovalRule, req models.ApprovalRuleRequest) {
+	rule.Name = req.Name
+	rule.MinAmount = req.MinAmount
+	rule.Supplier = req.Supplier
+	rule.Category = req.Category
+	rule.RequesterRole = req.RequesterRole
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+}
+
+// approvalReason 检查订单是否触发审批规则，返回触发原因（未触发时为空）
+func approvalReason(tx *gorm.DB, order *models.Order, products []models.Product, requesterRole string) (string, error) {
+	var rules []models.ApprovalRule
+	if err := tx.Where("active = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return "", err
+	}
+
+	categories := make(map[string]bool)
+	for _, product := range products {
+		categories[product.Category] = true
+	}
+
+	var reasons []string
+	for _, rule := range rules {
+		if rule.MinAmount > 0 && order.TotalPrice < rule.MinAmount {
+			continue
+		}
+		if rule.Supplier != "" && rule.Supplier != order.Supplier {
+			continue
+		}
+		if rule.Category != "" && !categories[rule.Category] {
+			continue
+		}
+		if rule.RequesterRole != "" && rule.RequesterRole != requesterRole {
+			continue
+		}
+		reasons = append(reasons, rule.Name)
+	}
+
+	return strings.Join(reasons, "；"), nil
+}
+
+// CreateUser 创建用户，角色为空时为 buyer
+func (as *ApprovalService) CreateUser(req models.CreateUserRequest) (*models.User, error) {
+	if req.Role == "" {
+		req.Role = "buyer"
+	}
+	if !userRoles[req.Role] {
+		return nil, ErrInvalidRole
+	}
+
+	var count int64
+	if err := as.db.Model(&models.User{}).Where("open_id = ?", req.OpenID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrUserExists
+	}
+
+	user := models.User{OpenID: req.OpenID, NickName: req.NickName, Role: req.Role}
+	if err := as.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserRole 设置用户角色
+func (as *ApprovalService) UpdateUserRole(openID, role string) (*models.User, error) {
+	var user models.User
+	if err := as.db.Where("open_id = ?", openID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	if err := as.db.Model(&user).Update("role", role).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// IssueToken 为用户生成新的访问令牌，数据库只保存令牌的 SHA-256 摘要，旧令牌随即失效
+func (as *ApprovalService) IssueToken(openID string) (string, error) {
+	var user models.User
+	if err := as.db.Where("open_id = ?", openID).First(&user).Error; err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := as.db.Model(&user).Update("token_hash", hashToken(token)).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate 根据访问令牌查找用户
+func (as *ApprovalService) Authenticate(token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	var user models.User
+	if err := as.db.Where("token_hash = ?", hashToken(token)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResolveUser 查找令牌对应用户的 openId，供访问日志记录请求用户
+func (as *ApprovalService) ResolveUser(ctx context.Context, token string) (string, error) {
+	user, err := as.WithContext(ctx).Authenticate(token)
+	if err != nil {
+		return "", err
+	}
+	return user.OpenID, nil
+}
+
+// hashToken 计算令牌摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"purches-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotAdmin 操作人没有归档权限
+	ErrNotAdmin = errors.New("只有店主或管理员可以归档或恢复数据")
+	// ErrOrderNotClosed 订单仍在进行中，不能归档
+	ErrOrderNotClosed = errors.New("只能归档已完成、已取消或已驳回的订单")
+	// ErrNotArchived 记录未归档
+	ErrNotArchived = errors.New("记录未归档")
+	// ErrSupplierArchived 商品所属供应商已归档
+	ErrSupplierArchived = errors.New("供应商已归档，请先恢复供应商")
+)
+
+// adminRoles 可以归档和恢复数据的角色
+var adminRoles = map[string]bool{"owner": true, "admin": true}
+
+// closedOrderStatuses 可以归档的订单状态
+var closedOrderStatuses = map[string]bool{"completed": true, "cancelled": true, "rejected": true}
+
+type ArchiveService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewArchiveService(db *gorm.DB, logger *slog.Logger) *ArchiveService {
+	return &ArchiveService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (as *ArchiveService) WithContext(ctx context.Context) *ArchiveService {
+	return &ArchiveService{db: as.db.WithContext(ctx), logger: as.logger}
+}
+
+// withArchived 预加载时包含已归档的记录，历史订单等仍能关联到归档的商品
+func withArchived(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// GetArchive 获取已归档的商品、供应商和订单
+func (as *ArchiveService) GetArchive(req models.ArchiveListRequest) (*models.ArchiveResponse, error) {
+	if err := as.requireAdmin(req.Operator); err != nil {
+		return nil, err
+	}
+
+	response := &models.ArchiveResponse{
+		Products:  []models.Product{},
+		Suppliers: []models.Supplier{},
+		Orders:    []models.Order{},
+	}
+	archived := as.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+
+	if req.Type == "" || req.Type == "products" {
+		if err := archived.Session(&gorm.Session{}).Find(&response.Products).Error; err != nil {
+			return nil, err
+		}
+	}
+	if req.Type == "" || req.Type == "suppliers" {
+		if err := archived.Session(&gorm.Session{}).Find(&response.Suppliers).Error; err != nil {
+			return nil, err
+		}
+	}
+	if req.Type == "" || req.Type == "orders" {
+		if err := archived.Session(&gorm.Session{}).Preload("Products").Find(&response.Orders).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// ArchiveProduct 归档商品，同时从购物车中移除
+func (as *ArchiveService) ArchiveProduct(productID int, operator string) error {
+	if err := as.requireAdmin(operator); err != nil {
+		return err
+	}
+
+	var product models.Product
+	if err := as.db.First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	})
+	if err != nil {
+		return err
+	}
+	logEvent(as.logger, as.db, "商品已归档", "product_id", product.ID, "operator", operator)
+	return nil
+}
+
+// RestoreProduct 恢复已归档的商品
+func (as *ArchiveService) RestoreProduct(productID int, operator string) (*models.Product, error) {
+	if err := as.requireAdmin(operator); err != nil {
+		return nil, err
+	}
+
+	var product models.Product
+	if err := as.db.Unscoped().First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	if !product.DeletedAt.Valid {
+		return nil, ErrNotArchived
+	}
+
+	var supplier models.Supplier
+	if err := as.db.Unscoped().First(&supplier, "name = ?", product.Supplier).Error; err == nil && supplier.DeletedAt.Valid {
+		return nil, ErrSupplierArchived
+	}
+
+	if err := as.db.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	logEvent(as.logger, as.db, "商品已恢复", "product_id", product.ID, "operator", operator)
+	product.DeletedAt = gorm.DeletedAt{}
+	return &product, nil
+}
+
+// ArchiveSupplier 归档供应商及其商品，恢复供应商时一并恢复
+func (as *ArchiveService) ArchiveSupplier(name, operator string) error {
+	if err := as.requireAdmin(operator); err != nil {
+		return err
+	}
+
+	var supplier models.Supplier
+	if err := as.db.First(&supplier, "name = ?", name).Error; err != nil {
+		return err
+	}
+
+	// 供应商和商品使用同一归档时间，恢复时据此找回一起归档的商品
+	now := time.Now()
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		productIDs := tx.Model(&models.Product{}).Select("id").Where("supplier = ?", name)
+		if err := tx.Where("product_id IN (?)", productIDs).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Product{}).Where("supplier = ?", name).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Supplier{}).Where("name = ?", name).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		return err
+	}
+	logEvent(as.logger, as.db, "供应商已归档", "supplier", name, "operator", operator)
+	return nil
+}
+
+// RestoreSupplier 恢复已归档的供应商及与其一起归档的商品
+func (as *ArchiveService) RestoreSupplier(name, operator string) (*models.Supplier, error) {
+	if err := as.requireAdmin(operator); err != nil {
+		return nil, err
+	}
+
+	var supplier models.Supplier
+	if err := as.db.Unscoped().First(&supplier, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	if !supplier.DeletedAt.Valid {
+		return nil, ErrNotArchived
+	}
+
+	err := as.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Product{}).
+			Where("supplier = ? AND deleted_at = ?", name, supplier.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Supplier{}).Where("name = ?", name).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	logEvent(as.logger, as.db, "供应商已恢复", "supplier", name, "operator", operator)
+
+	supplier.DeletedAt = gorm.DeletedAt{}
+	return &supplier, nil
+}
+
+// ArchiveOrder 归档已结束的订单，进行中的订单不能归档
+func (as *ArchiveService) ArchiveOrder(orderID, operator string) error {
+	if err := as.requireAdmin(operator); err != nil {
+		return err
+	}
+
+	var order models.Order
+	if err := as.db.First(&order, "id = ?", orderID).Error; err != nil {
+		return err
+	}
+	db := withOrderFields(as.db, &order)
+	if !closedOrderStatuses[order.Status] {
+		return ErrOrderNotClosed
+	}
+
+	if err := db.Delete(&order).Error; err != nil {
+		return err
+	}
+	logEvent(as.logger, db, "订单已归档", "operator", operator)
+	return nil
+}
+
+// RestoreOrder 恢复已归档的订单
+func (as *ArchiveService) RestoreOrder(orderID, operator string) (*models.Order, error) {
+	if err := as.requireAdmin(operator); err != nil {
+		return nil, err
+	}
+
+	var order models.Order
+	if err := as.db.Unscoped().First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	db := withOrderFields(as.db, &order)
+	if !order.DeletedAt.Valid {
+		return nil, ErrNotArchived
+	}
+
+	if err := db.Unscoped().Model(&order).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	logEvent(as.logger, db, "订单已恢复", "operator", operator)
+
+	var restored models.Order
+	if err := db.Preload("Products").First(&restored, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	return &restored, nil
+}
+
+// requireAdmin 检查操作人是否为店主或管理员
+func (as *ArchiveService) requireAdmin(operator string) error {
+	var user models.User
+	if err := as.db.Where("open_id = ?", operator).First(&user).Error; err != nil || !adminRoles[user.Role] {
+		return ErrNotAdmin
+	}
+	return nil
+}
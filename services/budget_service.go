@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"purches-backend/config"
+	"purches-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBudgetExceeded 订单超出预算且预算设置为禁止下单
+var ErrBudgetExceeded = errors.New("订单超出采购预算")
+
+type BudgetService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewBudgetService(db *gorm.DB, logger *slog.Logger) *BudgetService {
+	return &BudgetService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (bs *BudgetService) WithContext(ctx context.Context) *BudgetService {
+	return &BudgetService{db: bs.db.WithContext(ctx), logger: bs.logger}
+}
+
+// GetBudgetUsage 获取预算及当前周期使用情况
+func (bs *BudgetService) GetBudgetUsage(req models.BudgetUsageRequest) ([]models.BudgetUsage, error) {
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidDate, req.Date)
+		}
+		date = parsed
+	}
+
+	query := bs.db.Order("store, period, category")
+	if req.Store != "" {
+		query = query.Where("store = ?", req.Store)
+	}
+
+	var budgets []models.Budget
+	if err := query.Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+
+	usages := make([]models.BudgetUsage, 0, len(budgets))
+	for _, budget := range budgets {
+		start, end := budgetPeriod(budget.Period, date)
+		committed, actual, err := budgetSpend(bs.db, budget, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		spent := committed + actual
+		usages = append(usages, models.BudgetUsage{
+			Budget:      budget,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Committed:   committed,
+			Actual:      actual,
+			Remaining:   budget.Amount - spent,
+			Utilization: spent / budget.Amount * 100,
+			Exceeded:    spent > budget.Amount,
+		})
+	}
+
+	return usages, nil
+}
+
+// CreateBudget 创建预算
+func (bs *BudgetService) CreateBudget(req models.BudgetRequest) (*models.Budget, error) {
+	var budget models.Budget
+	applyBudgetRequest(&budget, req)
+
+	if err := bs.db.Create(&budget).Error; err != nil {
+		return nil, err
+	}
+	logStoreEvent(bs.logger, bs.db, budget.Store, "预算已创建", "budget_id", budget.ID, "category", budget.Category,
+		"period", budget.Period, "amount", budget.Amount, "enforcement", budget.Enforcement)
+	return &budget, nil
+}
+
+// UpdateBudget 更新预算
+func (bs *BudgetService) UpdateBudget(budgetID int, req models.BudgetRequest) (*models.Budget, error) {
+	var budget models.Budget
+	if err := bs.db.First(&budget, budgetID).Error; err != nil {
+		return nil, err
+	}
+
+	applyBudgetRequest(&budget, req)
+	if err := bs.db.Save(&budget).Error; err != nil {
+		return nil, err
+	}
+	logStoreEvent(bs.logger, bs.db, budget.Store, "预算已更新", "budget_id", budget.ID, "category", budget.Category,
+		"period", budget.Period, "amount", budget.Amount, "enforcement", budget.Enforcement)
+	return &budget, nil
+}
+
+// DeleteBudget 删除预算
+func (bs *BudgetService) DeleteBudget(budgetID int) error {
+	result := bs.db.Delete(&models.Budget{}, budgetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	logEvent(bs.logger, bs.db, "预算已删除", "budget_id", budgetID)
+	return nil
+}
+
+// applyBudgetRequest 将请求内容写入预算
+func applyBudgetRequest(budget *models.Budget, req models.BudgetRequest) {
+	budget.Store = req.Store
+	if budget.Store == "" {
+		budget.Store = defaultStore()
+	}
+	budget.Category = req.Category
+	budget.Period = req.Period
+	budget.Amount = req.Amount
+	budget.Enforcement = req.Enforcement
+	if budget.Enforcement == "" {
+		budget.Enforcement = "warn"
+	}
+}
+
+// defaultStore 获取默认门店
+func defaultStore() string {
+	return config.GetConfig().App.DefaultStore
+}
+
+// budgetPeriod 计算日期所在的预算周期 [start, end)，周预算从周一开始
+func budgetPeriod(period string, date time.Time) (time.Time, time.Time) {
+	day := truncateToDate(date)
+	if period == "weekly" {
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7)
+	}
+
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// budgetSpend 统计预算周期内的在途金额和已收货金额
+func budgetSpend(tx *gorm.DB, budget models.Budget, start, end time.Time) (float64, float64, error) {
+	statuses := append([]string{"completed"}, openOrderStatuses...)
+
+	var orders []models.Order
+	// 已归档的订单和商品仍计入花费
+	if err := tx.Unscoped().Preload("Products.Product", withArchived).
+		Where("store = ? AND status IN ? AND created_at >= ? AND created_at < ?", budget.Store, statuses, start, end).
+		Find(&orders).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var committed, actual float64
+	for _, order := range orders {
+		var amount float64
+		for _, item := range order.Products {
+			if budget.Category == "" || item.Product.Category == budget.Category {
+				amount += item.TotalPrice
+			}
+		}
+
+		if order.Status != "completed" {
+			committed += amount
+			continue
+		}
+		// 有最终价格时按比例折算实际花费
+		if order.FinalPrice != nil && order.TotalPrice > 0 {
+			amount = amount * *order.FinalPrice / order.TotalPrice
+		}
+		actual += amount
+	}
+
+	return committed, actual, nil
+}
+
+// checkBudgets 检查订单是否超出门店预算：warn 预算记录提醒，block 预算返回 ErrBudgetExceeded
+func checkBudgets(tx *gorm.DB, order *models.Order, productMap map[int]models.Product) error {
+	var budgets []models.Budget
+	if err := tx.Where("store = ?", order.Store).Order("id").Find(&budgets).Error; err != nil {
+		return err
+	}
+
+	for _, budget := range budgets {
+		var amount float64
+		for _, item := range order.Products {
+			if budget.Category == "" || productMap[item.ProductID].Category == budget.Category {
+				amount += item.TotalPrice
+			}
+		}
+		if amount == 0 {
+			continue
+		}
+
+		start, end := budgetPeriod(budget.Period, order.CreatedAt)
+		committed, actual, err := budgetSpend(tx, budget, start, end)
+		if err != nil {
+			return err
+		}
+
+		projected := committed + actual + amount
+		if projected <= budget.Amount {
+			continue
+		}
+
+		message := fmt.Sprintf("%s%s预算 ¥%.2f，下单后将使用 ¥%.2f", budgetLabel(budget), budgetPeriodLabel(budget.Period), budget.Amount, projected)
+		if budget.Enforcement == "block" {
+			return fmt.Errorf("%w：%s", ErrBudgetExceeded, message)
+		}
+		order.BudgetWarnings = append(order.BudgetWarnings, message)
+	}
+
+	return nil
+}
+
+// budgetLabel 预算的展示名称
+func budgetLabel(budget models.Budget) string {
+	if budget.Category == "" {
+		return budget.Store
+	}
+	return budget.Store + " " + budget.Category
+}
+
+// budgetPeriodLabel 预算周期的展示名称
+func budgetPeriodLabel(period string) string {
+	if period == "weekly" {
+		return "本周"
+	}
+	return "本月"
+}
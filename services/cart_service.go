@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
+	"log/slog"
 	"purches-backend/config"
+	"purches-backend/metrics"
 	"purches-backend/models"
 	"time"
 
@@ -9,15 +12,22 @@ import (
 )
 
 type CartService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
-func NewCartService(db *gorm.DB) *CartService {
+func NewCartService(db *gorm.DB, logger *slog.Logger) *CartService {
 	return &CartService{
-		db: db,
+		db:     db,
+		logger: logger,
 	}
 }
 
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (cs *CartService) WithContext(ctx context.Context) *CartService {
+	return &CartService{db: cs.db.WithContext(ctx), logger: cs.logger}
+}
+
 // getDefaultUserID 获取默认用户ID
 func (cs *CartService) getDefaultUserID() string {
 	return config.GetConfig().App.DefaultUser
@@ -85,6 +95,8 @@ func (cs *CartService) AddToCart(req models.AddToCartRequest) (*models.CartItem,
 		if err := cs.db.Save(&existingItem).Error; err != nil {
 			return nil, err
 		}
+		logEvent(cs.logger, cs.db, "商品已加入购物车", "product_id", product.ID, "supplier", product.Supplier, "count", req.Count)
+		metrics.CartItemAdded(product.Supplier)
 		return &existingItem, nil
 	} else {
 		// 如果不存在，添加新商品
@@ -101,6 +113,8 @@ func (cs *CartService) AddToCart(req models.AddToCartRequest) (*models.CartItem,
 		if err := cs.db.Create(&newItem).Error; err != nil {
 			return nil, err
 		}
+		logEvent(cs.logger, cs.db, "商品已加入购物车", "product_id", product.ID, "supplier", product.Supplier, "count", req.Count)
+		metrics.CartItemAdded(product.Supplier)
 		return &newItem, nil
 	}
 }
@@ -162,3 +176,169 @@ func (cs *CartService) ClearCart() error {
 
 	return cs.db.Where("user_id = ?", user.ID).Delete(&models.CartItem{}).Error
 }
+
+// SuggestCart 根据标准库存生成建议采购并填充购物车
+func (cs *CartService) SuggestCart(weekday int) (*models.SuggestCartResponse, error) {
+	userID := cs.getDefaultUserID()
+
+	// 确保用户存在
+	var user models.User
+	if err := cs.db.FirstOrCreate(&user, models.User{OpenID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	suggestions, err := calculateSuggestions(cs.db, weekday)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.db.Transaction(func(tx *gorm.DB) error {
+		for _, group := range suggestions {
+			for _, item := range group.Items {
+				// 已在购物车中的商品直接改为建议数量
+				var cartItem models.CartItem
+				result := tx.Where("user_id = ? AND product_id = ?", user.ID, item.ProductID).First(&cartItem)
+				if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+					return result.Error
+				}
+
+				if result.Error == gorm.ErrRecordNotFound {
+					cartItem = models.CartItem{
+						UserID:    user.ID,
+						ProductID: item.ProductID,
+						Name:      item.Name,
+						ShopName:  group.Supplier,
+						AddedAt:   time.Now(),
+					}
+				}
+				cartItem.Count = item.Count
+				cartItem.Price = item.Price
+				cartItem.TotalPrice = item.TotalPrice
+
+				if err := tx.Save(&cartItem).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := cs.GetCart()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SuggestCartResponse{
+		Weekday:   weekday,
+		Suppliers: suggestions,
+		Cart:      cart,
+	}
+
+	return response, nil
+}
+
+// ReorderToCart 将历史订单的商品按当前价格加入购物车，不可购买的商品会被跳过
+func (cs *CartService) ReorderToCart(orderID string) (*models.ReorderResponse, error) {
+	var order models.Order
+	if err := cs.db.Preload("Products").First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	db := withOrderFields(cs.db, &order)
+
+	userID := cs.getDefaultUserID()
+
+	// 确保用户存在
+	var user models.User
+	if err := db.FirstOrCreate(&user, models.User{OpenID: userID}).Error; err != nil {
+		return nil, err
+	}
+
+	response := &models.ReorderResponse{
+		OrderID: order.ID,
+		Added:   []models.ReorderItem{},
+		Skipped: []models.ReorderItem{},
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, orderItem := range order.Products {
+			item := models.ReorderItem{
+				ProductID:     orderItem.ProductID,
+				Name:          orderItem.Name,
+				Count:         orderItem.Count,
+				Unit:          orderItem.Unit,
+				OriginalPrice: orderItem.Price,
+			}
+
+			var product models.Product
+			if err := tx.First(&product, orderItem.ProductID).Error; err != nil {
+				if err != gorm.ErrRecordNotFound {
+					return err
+				}
+				item.Reason = "商品已删除"
+				response.Skipped = append(response.Skipped, item)
+				continue
+			}
+
+			item.CurrentPrice = product.Price
+			item.PriceChange = product.Price - orderItem.Price
+			if product.Status != "available" {
+				item.Reason = unavailableReason(product.Status)
+				response.Skipped = append(response.Skipped, item)
+				continue
+			}
+
+			// 已在购物车中的商品累加数量，并按当前价格重新计算
+			var cartItem models.CartItem
+			result := tx.Where("user_id = ? AND product_id = ?", user.ID, product.ID).First(&cartItem)
+			if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+				return result.Error
+			}
+			if result.Error == gorm.ErrRecordNotFound {
+				cartItem = models.CartItem{
+					UserID:    user.ID,
+					ProductID: product.ID,
+					Name:      product.Name,
+					ShopName:  product.Supplier,
+					AddedAt:   time.Now(),
+				}
+			}
+			cartItem.Count += orderItem.Count
+			cartItem.Price = product.Price
+			cartItem.TotalPrice = float64(cartItem.Count) * product.Price
+
+			if err := tx.Save(&cartItem).Error; err != nil {
+				return err
+			}
+
+			response.Added = append(response.Added, item)
+			response.PriceDifference += item.PriceChange * float64(orderItem.Count)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cart, err := cs.GetCart()
+	if err != nil {
+		return nil, err
+	}
+	response.Cart = cart
+
+	return response, nil
+}
+
+// unavailableReason 商品不可购买的原因
+func unavailableReason(status string) string {
+	switch status {
+	case "discontinued":
+		return "商品已停售"
+	case "unavailable":
+		return "商品暂时缺货"
+	default:
+		return "商品不可购买"
+	}
+}
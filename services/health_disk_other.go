@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package services
+
+// diskFreeBytes 当前系统不支持，磁盘检查跳过
+func diskFreeBytes(dir string) (uint64, error) {
+	return 0, errDiskUsageUnsupported
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package services
+
+import "syscall"
+
+// diskFreeBytes 返回目录所在磁盘中非特权用户可用的剩余空间（字节）
+func diskFreeBytes(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
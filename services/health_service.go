@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"purches-backend/config"
+	"purches-backend/migrations"
+	"purches-backend/models"
+	"purches-backend/scheduler"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dbPingTimeout 就绪检查中数据库连通性检查的超时时间
+const dbPingTimeout = 2 * time.Second
+
+// errDiskUsageUnsupported 当前系统不支持读取磁盘剩余空间
+var errDiskUsageUnsupported = errors.New("当前系统不支持检查磁盘剩余空间")
+
+// 单项检查结果状态
+const (
+	checkOK      = "ok"
+	checkWarn    = "warn"
+	checkFail    = "fail"
+	checkSkipped = "skipped"
+)
+
+type HealthService struct {
+	db   *gorm.DB
+	cfg  *config.Config
+	jobs *scheduler.Scheduler
+}
+
+func NewHealthService(db *gorm.DB, cfg *config.Config, jobs *scheduler.Scheduler) *HealthService {
+	return &HealthService{
+		db:   db,
+		cfg:  cfg,
+		jobs: jobs,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (hs *HealthService) WithContext(ctx context.Context) *HealthService {
+	return &HealthService{db: hs.db.WithContext(ctx), cfg: hs.cfg, jobs: hs.jobs}
+}
+
+// Readiness 检查数据库连通性、迁移状态、SQLite 磁盘空间和定时任务，任一检查失败时为 degraded
+func (hs *HealthService) Readiness() *models.ReadinessResponse {
+	response := &models.ReadinessResponse{
+		Status: "ready",
+		Checks: map[string]models.HealthCheck{
+			"database":   hs.checkDatabase(),
+			"migrations": hs.checkMigrations(),
+			"disk":       hs.checkDisk(),
+			"scheduler":  hs.checkScheduler(),
+		},
+	}
+	for _, check := range response.Checks {
+		if check.Status == checkFail {
+			response.Status = "degraded"
+		}
+	}
+	return response
+}
+
+// checkDatabase 检查数据库连接是否可用
+func (hs *HealthService) checkDatabase() models.HealthCheck {
+	sqlDB, err := hs.db.DB()
+	if err != nil {
+		return models.HealthCheck{Status: checkFail, Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(hs.db.Statement.Context, dbPingTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return models.HealthCheck{Status: checkFail, Message: "数据库无法连接: " + err.Error()}
+	}
+	return models.HealthCheck{Status: checkOK, Details: map[string]interface{}{
+		"type":            hs.db.Dialector.Name(),
+		"openConnections": sqlDB.Stats().OpenConnections,
+	}}
+}
+
+// checkMigrations 只读查询已执行的迁移版本，与代码中的迁移比较。有未执行的迁移，
+// 或数据库中有代码不认识的版本（回退到了旧版本程序）时失败
+func (hs *HealthService) checkMigrations() models.HealthCheck {
+	var versions []int64
+	if hs.db.Migrator().HasTable(&migrations.SchemaMigration{}) {
+		if err := hs.db.Raw("SELECT version FROM schema_migrations").Scan(&versions).Error; err != nil {
+			return models.HealthCheck{Status: checkFail, Message: "无法读取迁移状态: " + err.Error()}
+		}
+	} else if err := hs.db.Exec("SELECT 1").Error; err != nil {
+		return models.HealthCheck{Status: checkFail, Message: "无法读取迁移状态: " + err.Error()}
+	}
+
+	applied := make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	known := make(map[int64]bool)
+	var pending []string
+	for _, migration := range migrations.All() {
+		known[migration.Version] = true
+		if !applied[migration.Version] {
+			pending = append(pending, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+	}
+	var unknown []int64
+	for _, version := range versions {
+		if !known[version] {
+			unknown = append(unknown, version)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return models.HealthCheck{
+			Status:  checkFail,
+			Message: fmt.Sprintf("数据库中有 %d 个未知的迁移版本，程序版本可能过旧", len(unknown)),
+			Details: map[string]interface{}{"unknown": unknown},
+		}
+	}
+	if len(pending) > 0 {
+		return models.HealthCheck{
+			Status:  checkFail,
+			Message: fmt.Sprintf("有 %d 个迁移未执行，请运行 migrate up", len(pending)),
+			Details: map[string]interface{}{"pending": pending},
+		}
+	}
+	return models.HealthCheck{Status: checkOK, Details: map[string]interface{}{"applied": len(versions)}}
+}
+
+// checkDisk 检查 SQLite 文件所在磁盘的剩余空间，其他数据库不检查
+func (hs *HealthService) checkDisk() models.HealthCheck {
+	if hs.db.Dialector.Name() != "sqlite" {
+		return models.HealthCheck{Status: checkSkipped, Message: "非 SQLite 数据库"}
+	}
+
+	dir := filepath.Dir(config.DatabaseDSN(hs.cfg.Database))
+	free, err := diskFreeBytes(dir)
+	if errors.Is(err, errDiskUsageUnsupported) {
+		return models.HealthCheck{Status: checkSkipped, Message: err.Error()}
+	}
+	if err != nil {
+		return models.HealthCheck{Status: checkFail, Message: "无法读取磁盘剩余空间: " + err.Error()}
+	}
+
+	freeMB := free / (1 << 20)
+	minFreeMB := uint64(hs.cfg.Health.MinFreeDiskMB)
+	details := map[string]interface{}{"path": dir, "freeMB": freeMB, "minFreeMB": minFreeMB}
+	if freeMB < minFreeMB {
+		return models.HealthCheck{
+			Status:  checkFail,
+			Message: fmt.Sprintf("磁盘剩余空间不足 %d MB", minFreeMB),
+			Details: details,
+		}
+	}
+	return models.HealthCheck{Status: checkOK, Details: details}
+}
+
+// checkScheduler 检查定时任务是否在运行，以及每个任务是否按间隔运行（超过两个间隔没有运行完成视为卡住）。
+// 任务最近一次运行失败时为 warn，不影响就绪
+func (hs *HealthService) checkScheduler() models.HealthCheck {
+	if !hs.cfg.Scheduler.Enabled {
+		return models.HealthCheck{Status: checkSkipped, Message: "定时任务未启用"}
+	}
+	if hs.jobs == nil || !hs.jobs.Running() {
+		return models.HealthCheck{Status: checkFail, Message: "定时任务未运行"}
+	}
+
+	now := time.Now()
+	jobs := hs.jobs.Status()
+	check := models.HealthCheck{Status: checkOK, Details: map[string]interface{}{"jobs": jobs}}
+	for _, job := range jobs {
+		if job.Overdue(now) {
+			check.Status = checkFail
+			check.Message = fmt.Sprintf("任务 %s 超过两个间隔（%s）没有运行完成，可能已卡住", job.Name, job.Interval)
+			return check
+		}
+		if job.LastError != "" {
+			check.Status = checkWarn
+			check.Message = fmt.Sprintf("任务 %s 最近一次运行失败: %s", job.Name, job.LastError)
+		}
+	}
+	return check
+}
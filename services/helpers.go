@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"purches-backend/logging"
+	"purches-backend/metrics"
+	"purches-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dateLayout 查询参数中的日期格式
+const dateLayout = "2006-01-02"
+
+// ErrInvalidDate 查询参数中的日期格式错误
+var ErrInvalidDate = errors.New("日期格式错误，应为 YYYY-MM-DD")
+
+// applyDateRange 按日期范围筛选（包含结束日期当天）
+func applyDateRange(query *gorm.DB, column, dateFrom, dateTo string) (*gorm.DB, error) {
+	if dateFrom != "" {
+		from, err := time.ParseInLocation(dateLayout, dateFrom, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 开始日期 %s", ErrInvalidDate, dateFrom)
+		}
+		query = query.Where(column+" >= ?", from)
+	}
+	if dateTo != "" {
+		to, err := time.ParseInLocation(dateLayout, dateTo, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 结束日期 %s", ErrInvalidDate, dateTo)
+		}
+		query = query.Where(column+" < ?", to.AddDate(0, 0, 1))
+	}
+	return query, nil
+}
+
+// requestID db 绑定的请求 context 中的请求ID，记录到审批、库存变动等数据中，便于和日志对应
+func requestID(db *gorm.DB) string {
+	return logging.FieldsFrom(db.Statement.Context).RequestID
+}
+
+// logEvent 记录业务事件日志，带上 db 绑定的请求 context 中的日志字段
+func logEvent(logger *slog.Logger, db *gorm.DB, msg string, args ...interface{}) {
+	logger.InfoContext(db.Statement.Context, msg, args...)
+}
+
+// withOrderFields 加载订单后在 db 绑定的 context 中加入订单号和门店，之后的 SQL 日志和业务事件日志都会带上
+func withOrderFields(db *gorm.DB, order *models.Order) *gorm.DB {
+	return db.WithContext(logging.WithFields(db.Statement.Context, logging.Fields{Store: order.Store, OrderID: order.ID}))
+}
+
+// logStoreEvent 记录门店相关的业务事件日志
+func logStoreEvent(logger *slog.Logger, db *gorm.DB, store, msg string, args ...interface{}) {
+	ctx := logging.WithFields(db.Statement.Context, logging.Fields{Store: store})
+	logger.InfoContext(ctx, msg, args...)
+}
+
+// logOrdersCreated 记录新建的订单（日志每个订单一条，并计入监控指标），source 为下单来源（cart、template、standing_order）
+func logOrdersCreated(logger *slog.Logger, db *gorm.DB, orders []models.Order, source string) {
+	for _, order := range orders {
+		ctx := logging.WithFields(db.Statement.Context, logging.Fields{Store: order.Store, OrderID: order.ID})
+		logger.InfoContext(ctx, "订单已创建", "supplier", order.Supplier, "status", order.Status,
+			"total_price", order.TotalPrice, "items", len(order.Products), "source", source)
+		metrics.OrderCreated(order.Supplier, source)
+	}
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"purches-backend/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInsufficientStock 现有库存不足
+var ErrInsufficientStock = errors.New("库存不足")
+
+// stockEpsilon 比较库存数量时允许的浮点误差
+const stockEpsilon = 1e-9
+
+// openOrderStatuses 尚未到货的订单状态
+var openOrderStatuses = []string{"awaiting_approval", "pending", "confirmed", "delivering"}
+
+type InventoryService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewInventoryService(db *gorm.DB, logger *slog.Logger) *InventoryService {
+	return &InventoryService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (is *InventoryService) WithContext(ctx context.Context) *InventoryService {
+	return &InventoryService{db: is.db.WithContext(ctx), logger: is.logger}
+}
+
+// GetInventory 获取库存列表
+func (is *InventoryService) GetInventory() ([]models.Inventory, error) {
+	var inventory []models.Inventory
+	err := is.db.Preload("Product", withArchived).Order("product_id").Find(&inventory).Error
+	return inventory, err
+}
+
+// SetOnHand 设置商品现有库存
+func (is *InventoryService) SetOnHand(productID int, quantity float64) (*models.Inventory, error) {
+	var product models.Product
+	if err := is.db.First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+
+	var inventory models.Inventory
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		inventory, err = setStock(tx, productID, quantity, "manual", "", "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inventory.Product = product
+	logEvent(is.logger, is.db, "现有库存已设置", "product_id", productID, "quantity", quantity)
+	return &inventory, nil
+}
+
+// GetMovements 获取库存变动记录
+func (is *InventoryService) GetMovements(productID int) ([]models.StockMovement, error) {
+	query := is.db.Order("created_at DESC, id DESC")
+	if productID > 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var movements []models.StockMovement
+	err := query.Find(&movements).Error
+	return movements, err
+}
+
+// ConsumeStock 出库，按先到期先出扣减批次
+func (is *InventoryService) ConsumeStock(req models.ConsumeStockRequest) (*models.ConsumeStockResponse, error) {
+	var product models.Product
+	if err := is.db.First(&product, req.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	response := &models.ConsumeStockResponse{}
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		response.Inventory, response.Allocations, err = adjustStock(tx, req.ProductID, -req.Quantity, "consume", "", req.Operator)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response.Inventory.Product = product
+	logEvent(is.logger, is.db, "库存已出库", "product_id", req.ProductID, "quantity", req.Quantity,
+		"batches", len(response.Allocations), "operator", req.Operator)
+	return response, nil
+}
+
+// GetBatches 获取有剩余的库存批次
+func (is *InventoryService) GetBatches(productID int) ([]models.StockBatch, error) {
+	query := is.db.Preload("Product", withArchived).Where("remaining > 0")
+	if productID > 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var batches []models.StockBatch
+	err := query.Order("expires_at IS NULL, expires_at, received_at, id").Find(&batches).Error
+	return batches, err
+}
+
+// GetExpiringBatches 获取指定天数内到期（含已过期）且有剩余的批次
+func (is *InventoryService) GetExpiringBatches(days int) ([]models.StockBatch, error) {
+	deadline := time.Now().AddDate(0, 0, days)
+
+	var batches []models.StockBatch
+	err := is.db.Preload("Product", withArchived).
+		Where("remaining > 0 AND expires_at IS NOT NULL AND expires_at <= ?", deadline).
+		Order("expires_at, id").
+		Find(&batches).Error
+	return batches, err
+}
+
+// GetParLevels 获取标准库存设置
+func (is *InventoryService) GetParLevels(productID int) ([]models.ParLevel, error) {
+	query := is.db.Preload("Product", withArchived)
+	if productID > 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var levels []models.ParLevel
+	err := query.Order("product_id, weekday").Find(&levels).Error
+	return levels, err
+}
+
+// SetParLevels 设置商品每周各天的标准库存
+func (is *InventoryService) SetParLevels(req models.SetParLevelsRequest) ([]models.ParLevel, error) {
+	var product models.Product
+	if err := is.db.First(&product, req.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	err := is.db.Transaction(func(tx *gorm.DB) error {
+		for _, level := range req.Levels {
+			var parLevel models.ParLevel
+			result := tx.Where("product_id = ? AND weekday = ?", req.ProductID, level.Weekday).First(&parLevel)
+			if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+				return result.Error
+			}
+
+			parLevel.ProductID = req.ProductID
+			parLevel.Weekday = level.Weekday
+			parLevel.Quantity = level.Quantity
+			if err := tx.Save(&parLevel).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logEvent(is.logger, is.db, "标准库存已设置", "product_id", req.ProductID, "levels", len(req.Levels))
+	return is.GetParLevels(req.ProductID)
+}
+
+// DeleteParLevel 删除标准库存设置
+func (is *InventoryService) DeleteParLevel(parLevelID int) error {
+	result := is.db.Delete(&models.ParLevel{}, parLevelID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	logEvent(is.logger, is.db, "标准库存已删除", "par_level_id", parLevelID)
+	return nil
+}
+
+// GetSuggestions 计算建议采购量：标准库存 - 现有库存 - 在途订单
+func (is *InventoryService) GetSuggestions(weekday int) ([]models.SuggestedOrderGroup, error) {
+	return calculateSuggestions(is.db, weekday)
+}
+
+// calculateSuggestions 按供应商分组计算建议采购量
+func calculateSuggestions(db *gorm.DB, weekday int) ([]models.SuggestedOrderGroup, error) {
+	var levels []models.ParLevel
+	if err := db.Preload("Product").Where("weekday = ?", weekday).Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	// 现有库存
+	var inventory []models.Inventory
+	if err := db.Find(&inventory).Error; err != nil {
+		return nil, err
+	}
+	onHand := make(map[int]float64)
+	for _, item := range inventory {
+		onHand[item.ProductID] = item.Quantity
+	}
+
+	// 在途订单数量
+	var openItems []struct {
+		ProductID int
+		Quantity  float64
+	}
+	if err := db.Table("order_items oi").
+		Select("oi.product_id, SUM(oi.count) as quantity").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("o.status IN ?", openOrderStatuses).
+		Group("oi.product_id").
+		Scan(&openItems).Error; err != nil {
+		return nil, err
+	}
+	onOrder := make(map[int]float64)
+	for _, item := range openItems {
+		onOrder[item.ProductID] = item.Quantity
+	}
+
+	groups := make(map[string]*models.SuggestedOrderGroup)
+	for _, level := range levels {
+		product := level.Product
+		if product.Status != "available" {
+			continue
+		}
+
+		needed := level.Quantity - onHand[level.ProductID] - onOrder[level.ProductID]
+		if needed <= 0 {
+			continue
+		}
+
+		count := int(math.Ceil(needed))
+		item := models.SuggestedItem{
+			ProductID:  product.ID,
+			Name:       product.Name,
+			Unit:       product.Unit,
+			ParLevel:   level.Quantity,
+			OnHand:     onHand[level.ProductID],
+			OnOrder:    onOrder[level.ProductID],
+			Count:      count,
+			Price:      product.Price,
+			TotalPrice: product.Price * float64(count),
+		}
+
+		group, exists := groups[product.Supplier]
+		if !exists {
+			group = &models.SuggestedOrderGroup{Supplier: product.Supplier}
+			groups[product.Supplier] = group
+		}
+		group.Items = append(group.Items, item)
+		group.TotalPrice += item.TotalPrice
+	}
+
+	suggestions := make([]models.SuggestedOrderGroup, 0, len(groups))
+	for _, group := range groups {
+		suggestions = append(suggestions, *group)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Supplier < suggestions[j].Supplier
+	})
+
+	return suggestions, nil
+}
+
+// adjustStock 调整库存并记录变动，减少库存时按先到期先出扣减批次。
+// 减少的数量超过现有库存时返回 ErrInsufficientStock，不做任何修改
+func adjustStock(tx *gorm.DB, productID int, delta float64, movementType, reference, operator string) (models.Inventory, []models.BatchAllocation, error) {
+	inventory := models.Inventory{ProductID: productID}
+	if err := tx.FirstOrInit(&inventory, "product_id = ?", productID).Error; err != nil {
+		return inventory, nil, err
+	}
+
+	var allocations []models.BatchAllocation
+	if delta < 0 {
+		if inventory.Quantity+delta < -stockEpsilon {
+			return inventory, nil, fmt.Errorf("%w: 商品ID %d 现有 %.2f，需要 %.2f", ErrInsufficientStock, productID, inventory.Quantity, -delta)
+		}
+
+		var err error
+		allocations, err = consumeBatches(tx, productID, -delta)
+		if err != nil {
+			return inventory, nil, err
+		}
+	}
+
+	inventory.Quantity += delta
+	inventory.UpdatedAt = time.Now()
+	if err := tx.Save(&inventory).Error; err != nil {
+		return inventory, nil, err
+	}
+
+	movement := models.StockMovement{
+		ProductID: productID,
+		Type:      movementType,
+		Quantity:  delta,
+		Reference: reference,
+		Operator:  operator,
+		RequestID: requestID(tx),
+		CreatedAt: time.Now(),
+	}
+	return inventory, allocations, tx.Create(&movement).Error
+}
+
+// setStock 将库存设置为指定数量并记录变动
+func setStock(tx *gorm.DB, productID int, quantity float64, movementType, reference, operator string) (models.Inventory, error) {
+	var current models.Inventory
+	if err := tx.FirstOrInit(&current, "product_id = ?", productID).Error; err != nil {
+		return current, err
+	}
+
+	inventory, _, err := adjustStock(tx, productID, quantity-current.Quantity, movementType, reference, operator)
+	return inventory, err
+}
+
+// consumeBatches 按先到期先出（FEFO）扣减批次剩余数量，未跟踪批次的库存不受影响。
+// 只由 adjustStock 在检查现有库存之后调用
+func consumeBatches(tx *gorm.DB, productID int, quantity float64) ([]models.BatchAllocation, error) {
+	var batches []models.StockBatch
+	if err := tx.Where("product_id = ? AND remaining > 0", productID).
+		Order("expires_at IS NULL, expires_at, received_at, id").
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	var allocations []models.BatchAllocation
+	for _, batch := range batches {
+		if quantity <= 0 {
+			break
+		}
+
+		taken := math.Min(batch.Remaining, quantity)
+		if err := tx.Model(&models.StockBatch{}).Where("id = ?", batch.ID).
+			Update("remaining", batch.Remaining-taken).Error; err != nil {
+			return nil, err
+		}
+
+		allocations = append(allocations, models.BatchAllocation{
+			BatchID:   batch.ID,
+			Quantity:  taken,
+			ExpiresAt: batch.ExpiresAt,
+		})
+		quantity -= taken
+	}
+
+	return allocations, nil
+}
+
+// lastPurchasePrice 获取商品最近一次采购单价，没有采购记录时使用商品当前价格
+func lastPurchasePrice(db *gorm.DB, product models.Product) (float64, error) {
+	var prices []float64
+	err := db.Table("order_items oi").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Where("oi.product_id = ? AND o.status <> ?", product.ID, "cancelled").
+		Order("oi.created_at DESC, oi.id DESC").
+		Limit(1).
+		Pluck("oi.price", &prices).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return product.Price, nil
+	}
+	return prices[0], nil
+}
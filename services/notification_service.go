@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"purches-backend/config"
+	"purches-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewNotificationService(db *gorm.DB, logger *slog.Logger) *NotificationService {
+	return &NotificationService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ns *NotificationService) WithContext(ctx context.Context) *NotificationService {
+	return &NotificationService{db: ns.db.WithContext(ctx), logger: ns.logger}
+}
+
+// GetNotifications 获取当前用户的通知
+func (ns *NotificationService) GetNotifications(unreadOnly bool) ([]models.Notification, error) {
+	var user models.User
+	if err := ns.db.FirstOrCreate(&user, models.User{OpenID: config.GetConfig().App.DefaultUser}).Error; err != nil {
+		return nil, err
+	}
+
+	query := ns.db.Where("user_id = ?", user.ID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkRead 标记通知为已读
+func (ns *NotificationService) MarkRead(notificationID int) error {
+	result := ns.db.Model(&models.Notification{}).Where("id = ?", notificationID).Update("is_read", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// notify 给用户发送通知
+func notify(tx *gorm.DB, userID uint, notificationType, title, content, reference string) error {
+	notification := models.Notification{
+		UserID:    userID,
+		Type:      notificationType,
+		Title:     title,
+		Content:   content,
+		Reference: reference,
+		RequestID: requestID(tx),
+		CreatedAt: time.Now(),
+	}
+	return tx.Create(&notification).Error
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"purches-backend/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// utf8BOM 让 Excel 以 UTF-8 打开 CSV，避免中文乱码
+const utf8BOM = "\xEF\xBB\xBF"
+
+// exportSheet Excel 导出的工作表名称
+const exportSheet = "订单明细"
+
+// exportBatchSize 导出时每批从数据库读取的订单数，内存占用与导出范围无关
+const exportBatchSize = 200
+
+// exportExcludedStatuses 不计入小计和合计的订单状态，这些订单仍列出明细，状态列可见
+var exportExcludedStatuses = map[string]bool{"cancelled": true, "rejected": true}
+
+// orderExportHeader 订单导出表头，每行一个订单商品
+var orderExportHeader = []interface{}{
+	"订单号", "门店", "供应商", "下单时间", "状态",
+	"商品", "单位", "单价", "数量", "商品小计",
+	"订单金额", "最终价格", "差额",
+}
+
+// ExportFileInfo 返回导出格式对应的 Content-Type 和文件扩展名
+func ExportFileInfo(format string) (string, string) {
+	if format == "csv" {
+		return "text/csv; charset=utf-8", "csv"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"
+}
+
+// WriteOrderExport 按格式将订单明细流式写入 w，按供应商分组并附供应商小计和合计，
+// 已取消和已驳回的订单不计入小计和合计。
+// 第一批订单读取成功后才开始写入，在此之前出错时 w 中没有任何内容
+func (os *OrderService) WriteOrderExport(w io.Writer, req models.ExportOrdersRequest) error {
+	query, err := os.exportOrdersQuery(req)
+	if err != nil {
+		return err
+	}
+
+	// 按 (供应商, 订单号) 分页读取，订单号按时间递增
+	var lastSupplier, lastID string
+	nextBatch := func() ([]models.Order, error) {
+		batchQuery := query.Session(&gorm.Session{})
+		if lastID != "" {
+			batchQuery = batchQuery.Where("supplier > ? OR (supplier = ? AND id > ?)", lastSupplier, lastSupplier, lastID)
+		}
+		var orders []models.Order
+		err := batchQuery.Order("supplier, id").Limit(exportBatchSize).Preload("Products").Find(&orders).Error
+		return orders, err
+	}
+
+	orders, err := nextBatch()
+	if err != nil {
+		return err
+	}
+
+	writer, err := newRowWriter(req.Format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteRow(orderExportHeader); err != nil {
+		return err
+	}
+
+	var subtotal, total exportSubtotal
+	for {
+		for _, order := range orders {
+			if subtotal.listed > 0 && order.Supplier != subtotal.supplier {
+				if err := writer.WriteRow(subtotal.row(subtotal.supplier + " 小计")); err != nil {
+					return err
+				}
+				subtotal = exportSubtotal{}
+			}
+			subtotal.supplier = order.Supplier
+
+			for _, row := range orderExportRows(order) {
+				if err := writer.WriteRow(row); err != nil {
+					return err
+				}
+			}
+			subtotal.add(order)
+			total.add(order)
+		}
+
+		if len(orders) < exportBatchSize {
+			break
+		}
+		lastSupplier = orders[len(orders)-1].Supplier
+		lastID = orders[len(orders)-1].ID
+		if orders, err = nextBatch(); err != nil {
+			return err
+		}
+	}
+
+	if subtotal.listed > 0 {
+		if err := writer.WriteRow(subtotal.row(subtotal.supplier + " 小计")); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteRow(total.row("合计")); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// ValidateOrderExport 检查导出筛选条件
+func (os *OrderService) ValidateOrderExport(req models.ExportOrdersRequest) error {
+	_, err := os.exportOrdersQuery(req)
+	return err
+}
+
+// exportOrdersQuery 构建导出订单的筛选条件
+func (os *OrderService) exportOrdersQuery(req models.ExportOrdersRequest) (*gorm.DB, error) {
+	query, err := applyDateRange(os.db.Model(&models.Order{}), "created_at", req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Supplier != "" {
+		query = query.Where("supplier = ?", req.Supplier)
+	}
+	if req.Store != "" {
+		query = query.Where("store = ?", req.Store)
+	}
+	return query, nil
+}
+
+// orderExportRows 生成订单的导出行，订单金额相关列只写在第一行，避免求和时重复计算
+func orderExportRows(order models.Order) [][]interface{} {
+	finalPrice := order.TotalPrice
+	if order.FinalPrice != nil {
+		finalPrice = *order.FinalPrice
+	}
+
+	rows := make([][]interface{}, 0, len(order.Products))
+	for i, item := range order.Products {
+		row := []interface{}{
+			order.ID, order.Store, order.Supplier, order.CreatedAt.Format("2006-01-02 15:04"), order.Status,
+			item.Name, item.Unit, item.Price, item.Count, item.TotalPrice,
+		}
+		if i == 0 {
+			row = append(row, order.TotalPrice, finalPrice, finalPrice-order.TotalPrice)
+		} else {
+			row = append(row, nil, nil, nil)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// exportSubtotal 导出小计，listed 为列出的订单数，orders 为计入金额的订单数
+type exportSubtotal struct {
+	supplier   string
+	listed     int
+	orders     int
+	items      float64
+	totalPrice float64
+	finalPrice float64
+}
+
+func (s *exportSubtotal) add(order models.Order) {
+	s.listed++
+	if exportExcludedStatuses[order.Status] {
+		return
+	}
+	s.orders++
+	for _, item := range order.Products {
+		s.items += item.TotalPrice
+	}
+	s.totalPrice += order.TotalPrice
+	if order.FinalPrice != nil {
+		s.finalPrice += *order.FinalPrice
+	} else {
+		s.finalPrice += order.TotalPrice
+	}
+}
+
+func (s *exportSubtotal) row(label string) []interface{} {
+	return []interface{}{
+		label, nil, nil, nil, fmt.Sprintf("%d 单", s.orders),
+		nil, nil, nil, nil, s.items,
+		s.totalPrice, s.finalPrice, s.finalPrice - s.totalPrice,
+	}
+}
+
+// rowWriter 导出文件的逐行写入器
+type rowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// newRowWriter 根据导出格式创建写入器
+func newRowWriter(format string, w io.Writer) (rowWriter, error) {
+	if format == "csv" {
+		return newCSVRowWriter(w)
+	}
+	return newXLSXRowWriter(w, exportSheet)
+}
+
+// csvRowWriter 写入带 BOM 的 UTF-8 CSV
+type csvRowWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVRowWriter(w io.Writer) (*csvRowWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	return &csvRowWriter{writer: csv.NewWriter(w)}, nil
+}
+
+func (cw *csvRowWriter) WriteRow(values []interface{}) error {
+	cw.record = cw.record[:0]
+	for _, value := range values {
+		cw.record = append(cw.record, formatCSVValue(value))
+	}
+	return cw.writer.Write(cw.record)
+}
+
+func (cw *csvRowWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// formatCSVValue 金额保留两位小数
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,24 +1,49 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"purches-backend/config"
+	"purches-backend/metrics"
 	"purches-backend/models"
+	"slices"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrOrderNotReceivable 订单不在可收货的状态
+	ErrOrderNotReceivable = errors.New("只有待处理、已确认或配送中的订单可以收货")
+	// ErrReceiveItemNotInOrder 收货明细中有订单以外的商品
+	ErrReceiveItemNotInOrder = errors.New("收货商品不在订单中")
+	// ErrOrderNotDraft 只有草稿订单可以修改或提交
+	ErrOrderNotDraft = errors.New("只有草稿订单可以修改或提交")
+	// ErrOrderAwaitingApproval 待审批订单只能审批或取消
+	ErrOrderAwaitingApproval = errors.New("订单待审批，只能审批或取消")
+	// ErrOrderStatusTransition 不允许通过更新状态接口进行的状态变更
+	ErrOrderStatusTransition = errors.New("不允许的订单状态变更")
+)
+
 type OrderService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
-func NewOrderService(db *gorm.DB) *OrderService {
+func NewOrderService(db *gorm.DB, logger *slog.Logger) *OrderService {
 	return &OrderService{
-		db: db,
+		db:     db,
+		logger: logger,
 	}
 }
 
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (os *OrderService) WithContext(ctx context.Context) *OrderService {
+	return &OrderService{db: os.db.WithContext(ctx), logger: os.logger}
+}
+
 // getDefaultUserID 获取默认用户ID
 func (os *OrderService) getDefaultUserID() string {
 	return config.GetConfig().App.DefaultUser
@@ -34,81 +59,147 @@ func (os *OrderService) CreateOrder(req models.CreateOrderRequest) ([]models.Ord
 		return nil, err
 	}
 
+	var createdOrders []models.Order
+	err := os.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		store := req.Store
+		if store == "" {
+			store = defaultStore()
+		}
+		createdOrders, err = createOrders(tx, user.ID, store, req.Items, req.Notes, "pending")
+		if err != nil {
+			return err
+		}
+
+		// 清空购物车（如果是从购物车提交的）
+		return tx.Where("user_id = ?", user.ID).Delete(&models.CartItem{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logOrdersCreated(os.logger, os.db, createdOrders, "cart")
+	return createdOrders, nil
+}
+
+// createOrders 按供应商分组创建订单
+func createOrders(tx *gorm.DB, userID uint, store string, items []models.OrderItemRequest, notes, status string) ([]models.Order, error) {
+	var requester models.User
+	if err := tx.First(&requester, userID).Error; err != nil {
+		return nil, err
+	}
+
 	// 获取商品信息并按供应商分组
 	supplierGroups := make(map[string][]models.OrderItemRequest)
 	productMap := make(map[int]models.Product)
+	var suppliers []string
 
-	for _, item := range req.Items {
+	for _, item := range items {
 		var product models.Product
-		if err := os.db.First(&product, item.ProductID).Error; err != nil {
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
 			return nil, fmt.Errorf("商品ID %d 不存在", item.ProductID)
 		}
 		productMap[item.ProductID] = product
+		if _, exists := supplierGroups[product.Supplier]; !exists {
+			suppliers = append(suppliers, product.Supplier)
+		}
 		supplierGroups[product.Supplier] = append(supplierGroups[product.Supplier], item)
 	}
 
 	var createdOrders []models.Order
 
 	// 为每个供应商创建订单
-	for supplier, items := range supplierGroups {
-		orderID := fmt.Sprintf("ORD%d%03d", time.Now().Unix(), len(createdOrders)+1)
-
-		var totalPrice float64
-		var orderItems []models.OrderItem
-
-		for _, item := range items {
-			product := productMap[item.ProductID]
-			itemTotal := product.Price * float64(item.Count)
-			totalPrice += itemTotal
-
-			orderItem := models.OrderItem{
-				OrderID:     orderID,
-				ProductID:   item.ProductID,
-				Name:        product.Name,
-				Description: product.Description,
-				Count:       item.Count,
-				Unit:        product.Unit,
-				Price:       product.Price,
-				TotalPrice:  itemTotal,
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
-			}
-			orderItems = append(orderItems, orderItem)
+	for _, supplier := range suppliers {
+		orderID, err := generateOrderID(tx)
+		if err != nil {
+			return nil, err
 		}
 
+		orderItems, totalPrice := buildOrderItems(orderID, supplierGroups[supplier], productMap)
+
 		// 创建订单
 		order := models.Order{
 			ID:         orderID,
-			UserID:     user.ID,
+			UserID:     userID,
+			Store:      store,
 			Supplier:   supplier,
 			TotalPrice: totalPrice,
-			Status:     "pending",
-			Notes:      req.Notes,
+			Status:     status,
+			Notes:      notes,
 			Products:   orderItems,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
 		}
 
-		if err := os.db.Create(&order).Error; err != nil {
-			return nil, err
-		}
-
-		// 创建订单商品明细
-		for _, orderItem := range orderItems {
-			if err := os.db.Create(&orderItem).Error; err != nil {
+		// 提交的订单按审批规则判断是否需要审批
+		if status == "pending" {
+			var products []models.Product
+			for _, item := range supplierGroups[supplier] {
+				products = append(products, productMap[item.ProductID])
+			}
+			if err := requireApprovalIfNeeded(tx, &order, products, requester.Role); err != nil {
+				return nil, err
+			}
+			if err := checkBudgets(tx, &order, productMap); err != nil {
 				return nil, err
 			}
 		}
 
+		// 订单商品明细随订单一起创建
+		if err := tx.Create(&order).Error; err != nil {
+			return nil, err
+		}
+
 		createdOrders = append(createdOrders, order)
 	}
 
-	// 清空购物车（如果是从购物车提交的）
-	os.db.Where("user_id = ?", user.ID).Delete(&models.CartItem{})
-
 	return createdOrders, nil
 }
 
+// buildOrderItems 按当前商品价格生成订单商品明细
+func buildOrderItems(orderID string, items []models.OrderItemRequest, productMap map[int]models.Product) ([]models.OrderItem, float64) {
+	var totalPrice float64
+	var orderItems []models.OrderItem
+
+	for _, item := range items {
+		product := productMap[item.ProductID]
+		itemTotal := product.Price * float64(item.Count)
+		totalPrice += itemTotal
+
+		orderItems = append(orderItems, models.OrderItem{
+			OrderID:     orderID,
+			ProductID:   item.ProductID,
+			Name:        product.Name,
+			Description: product.Description,
+			Count:       item.Count,
+			Unit:        product.Unit,
+			Price:       product.Price,
+			TotalPrice:  itemTotal,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		})
+	}
+
+	return orderItems, totalPrice
+}
+
+// generateOrderID 生成订单号，同一秒内的订单依次递增序号
+func generateOrderID(tx *gorm.DB) (string, error) {
+	prefix := fmt.Sprintf("ORD%d", time.Now().Unix())
+	for seq := 1; ; seq++ {
+		orderID := fmt.Sprintf("%s%03d", prefix, seq)
+
+		var count int64
+		// 已归档的订单号也不能复用
+		if err := tx.Unscoped().Model(&models.Order{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return orderID, nil
+		}
+	}
+}
+
 // GetOrders 获取订单列表
 func (os *OrderService) GetOrders(req models.OrderListRequest) (*models.OrderListResponse, error) {
 	userID := os.getDefaultUserID()
@@ -129,6 +220,9 @@ func (os *OrderService) GetOrders(req models.OrderListRequest) (*models.OrderLis
 	if req.Status != "" {
 		query = query.Where("status = ?", req.Status)
 	}
+	if req.Store != "" {
+		query = query.Where("store = ?", req.Store)
+	}
 
 	// 分页查询
 	var orders []models.Order
@@ -137,17 +231,18 @@ func (os *OrderService) GetOrders(req models.OrderListRequest) (*models.OrderLis
 		return nil, err
 	}
 
-	// 统计供应商信息
+	// 统计供应商信息（使用标准 SQL，兼容 SQLite、PostgreSQL 和 MySQL）
 	var suppliers []models.SupplierSummary
 	if err := os.db.Raw(`
-		SELECT supplier, 
-			   COUNT(*) as order_count,
-			   SUM(total_price) as total_price,
-			   COUNT(DISTINCT product_id) as product_count
+		SELECT o.supplier,
+			   COUNT(DISTINCT o.id) as order_count,
+			   COALESCE(SUM(oi.total_price), 0) as total_price,
+			   COUNT(DISTINCT oi.product_id) as product_count
 		FROM orders o
 		LEFT JOIN order_items oi ON o.id = oi.order_id
-		WHERE o.user_id = ?
-		GROUP BY supplier
+		WHERE o.user_id = ? AND o.deleted_at IS NULL
+		GROUP BY o.supplier
+		ORDER BY o.supplier
 	`, user.ID).Scan(&suppliers).Error; err != nil {
 		return nil, err
 	}
@@ -163,26 +258,52 @@ func (os *OrderService) GetOrders(req models.OrderListRequest) (*models.OrderLis
 // GetOrderByID 根据ID获取订单
 func (os *OrderService) GetOrderByID(orderID string) (*models.Order, error) {
 	var order models.Order
-	if err := os.db.Preload("Products").First(&order, "id = ?", orderID).Error; err != nil {
+	if err := os.db.Preload("Products.Product", withArchived).First(&order, "id = ?", orderID).Error; err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
+// orderStatusTransitions 状态接口允许的订单状态变更。草稿只能通过提交接口、待审批只能通过审批接口
+// 进入待处理（经过审批规则和预算检查），只能直接取消；已完成、已取消和驳回的订单不能再变更，
+// 取消的订单不能恢复，否则取消后再改为待处理就绕过了审批
+var orderStatusTransitions = map[string][]string{
+	"draft":             {"cancelled"},
+	"awaiting_approval": {"cancelled"},
+	"pending":           {"confirmed", "delivering", "completed", "cancelled"},
+	"confirmed":         {"delivering", "completed", "cancelled"},
+	"delivering":        {"completed", "cancelled"},
+}
+
 // UpdateOrderStatus 更新订单状态
 func (os *OrderService) UpdateOrderStatus(orderID string, req models.UpdateOrderStatusRequest) error {
 	var order models.Order
 	if err := os.db.First(&order, "id = ?", orderID).Error; err != nil {
 		return err
 	}
+	db := withOrderFields(os.db, &order)
+
+	// 审批状态只能通过审批接口变更
+	if (order.Status == "awaiting_approval" && req.Status != "cancelled") ||
+		(order.Status != "awaiting_approval" && req.Status == "awaiting_approval") {
+		return ErrOrderAwaitingApproval
+	}
+	if !slices.Contains(orderStatusTransitions[order.Status], req.Status) {
+		return fmt.Errorf("%w: %s → %s", ErrOrderStatusTransition, order.Status, req.Status)
+	}
 
+	previousStatus := order.Status
 	order.Status = req.Status
 	if req.Notes != "" {
 		order.Notes = req.Notes
 	}
 	order.UpdatedAt = time.Now()
 
-	return os.db.Save(&order).Error
+	if err := db.Save(&order).Error; err != nil {
+		return err
+	}
+	logEvent(os.logger, db, "订单状态已更新", "from", previousStatus, "to", order.Status)
+	return nil
 }
 
 // UpdateOrderFinalPrice 更新订单最终价格
@@ -191,32 +312,214 @@ func (os *OrderService) UpdateOrderFinalPrice(orderID string, finalPrice float64
 	if err := os.db.First(&order, "id = ?", orderID).Error; err != nil {
 		return err
 	}
+	db := withOrderFields(os.db, &order)
 
+	previousPrice := order.TotalPrice
+	if order.FinalPrice != nil {
+		previousPrice = *order.FinalPrice
+	}
 	order.FinalPrice = &finalPrice
 	order.UpdatedAt = time.Now()
 
-	return os.db.Save(&order).Error
+	if err := db.Save(&order).Error; err != nil {
+		return err
+	}
+	logEvent(os.logger, db, "订单最终价格已修改", "supplier", order.Supplier,
+		"total_price", order.TotalPrice, "previous_price", previousPrice, "final_price", finalPrice)
+	metrics.FinalPriceOverridden(order.Supplier)
+	return nil
 }
 
-// ExportOrders 导出订单数据
-func (os *OrderService) ExportOrders(req models.ExportOrdersRequest) ([]models.Order, error) {
-	query := os.db.Model(&models.Order{})
+// receivableStatuses 可以收货的订单状态
+var receivableStatuses = map[string]bool{"pending": true, "confirmed": true, "delivering": true}
 
-	// 筛选条件
-	if req.DateFrom != "" {
-		query = query.Where("created_at >= ?", req.DateFrom)
+// ReceiveOrder 订单收货：按到货批次入库并将订单标记为已完成
+func (os *OrderService) ReceiveOrder(orderID string, req models.ReceiveOrderRequest) (*models.ReceiveOrderResponse, error) {
+	order, err := os.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
 	}
-	if req.DateTo != "" {
-		query = query.Where("created_at <= ?", req.DateTo)
+	db := withOrderFields(os.db, order)
+	// 草稿、待审批和驳回的订单还没有经过审批和预算检查，收货会绕过审批
+	if !receivableStatuses[order.Status] {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotReceivable, order.Status)
 	}
-	if req.Supplier != "" {
-		query = query.Where("supplier = ?", req.Supplier)
+
+	// 只能收订单中的商品，未指定收货明细时按订单数量全部收货
+	items := req.Items
+	ordered := make(map[int]bool, len(order.Products))
+	for _, orderItem := range order.Products {
+		ordered[orderItem.ProductID] = true
+	}
+	for _, item := range items {
+		if !ordered[item.ProductID] {
+			return nil, fmt.Errorf("%w: 商品ID %d", ErrReceiveItemNotInOrder, item.ProductID)
+		}
+	}
+	if len(items) == 0 {
+		for _, orderItem := range order.Products {
+			items = append(items, models.ReceiveItemRequest{
+				ProductID: orderItem.ProductID,
+				Quantity:  float64(orderItem.Count),
+			})
+		}
 	}
 
-	var orders []models.Order
-	if err := query.Preload("Products").Find(&orders).Error; err != nil {
+	receivedAt := time.Now()
+	var batches []models.StockBatch
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			if item.Quantity <= 0 {
+				continue
+			}
+
+			var product models.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				return fmt.Errorf("商品ID %d 不存在", item.ProductID)
+			}
+
+			expiresAt := item.ExpiresAt
+			if expiresAt == nil && product.ShelfLife > 0 {
+				expiry := receivedAt.AddDate(0, 0, product.ShelfLife)
+				expiresAt = &expiry
+			}
+
+			batch := models.StockBatch{
+				ProductID:  item.ProductID,
+				OrderID:    order.ID,
+				Quantity:   item.Quantity,
+				Remaining:  item.Quantity,
+				ReceivedAt: receivedAt,
+				ExpiresAt:  expiresAt,
+			}
+			if err := tx.Create(&batch).Error; err != nil {
+				return err
+			}
+
+			if _, _, err := adjustStock(tx, item.ProductID, item.Quantity, "receive", order.ID, req.ReceivedBy); err != nil {
+				return err
+			}
+
+			batch.Product = product
+			batches = append(batches, batch)
+		}
+
+		order.Status = "completed"
+		order.UpdatedAt = receivedAt
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":     order.Status,
+			"updated_at": order.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ReceiveOrderResponse{
+		Order:   *order,
+		Batches: batches,
+	}
+
+	logEvent(os.logger, db, "订单已收货", "supplier", order.Supplier, "batches", len(batches))
+	return response, nil
+}
+
+// UpdateDraftOrderItems 修改草稿订单的商品（同一供应商的商品）
+func (os *OrderService) UpdateDraftOrderItems(orderID string, req models.UpdateOrderItemsRequest) (*models.Order, error) {
+	order, err := os.GetOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	db := withOrderFields(os.db, order)
+	if order.Status != "draft" {
+		return nil, ErrOrderNotDraft
+	}
+
+	productMap := make(map[int]models.Product)
+	for _, item := range req.Items {
+		var product models.Product
+		if err := db.First(&product, item.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("商品ID %d 不存在", item.ProductID)
+		}
+		if product.Supplier != order.Supplier {
+			return nil, fmt.Errorf("商品 %s 不属于供应商 %s", product.Name, order.Supplier)
+		}
+		productMap[item.ProductID] = product
+	}
+
+	orderItems, totalPrice := buildOrderItems(order.ID, req.Items, productMap)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&orderItems).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"total_price": totalPrice,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return os.GetOrderByID(order.ID)
+}
+
+// SubmitDraftOrder 提交草稿订单，触发审批规则时进入待审批状态
+func (os *OrderService) SubmitDraftOrder(orderID string) (*models.Order, error) {
+	var order *models.Order
+	if err := os.db.Preload("Products.Product", withArchived).First(&order, "id = ?", orderID).Error; err != nil {
+		return nil, err
+	}
+	db := withOrderFields(os.db, order)
+	if order.Status != "draft" {
+		return nil, ErrOrderNotDraft
+	}
+
+	var requester models.User
+	if err := db.First(&requester, order.UserID).Error; err != nil {
+		return nil, err
+	}
+	var products []models.Product
+	productMap := make(map[int]models.Product)
+	for _, item := range order.Products {
+		products = append(products, item.Product)
+		productMap[item.ProductID] = item.Product
+	}
+
+	order.Status = "pending"
+	order.UpdatedAt = time.Now()
+	if err := requireApprovalIfNeeded(db, order, products, requester.Role); err != nil {
+		return nil, err
+	}
+	if err := checkBudgets(db, order, productMap); err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"status":          order.Status,
+		"approval_reason": order.ApprovalReason,
+		"updated_at":      order.UpdatedAt,
+	}).Error; err != nil {
 		return nil, err
 	}
 
-	return orders, nil
+	logEvent(os.logger, db, "草稿订单已提交", "status", order.Status)
+	return order, nil
+}
+
+// requireApprovalIfNeeded 订单触发审批规则时改为待审批状态
+func requireApprovalIfNeeded(tx *gorm.DB, order *models.Order, products []models.Product, requesterRole string) error {
+	reason, err := approvalReason(tx, order, products, requesterRole)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		order.Status = "awaiting_approval"
+		order.ApprovalReason = reason
+	}
+	return nil
 }
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"purches-backend/config"
+	"purches-backend/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSchedule 常规订单计划设置错误
+var ErrInvalidSchedule = errors.New("常规订单计划设置错误")
+
+// standingOrderRetryDelay 常规订单生成失败且无法计算下次计划时间时，延后重试的间隔
+const standingOrderRetryDelay = 24 * time.Hour
+
+type OrderTemplateService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewOrderTemplateService(db *gorm.DB, logger *slog.Logger) *OrderTemplateService {
+	return &OrderTemplateService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ts *OrderTemplateService) WithContext(ctx context.Context) *OrderTemplateService {
+	return &OrderTemplateService{db: ts.db.WithContext(ctx), logger: ts.logger}
+}
+
+// getDefaultUser 获取默认用户
+func (ts *OrderTemplateService) getDefaultUser() (*models.User, error) {
+	var user models.User
+	if err := ts.db.FirstOrCreate(&user, models.User{OpenID: config.GetConfig().App.DefaultUser}).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateTemplate 创建订单模板
+func (ts *OrderTemplateService) CreateTemplate(req models.OrderTemplateRequest) (*models.OrderTemplate, error) {
+	user, err := ts.getDefaultUser()
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := ts.buildTemplateItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	template := models.OrderTemplate{
+		UserID: user.ID,
+		Name:   req.Name,
+		Notes:  req.Notes,
+		Items:  items,
+	}
+	if err := ts.db.Create(&template).Error; err != nil {
+		return nil, err
+	}
+
+	return ts.GetTemplate(template.ID)
+}
+
+// GetTemplates 获取订单模板列表
+func (ts *OrderTemplateService) GetTemplates() ([]models.OrderTemplate, error) {
+	user, err := ts.getDefaultUser()
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []models.OrderTemplate
+	err = ts.db.Preload("Items.Product", withArchived).Where("user_id = ?", user.ID).Order("id").Find(&templates).Error
+	return templates, err
+}
+
+// GetTemplate 获取订单模板详情
+func (ts *OrderTemplateService) GetTemplate(templateID int) (*models.OrderTemplate, error) {
+	var template models.OrderTemplate
+	if err := ts.db.Preload("Items.Product", withArchived).First(&template, templateID).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateTemplate 更新订单模板（整体替换商品）
+func (ts *OrderTemplateService) UpdateTemplate(templateID int, req models.OrderTemplateRequest) (*models.OrderTemplate, error) {
+	template, err := ts.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := ts.buildTemplateItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].TemplateID = template.ID
+	}
+
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.OrderTemplateItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.OrderTemplate{}).Where("id = ?", template.ID).Updates(map[string]interface{}{
+			"name":       req.Name,
+			"notes":      req.Notes,
+			"updated_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.GetTemplate(template.ID)
+}
+
+// DeleteTemplate 删除订单模板及其常规订单
+func (ts *OrderTemplateService) DeleteTemplate(templateID int) error {
+	if _, err := ts.GetTemplate(templateID); err != nil {
+		return err
+	}
+
+	return ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", templateID).Delete(&models.StandingOrder{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", templateID).Delete(&models.OrderTemplateItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.OrderTemplate{}, templateID).Error
+	})
+}
+
+// CreateOrdersFromTemplate 按模板立即下单（已下架商品会被跳过）
+func (ts *OrderTemplateService) CreateOrdersFromTemplate(templateID int) (*models.StandingOrderRunResult, error) {
+	template, err := ts.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StandingOrderRunResult{}
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result.Orders, result.Skipped, err = createOrdersFromTemplate(tx, template, "pending")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	logOrdersCreated(ts.logger, ts.db, result.Orders, "template")
+
+	return result, nil
+}
+
+// buildTemplateItems 校验商品并生成模板商品
+func (ts *OrderTemplateService) buildTemplateItems(requests []models.OrderItemRequest) ([]models.OrderTemplateItem, error) {
+	var items []models.OrderTemplateItem
+	for _, item := range requests {
+		var product models.Product
+		if err := ts.db.First(&product, item.ProductID).Error; err != nil {
+			return nil, fmt.Errorf("商品ID %d 不存在", item.ProductID)
+		}
+		items = append(items, models.OrderTemplateItem{
+			ProductID: item.ProductID,
+			Count:     item.Count,
+		})
+	}
+	return items, nil
+}
+
+// CreateStandingOrder 创建常规订单
+func (ts *OrderTemplateService) CreateStandingOrder(req models.StandingOrderRequest) (*models.StandingOrder, error) {
+	if _, err := ts.GetTemplate(req.TemplateID); err != nil {
+		return nil, err
+	}
+
+	standingOrder := models.StandingOrder{Active: true}
+	if err := applyStandingOrderRequest(&standingOrder, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := ts.db.Create(&standingOrder).Error; err != nil {
+		return nil, err
+	}
+
+	return ts.GetStandingOrder(standingOrder.ID)
+}
+
+// GetStandingOrders 获取常规订单列表
+func (ts *OrderTemplateService) GetStandingOrders() ([]models.StandingOrder, error) {
+	var standingOrders []models.StandingOrder
+	err := ts.db.Preload("Template").Order("id").Find(&standingOrders).Error
+	return standingOrders, err
+}
+
+// GetStandingOrder 获取常规订单详情
+func (ts *OrderTemplateService) GetStandingOrder(standingOrderID int) (*models.StandingOrder, error) {
+	var standingOrder models.StandingOrder
+	if err := ts.db.Preload("Template.Items.Product", withArchived).First(&standingOrder, standingOrderID).Error; err != nil {
+		return nil, err
+	}
+	return &standingOrder, nil
+}
+
+// UpdateStandingOrder 更新常规订单计划
+func (ts *OrderTemplateService) UpdateStandingOrder(standingOrderID int, req models.StandingOrderRequest) (*models.StandingOrder, error) {
+	standingOrder, err := ts.GetStandingOrder(standingOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ts.GetTemplate(req.TemplateID); err != nil {
+		return nil, err
+	}
+
+	if err := applyStandingOrderRequest(standingOrder, req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := ts.db.Omit("Template").Save(standingOrder).Error; err != nil {
+		return nil, err
+	}
+
+	return ts.GetStandingOrder(standingOrder.ID)
+}
+
+// DeleteStandingOrder 删除常规订单
+func (ts *OrderTemplateService) DeleteStandingOrder(standingOrderID int) error {
+	result := ts.db.Delete(&models.StandingOrder{}, standingOrderID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RunStandingOrder 立即执行一次常规订单（不影响下次计划时间）
+func (ts *OrderTemplateService) RunStandingOrder(standingOrderID int) (*models.StandingOrderRunResult, error) {
+	standingOrder, err := ts.GetStandingOrder(standingOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *models.StandingOrderRunResult
+	err = ts.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = runStandingOrder(tx, standingOrder)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	logOrdersCreated(ts.logger, ts.db, result.Orders, "standing_order")
+	return result, nil
+}
+
+// RunDueStandingOrders 生成所有到期的常规订单，由调度器定时调用。
+// 单个常规订单失败时在结果中记录原因并继续生成其他常规订单，返回合并后的错误
+func (ts *OrderTemplateService) RunDueStandingOrders(now time.Time) ([]models.StandingOrderRunResult, error) {
+	var standingOrders []models.StandingOrder
+	if err := ts.db.Preload("Template.Items.Product", withArchived).
+		Where("active = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at, id").
+		Find(&standingOrders).Error; err != nil {
+		return nil, err
+	}
+
+	var results []models.StandingOrderRunResult
+	var errs []error
+	for i := range standingOrders {
+		standingOrder := &standingOrders[i]
+
+		err := ts.db.Transaction(func(tx *gorm.DB) error {
+			result, err := runStandingOrder(tx, standingOrder)
+			if err != nil {
+				return err
+			}
+
+			nextRunAt, err := nextRunTime(standingOrder, now)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.StandingOrder{}).Where("id = ?", standingOrder.ID).Updates(map[string]interface{}{
+				"last_run_at": now,
+				"next_run_at": nextRunAt,
+			}).Error; err != nil {
+				return err
+			}
+
+			results = append(results, *result)
+			return nil
+		})
+		if err != nil {
+			// 记录失败原因后继续生成其他常规订单，失败的常规订单跳过本次并通知采购员
+			err = fmt.Errorf("常规订单 %d 生成失败: %w", standingOrder.ID, err)
+			results = append(results, models.StandingOrderRunResult{StandingOrderID: standingOrder.ID, Error: err.Error()})
+			errs = append(errs, err)
+			if err := ts.skipFailedStandingOrder(standingOrder, now, err); err != nil {
+				errs = append(errs, fmt.Errorf("常规订单 %d 记录失败: %w", standingOrder.ID, err))
+			}
+			continue
+		}
+		logOrdersCreated(ts.logger, ts.db, results[len(results)-1].Orders, "standing_order")
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// skipFailedStandingOrder 跳过生成失败的这一次，下次生成时间推进到下一个计划时间（无法计算时延后重试），
+// 并通知采购员，避免每次检查都重复失败
+func (ts *OrderTemplateService) skipFailedStandingOrder(standingOrder *models.StandingOrder, now time.Time, cause error) error {
+	nextRunAt, err := nextRunTime(standingOrder, now)
+	if err != nil {
+		nextRunAt = now.Add(standingOrderRetryDelay)
+	}
+
+	return ts.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.StandingOrder{}).Where("id = ?", standingOrder.ID).
+			Update("next_run_at", nextRunAt).Error; err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("常规订单「%s」本次生成失败: %v。下次生成时间 %s，如需补单请立即执行常规订单或手动下单",
+			standingOrder.Template.Name, cause, nextRunAt.Format("2006-01-02 15:04"))
+		return notify(tx, standingOrder.Template.UserID, "standing_order_failed", "常规订单生成失败", content, strconv.Itoa(standingOrder.ID))
+	})
+}
+
+// runStandingOrder 按模板生成订单并通知采购员确认，所有商品都已下架没有生成订单时不通知
+func runStandingOrder(tx *gorm.DB, standingOrder *models.StandingOrder) (*models.StandingOrderRunResult, error) {
+	template := &standingOrder.Template
+	orders, skipped, err := createOrdersFromTemplate(tx, template, standingOrder.OrderStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StandingOrderRunResult{
+		StandingOrderID: standingOrder.ID,
+		Orders:          orders,
+		Skipped:         skipped,
+	}
+	if len(orders) == 0 {
+		return result, nil
+	}
+
+	var orderIDs []string
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	action := "请确认或修改"
+	if standingOrder.OrderStatus == "pending" {
+		action = "已直接提交，如需调整请及时修改"
+	}
+	content := fmt.Sprintf("常规订单「%s」已生成 %d 张订单，%s", template.Name, len(orders), action)
+	if len(skipped) > 0 {
+		content += fmt.Sprintf("。以下商品已下架未下单: %s", strings.Join(skipped, "、"))
+	}
+	if err := notify(tx, template.UserID, "standing_order", "常规订单已生成", content, strings.Join(orderIDs, ",")); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// createOrdersFromTemplate 按模板创建订单，跳过不可购买的商品
+func createOrdersFromTemplate(tx *gorm.DB, template *models.OrderTemplate, status string) ([]models.Order, []string, error) {
+	var items []models.OrderItemRequest
+	var skipped []string
+
+	for _, item := range template.Items {
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil || product.Status != "available" {
+			name := item.Product.Name
+			if name == "" {
+				name = strconv.Itoa(item.ProductID)
+			}
+			skipped = append(skipped, name)
+			continue
+		}
+		items = append(items, models.OrderItemRequest{ProductID: item.ProductID, Count: item.Count})
+	}
+
+	if len(items) == 0 {
+		return nil, skipped, nil
+	}
+
+	orders, err := createOrders(tx, template.UserID, defaultStore(), items, template.Notes, status)
+	return orders, skipped, err
+}
+
+// applyStandingOrderRequest 校验请求并计算下次生成时间
+func applyStandingOrderRequest(standingOrder *models.StandingOrder, req models.StandingOrderRequest, now time.Time) error {
+	if _, err := time.Parse("15:04", req.RunAt); err != nil {
+		return fmt.Errorf("%w: 生成时间格式应为 HH:MM", ErrInvalidSchedule)
+	}
+
+	startDate := truncateToDate(now)
+	if req.StartDate != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.StartDate, time.Local)
+		if err != nil {
+			return fmt.Errorf("%w: 开始日期格式应为 YYYY-MM-DD", ErrInvalidSchedule)
+		}
+		startDate = parsed
+	}
+
+	var weekdays []string
+	switch req.Frequency {
+	case "weekly":
+		if len(req.Weekdays) == 0 {
+			return fmt.Errorf("%w: 每周计划需要指定星期", ErrInvalidSchedule)
+		}
+		for _, weekday := range req.Weekdays {
+			weekdays = append(weekdays, strconv.Itoa(weekday))
+		}
+	case "interval":
+		if req.IntervalDays < 1 {
+			return fmt.Errorf("%w: 间隔天数至少为1", ErrInvalidSchedule)
+		}
+	}
+
+	standingOrder.TemplateID = req.TemplateID
+	standingOrder.Frequency = req.Frequency
+	standingOrder.Weekdays = strings.Join(weekdays, ",")
+	standingOrder.IntervalDays = req.IntervalDays
+	standingOrder.RunAt = req.RunAt
+	standingOrder.StartDate = startDate
+	standingOrder.OrderStatus = req.OrderStatus
+	if standingOrder.OrderStatus == "" {
+		standingOrder.OrderStatus = "draft"
+	}
+	if req.Active != nil {
+		standingOrder.Active = *req.Active
+	}
+
+	nextRunAt, err := nextRunTime(standingOrder, now)
+	if err != nil {
+		return err
+	}
+	standingOrder.NextRunAt = nextRunAt
+	return nil
+}
+
+// nextRunTime 计算 after 之后的下一次生成时间
+func nextRunTime(standingOrder *models.StandingOrder, after time.Time) (time.Time, error) {
+	runAt, err := time.Parse("15:04", standingOrder.RunAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: 生成时间格式应为 HH:MM", ErrInvalidSchedule)
+	}
+
+	weekdays := make(map[time.Weekday]bool)
+	for _, value := range strings.Split(standingOrder.Weekdays, ",") {
+		if weekday, err := strconv.Atoi(value); err == nil {
+			weekdays[time.Weekday(weekday)] = true
+		}
+	}
+
+	startDate := truncateToDate(standingOrder.StartDate)
+	day := truncateToDate(after)
+	if day.Before(startDate) {
+		day = startDate
+	}
+
+	interval := standingOrder.IntervalDays
+	if interval < 1 {
+		interval = 1
+	}
+
+	for i := 0; i <= 366*interval; i++ {
+		candidate := time.Date(day.Year(), day.Month(), day.Day(), runAt.Hour(), runAt.Minute(), 0, 0, time.Local)
+
+		var matches bool
+		switch standingOrder.Frequency {
+		case "daily":
+			matches = true
+		case "weekly":
+			matches = weekdays[day.Weekday()]
+		case "interval":
+			matches = daysBetween(startDate, day)%interval == 0
+		}
+
+		if matches && candidate.After(after) {
+			return candidate, nil
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, fmt.Errorf("%w: 无法计算下次生成时间", ErrInvalidSchedule)
+}
+
+// truncateToDate 取本地时间的日期部分
+func truncateToDate(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween 计算两个日期相差的天数（不受夏令时影响）
+func daysBetween(from, to time.Time) int {
+	fromUTC := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toUTC := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toUTC.Sub(fromUTC).Hours() / 24)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"purches-backend/models"
+)
+
+// productExportSheet 商品目录导出的工作表名称
+const productExportSheet = "商品目录"
+
+// productExportHeader 商品目录导出表头，与导入时自动识别的列名一致，导出的文件可直接重新导入
+var productExportHeader = []interface{}{
+	"编号", "商品名称", "价格", "单位", "描述", "供应商", "分类", "保质期", "状态",
+}
+
+// ExportProducts 获取要导出的商品目录，按商品ID排序
+func (ps *ProductService) ExportProducts(req models.ExportProductsRequest) ([]models.ProductExportItem, error) {
+	var products []models.Product
+	query := filterProducts(ps.db.Model(&models.Product{}), req.Supplier, req.Search, req.Status)
+	if err := query.Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ProductExportItem, 0, len(products))
+	for _, product := range products {
+		items = append(items, models.ProductExportItem{
+			ID:          product.ID,
+			ExternalID:  product.ExternalID,
+			Name:        product.Name,
+			Price:       product.Price,
+			Unit:        product.Unit,
+			Description: product.Description,
+			Supplier:    product.Supplier,
+			Category:    product.Category,
+			ShelfLife:   product.ShelfLife,
+			Status:      product.Status,
+		})
+	}
+	return items, nil
+}
+
+// ProductExportFileInfo 返回商品目录导出格式对应的 Content-Type 和文件扩展名
+func ProductExportFileInfo(format string) (string, string) {
+	if format == "" || format == "json" {
+		return "application/json; charset=utf-8", "json"
+	}
+	return ExportFileInfo(format)
+}
+
+// WriteProductExport 按格式将商品目录写入 w
+func (ps *ProductService) WriteProductExport(w io.Writer, req models.ExportProductsRequest) error {
+	items, err := ps.ExportProducts(req)
+	if err != nil {
+		return err
+	}
+
+	if req.Format == "" || req.Format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(items)
+	}
+
+	var writer rowWriter
+	if req.Format == "csv" {
+		writer, err = newCSVRowWriter(w)
+	} else {
+		writer, err = newXLSXRowWriter(w, productExportSheet)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteRow(productExportHeader); err != nil {
+		return err
+	}
+	for _, item := range items {
+		// 没有外部编号的商品留空，重新导入时按 供应商+商品名称 匹配
+		if err := writer.WriteRow([]interface{}{
+			item.ExternalID, item.Name, item.Price, item.Unit, item.Description,
+			item.Supplier, item.Category, item.ShelfLife, item.Status,
+		}); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"purches-backend/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrImportInvalid 导入数据校验未通过，未写入任何数据
+	ErrImportInvalid = errors.New("导入数据校验未通过")
+	// ErrImportColumnsMissing 缺少必需的列
+	ErrImportColumnsMissing = errors.New("缺少必需的列")
+	// ErrImportFileUnreadable 文件无法解析
+	ErrImportFileUnreadable = errors.New("无法解析导入文件")
+)
+
+// knownUnits 允许导入的计量单位
+var knownUnits = map[string]bool{
+	"斤": true, "公斤": true, "千克": true, "克": true, "kg": true, "g": true,
+	"个": true, "只": true, "条": true, "根": true, "块": true, "片": true, "颗": true, "棵": true, "把": true,
+	"包": true, "袋": true, "箱": true, "盒": true, "瓶": true, "罐": true, "桶": true, "升": true, "毫升": true,
+	"件": true, "份": true, "扎": true, "捆": true, "板": true, "打": true, "提": true, "对": true, "张": true,
+}
+
+// importFieldAliases 未指定列映射时按表头自动识别的列名
+var importFieldAliases = map[string][]string{
+	"externalId":  {"externalId", "编号", "商品编号", "外部编号"},
+	"name":        {"name", "商品名称", "名称", "品名", "商品"},
+	"price":       {"price", "价格", "单价"},
+	"unit":        {"unit", "单位"},
+	"description": {"description", "描述", "说明", "处理要求", "备注"},
+	"supplier":    {"supplier", "供应商", "档口"},
+	"category":    {"category", "分类", "类别"},
+	"shelfLife":   {"shelfLife", "保质期", "保质期(天)"},
+	"status":      {"status", "状态"},
+}
+
+// importStatuses 导入时允许的商品状态，为空时不修改
+var importStatuses = map[string]bool{"available": true, "unavailable": true, "discontinued": true}
+
+// requiredImportFields 必需的列
+var requiredImportFields = []string{"name", "price", "unit", "supplier"}
+
+// ImportProductsFile 从 CSV 或 xlsx 文件同步商品目录。mapping 为 字段 -> 表头 的列映射，未指定的字段按常用列名自动识别。
+// 文件中尚不存在的供应商只有列在 newSuppliers 中（经过确认）才会创建，否则作为行错误报告，避免供应商名称写错时误建供应商。
+// dryRun 时只返回逐行校验报告和差异预览；否则校验全部通过才写入，有错误时返回 ErrImportInvalid 和报告
+func (ps *ProductService) ImportProductsFile(r io.Reader, format string, mapping map[string]string, newSuppliers []string, dryRun, discontinueMissing bool) (*models.ProductImportReport, error) {
+	rows, err := readImportRows(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	// 已有的供应商（包括已归档的，同步时跳过）
+	var existing []string
+	if err := ps.db.Unscoped().Model(&models.Supplier{}).Pluck("name", &existing).Error; err != nil {
+		return nil, err
+	}
+	suppliers := make(map[string]bool, len(existing)+len(newSuppliers))
+	for _, name := range existing {
+		suppliers[name] = true
+	}
+	confirmed := make(map[string]bool, len(newSuppliers))
+	for _, name := range newSuppliers {
+		confirmed[strings.TrimSpace(name)] = true
+	}
+
+	products, report, err := parseImportRows(rows, mapping, suppliers, confirmed)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+
+	if len(report.Errors) > 0 {
+		if dryRun {
+			return report, nil
+		}
+		return report, ErrImportInvalid
+	}
+
+	report.Summary, err = ps.SyncProducts(products, discontinueMissing, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		report.Imported = len(products)
+	}
+
+	return report, nil
+}
+
+// ensureSupplier 供应商不存在时创建，返回是否新建以及供应商是否已归档。已归档的供应商不会自动恢复
+func ensureSupplier(tx *gorm.DB, name string) (created, archived bool, err error) {
+	supplier := models.Supplier{Name: name, Status: "active", CreatedAt: time.Now()}
+	result := tx.Unscoped().Where("name = ?", name).FirstOrCreate(&supplier)
+	if result.Error != nil {
+		return false, false, result.Error
+	}
+	return result.RowsAffected > 0, supplier.DeletedAt.Valid, nil
+}
+
+// readImportRows 读取文件中的所有行（第一行为表头）
+func readImportRows(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case "csv":
+		reader := bufio.NewReader(r)
+		// 跳过 Excel 保存 CSV 时写入的 BOM
+		if bom, err := reader.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+			reader.Discard(len(utf8BOM))
+		}
+		csvReader := csv.NewReader(reader)
+		csvReader.FieldsPerRecord = -1
+
+		var rows [][]string
+		for {
+			record, err := csvReader.Read()
+			if err == io.EOF {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrImportFileUnreadable, err)
+			}
+			// csv 会跳过空行，补齐以保持报告中的行号与文件一致
+			line, _ := csvReader.FieldPos(0)
+			for len(rows) < line-1 {
+				rows = append(rows, nil)
+			}
+			rows = append(rows, record)
+		}
+	case "xlsx":
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFileUnreadable, err)
+		}
+		defer file.Close()
+		rows, err := file.GetRows(file.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrImportFileUnreadable, err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s", format)
+	}
+}
+
+// resolveImportColumns 根据列映射和表头确定每个字段所在的列
+func resolveImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range importFieldAliases {
+		if column, ok := mapping[field]; ok && column != "" {
+			i, exists := index[column]
+			if !exists {
+				return nil, fmt.Errorf("%w: 文件中没有列 %q（映射字段 %s）", ErrImportColumnsMissing, column, field)
+			}
+			columns[field] = i
+			continue
+		}
+		for _, alias := range aliases {
+			if i, exists := index[alias]; exists {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredImportFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrImportColumnsMissing, strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// parseImportRows 按列映射解析并逐行校验。suppliers 为已有的供应商，confirmed 为确认新建的供应商
+func parseImportRows(rows [][]string, mapping map[string]string, suppliers, confirmed map[string]bool) ([]models.ImportProduct, *models.ProductImportReport, error) {
+	// 跳过开头的空行，第一个非空行为表头
+	headerIndex := 0
+	for headerIndex < len(rows) && isBlankRow(rows[headerIndex]) {
+		headerIndex++
+	}
+	if headerIndex == len(rows) {
+		return nil, nil, fmt.Errorf("%w: 文件为空", ErrImportColumnsMissing)
+	}
+
+	columns, err := resolveImportColumns(rows[headerIndex], mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &models.ProductImportReport{Errors: []models.ProductImportRowError{}, NewSuppliers: []string{}}
+	var products []models.ImportProduct
+	seen := make(map[string]int)
+	newSuppliers := make(map[string]bool)
+
+	for i, row := range rows[headerIndex+1:] {
+		if isBlankRow(row) {
+			continue
+		}
+		rowNumber := headerIndex + i + 2
+		report.TotalRows++
+
+		value := func(field string) string {
+			column, ok := columns[field]
+			if !ok || column >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[column])
+		}
+		addError := func(field, message string) {
+			report.Errors = append(report.Errors, models.ProductImportRowError{
+				Row: rowNumber, Field: field, Value: value(field), Message: message,
+			})
+		}
+
+		errorCount := len(report.Errors)
+		product := models.ImportProduct{
+			ExternalID: value("externalId"),
+			Name:       value("name"),
+			Unit:       value("unit"),
+			Supplier:   value("supplier"),
+			Status:     value("status"),
+		}
+		// 文件中有该列时才更新，没有该列时保留已有内容
+		if _, ok := columns["description"]; ok {
+			description := value("description")
+			product.Description = &description
+		}
+		if _, ok := columns["category"]; ok {
+			category := value("category")
+			product.Category = &category
+		}
+
+		if product.Name == "" {
+			addError("name", "商品名称不能为空")
+		}
+
+		price, err := parseImportPrice(value("price"))
+		switch {
+		case err != nil:
+			addError("price", "价格格式错误")
+		case price <= 0:
+			addError("price", "价格必须大于0")
+		default:
+			product.Price = price
+		}
+
+		if product.Unit == "" {
+			addError("unit", "单位不能为空")
+		} else if !knownUnits[product.Unit] {
+			addError("unit", "未知单位")
+		}
+
+		if product.Supplier == "" {
+			addError("supplier", "缺少供应商")
+		} else if !suppliers[product.Supplier] {
+			if !newSuppliers[product.Supplier] {
+				newSuppliers[product.Supplier] = true
+				report.NewSuppliers = append(report.NewSuppliers, product.Supplier)
+			}
+			if !confirmed[product.Supplier] {
+				addError("supplier", "未知供应商，确认新增后重新提交")
+			}
+		}
+
+		if _, ok := columns["shelfLife"]; ok {
+			days := 0
+			if shelfLife := value("shelfLife"); shelfLife != "" {
+				days, err = strconv.Atoi(shelfLife)
+				if err != nil || days < 0 {
+					addError("shelfLife", "保质期应为非负整数（天）")
+				}
+			}
+			product.ShelfLife = &days
+		}
+
+		if product.Status != "" && !importStatuses[product.Status] {
+			addError("status", "未知状态")
+		}
+
+		if product.Name != "" && product.Supplier != "" {
+			key := productSyncKey(product.Supplier, product.Name)
+			if first, exists := seen[key]; exists {
+				addError("name", fmt.Sprintf("与第 %d 行重复", first))
+			} else {
+				seen[key] = rowNumber
+			}
+		}
+		if product.ExternalID != "" {
+			key := "id\x00" + product.ExternalID
+			if first, exists := seen[key]; exists {
+				addError("externalId", fmt.Sprintf("编号与第 %d 行重复", first))
+			} else {
+				seen[key] = rowNumber
+			}
+		}
+
+		if len(report.Errors) == errorCount {
+			report.ValidRows++
+			products = append(products, product)
+		}
+	}
+
+	return products, report, nil
+}
+
+// parseImportPrice 解析价格，允许带货币符号和千分位
+func parseImportPrice(value string) (float64, error) {
+	value = strings.NewReplacer("¥", "", "￥", "", ",", "", "元", "").Replace(value)
+	return strconv.ParseFloat(strings.TrimSpace(value), 64)
+}
+
+// isBlankRow 是否为空行
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportFileFormat 根据文件名判断导入文件格式
+func ImportFileFormat(filename string) string {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return "csv"
+	case strings.HasSuffix(lower, ".xlsx"):
+		return "xlsx"
+	default:
+		return ""
+	}
+}
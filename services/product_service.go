@@ -1,41 +1,38 @@
 package services
 
 import (
+	"context"
+	"log/slog"
 	"purches-backend/database"
 	"purches-backend/models"
-	"time"
 
 	"gorm.io/gorm"
 )
 
 type ProductService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
-func NewProductService(db *gorm.DB) *ProductService {
+func NewProductService(db *gorm.DB, logger *slog.Logger) *ProductService {
 	return &ProductService{
-		db: db,
+		db:     db,
+		logger: logger,
 	}
 }
 
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ps *ProductService) WithContext(ctx context.Context) *ProductService {
+	return &ProductService{db: ps.db.WithContext(ctx), logger: ps.logger}
+}
+
 // GetProducts 获取商品列表
 func (ps *ProductService) GetProducts(req models.ProductListRequest) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
 	// 构建查询
-	query := ps.db.Model(&models.Product{})
-
-	// 筛选条件
-	if req.Supplier != "" {
-		query = query.Where("supplier = ?", req.Supplier)
-	}
-	if req.Search != "" {
-		query = query.Where("name LIKE ?", "%"+req.Search+"%")
-	}
-	if req.Status != "" {
-		query = query.Where("status = ?", req.Status)
-	}
+	query := filterProducts(ps.db.Model(&models.Product{}), req.Supplier, req.Search, req.Status)
 
 	// 计算总数
 	if err := query.Count(&total).Error; err != nil {
@@ -51,6 +48,20 @@ func (ps *ProductService) GetProducts(req models.ProductListRequest) ([]models.P
 	return products, total, nil
 }
 
+// filterProducts 商品列表和导出共用的筛选条件
+func filterProducts(query *gorm.DB, supplier, search, status string) *gorm.DB {
+	if supplier != "" {
+		query = query.Where("supplier = ?", supplier)
+	}
+	if search != "" {
+		query = query.Where("name LIKE ?", "%"+search+"%")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
 // GetProductByID 根据ID获取商品
 func (ps *ProductService) GetProductByID(id int) (*models.Product, error) {
 	var product models.Product
@@ -60,65 +71,39 @@ func (ps *ProductService) GetProductByID(id int) (*models.Product, error) {
 	return &product, nil
 }
 
-// ImportProducts 批量导入商品
+// ImportProducts 批量导入商品：按外部编号或 供应商+商品名称 更新已有商品，新增其余商品
 func (ps *ProductService) ImportProducts(importProducts []models.ImportProduct) ([]models.Product, error) {
-	var createdProducts []models.Product
-
-	for _, importProduct := range importProducts {
-		product := models.Product{
-			Name:        importProduct.Name,
-			Price:       importProduct.Price,
-			Unit:        importProduct.Unit,
-			Description: importProduct.Description,
-			Supplier:    importProduct.Supplier,
-			Status:      "available",
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+	if _, err := ps.SyncProducts(importProducts, false, false); err != nil {
+		return nil, err
+	}
 
-		if err := ps.db.Create(&product).Error; err != nil {
+	products := make([]models.Product, 0, len(importProducts))
+	for _, item := range importProducts {
+		var product models.Product
+		if err := ps.db.Where("supplier = ? AND name = ?", item.Supplier, item.Name).First(&product).Error; err != nil {
 			return nil, err
 		}
-
-		createdProducts = append(createdProducts, product)
+		products = append(products, product)
 	}
 
-	return createdProducts, nil
+	return products, nil
 }
 
-// ImportProductsFromJSON 从JSON文件导入商品
-func (ps *ProductService) ImportProductsFromJSON(filename string) (int, error) {
+// ImportProductsFromJSON 从JSON文件同步商品目录，JSON 中的 id 作为外部编号，文件中没有的商品标记为停售
+func (ps *ProductService) ImportProductsFromJSON(filename string) (*models.ProductSyncSummary, error) {
 	// 读取JSON文件
 	jsonProducts, err := database.LoadProductsFromJSON(filename)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// 清空现有数据
-	ps.db.Exec("DELETE FROM products")
-	ps.db.Exec("DELETE FROM suppliers")
-
-	// 创建供应商
+	// 创建供应商（已存在的不变）
 	database.CreateSuppliersFromProducts(jsonProducts)
 
-	// 导入商品
-	var createdCount int
+	items := make([]models.ImportProduct, 0, len(jsonProducts))
 	for _, jsonProduct := range jsonProducts {
-		product := models.Product{
-			Name:        jsonProduct.Name,
-			Price:       jsonProduct.Price,
-			Unit:        jsonProduct.Unit,
-			Description: jsonProduct.Description,
-			Supplier:    jsonProduct.Supplier,
-			Status:      "available",
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		if err := ps.db.Create(&product).Error; err == nil {
-			createdCount++
-		}
+		items = append(items, database.ToImportProduct(jsonProduct))
 	}
 
-	return createdCount, nil
+	return ps.SyncProducts(items, true, false)
 }
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"purches-backend/metrics"
+	"purches-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errSyncDryRun 试运行时用于回滚事务
+var errSyncDryRun = errors.New("dry run")
+
+// SyncProducts 按稳定键同步商品目录：有外部编号时按外部编号匹配，否则按 供应商+商品名称 匹配。
+// 已有商品原地更新（商品ID不变，购物车和历史订单引用不受影响），新商品新增；
+// 已归档的商品和已归档供应商的商品跳过并在结果中列出，需要通过归档接口恢复后再同步；
+// discontinueMissing 时目录中没有的商品标记为停售。dryRun 时只计算差异不写入
+func (ps *ProductService) SyncProducts(items []models.ImportProduct, discontinueMissing, dryRun bool) (*models.ProductSyncSummary, error) {
+	var summary *models.ProductSyncSummary
+	err := ps.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		summary, err = syncProducts(tx, items, discontinueMissing)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return errSyncDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSyncDryRun) {
+		return nil, err
+	}
+	if !dryRun {
+		logEvent(ps.logger, ps.db, "商品目录已同步", "added", summary.Added, "updated", summary.Updated,
+			"discontinued", summary.Discontinued, "unchanged", summary.Unchanged, "skipped", summary.Skipped)
+		metrics.ProductsImported(summary.Added, summary.Updated, summary.Discontinued)
+	}
+	return summary, nil
+}
+
+// syncProducts 在事务中执行商品同步
+func syncProducts(tx *gorm.DB, items []models.ImportProduct, discontinueMissing bool) (*models.ProductSyncSummary, error) {
+	// 已归档的商品也参与匹配，避免重复创建
+	var existing []models.Product
+	if err := tx.Unscoped().Order("id").Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	byExternalID := make(map[string]*models.Product)
+	byName := make(map[string]*models.Product)
+	for i := range existing {
+		product := &existing[i]
+		if product.ExternalID != "" {
+			byExternalID[product.ExternalID] = product
+		}
+		byName[productSyncKey(product.Supplier, product.Name)] = product
+	}
+
+	summary := &models.ProductSyncSummary{Changes: []models.ProductSyncChange{}}
+	matched := make(map[int]bool)
+
+	skip := func(productID int, item models.ImportProduct, reason string) {
+		summary.Skipped++
+		summary.Changes = append(summary.Changes, models.ProductSyncChange{
+			ProductID: productID, Name: item.Name, Supplier: item.Supplier, Action: "skipped", Reason: reason,
+		})
+	}
+
+	for _, item := range items {
+		supplierCreated, supplierArchived, err := ensureSupplier(tx, item.Supplier)
+		if err != nil {
+			return nil, err
+		}
+		if supplierCreated {
+			summary.NewSuppliers = append(summary.NewSuppliers, item.Supplier)
+		}
+
+		// 外部编号优先；首次同步时旧商品还没有外部编号，退回按名称匹配
+		var product *models.Product
+		if item.ExternalID != "" {
+			product = byExternalID[item.ExternalID]
+		}
+		if product == nil {
+			candidate := byName[productSyncKey(item.Supplier, item.Name)]
+			if candidate != nil && (candidate.ExternalID == "" || item.ExternalID == "" || candidate.ExternalID == item.ExternalID) {
+				product = candidate
+			}
+		}
+
+		if supplierArchived {
+			productID := 0
+			if product != nil {
+				productID = product.ID
+				matched[product.ID] = true
+			}
+			skip(productID, item, "供应商已归档")
+			continue
+		}
+
+		if product == nil {
+			created := models.Product{
+				ExternalID: item.ExternalID,
+				Name:       item.Name,
+				Price:      item.Price,
+				Unit:       item.Unit,
+				Supplier:   item.Supplier,
+				Status:     "available",
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
+			}
+			if item.Description != nil {
+				created.Description = *item.Description
+			}
+			if item.Category != nil {
+				created.Category = *item.Category
+			}
+			if item.ShelfLife != nil {
+				created.ShelfLife = *item.ShelfLife
+			}
+			if item.Status != "" {
+				created.Status = item.Status
+			}
+			if err := tx.Create(&created).Error; err != nil {
+				return nil, err
+			}
+			matched[created.ID] = true
+			summary.Added++
+			summary.Changes = append(summary.Changes, models.ProductSyncChange{
+				ProductID: created.ID, Name: created.Name, Supplier: created.Supplier, Action: "added",
+			})
+			continue
+		}
+
+		if matched[product.ID] {
+			continue
+		}
+		matched[product.ID] = true
+
+		if product.DeletedAt.Valid {
+			skip(product.ID, item, "商品已归档")
+			continue
+		}
+
+		updates, fields := productSyncUpdates(*product, item)
+		if len(updates) == 0 {
+			summary.Unchanged++
+			continue
+		}
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+		summary.Updated++
+		summary.Changes = append(summary.Changes, models.ProductSyncChange{
+			ProductID: product.ID, Name: item.Name, Supplier: item.Supplier, Action: "updated", Fields: fields,
+		})
+	}
+
+	if discontinueMissing {
+		for _, product := range existing {
+			if matched[product.ID] || product.Status == "discontinued" || product.DeletedAt.Valid {
+				continue
+			}
+			if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+				"status":     "discontinued",
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+				return nil, err
+			}
+			summary.Discontinued++
+			summary.Changes = append(summary.Changes, models.ProductSyncChange{
+				ProductID: product.ID, Name: product.Name, Supplier: product.Supplier, Action: "discontinued",
+			})
+		}
+	}
+
+	return summary, nil
+}
+
+// productSyncUpdates 比较目录中的商品与现有商品，返回需要更新的列和变化的字段名
+func productSyncUpdates(product models.Product, item models.ImportProduct) (map[string]interface{}, []string) {
+	updates := make(map[string]interface{})
+	var fields []string
+	set := func(field, column string, changed bool, value interface{}) {
+		if changed {
+			updates[column] = value
+			fields = append(fields, field)
+		}
+	}
+
+	set("externalId", "external_id", item.ExternalID != "" && item.ExternalID != product.ExternalID, item.ExternalID)
+	set("name", "name", item.Name != product.Name, item.Name)
+	set("supplier", "supplier", item.Supplier != product.Supplier, item.Supplier)
+	set("price", "price", math.Abs(item.Price-product.Price) >= 0.005, item.Price)
+	set("unit", "unit", item.Unit != product.Unit, item.Unit)
+	// 可选字段只在目录提供时比较，缺少的列不会清空已有内容
+	if item.Description != nil {
+		set("description", "description", *item.Description != product.Description, *item.Description)
+	}
+	if item.Category != nil {
+		set("category", "category", *item.Category != product.Category, *item.Category)
+	}
+	if item.ShelfLife != nil {
+		set("shelfLife", "shelf_life", *item.ShelfLife != product.ShelfLife, *item.ShelfLife)
+	}
+
+	// 目录中重新出现的停售商品恢复为可购买
+	status := item.Status
+	if status == "" && product.Status == "discontinued" {
+		status = "available"
+	}
+	set("status", "status", status != "" && status != product.Status, status)
+
+	return updates, fields
+}
+
+// productSyncKey 按名称匹配时的键
+func productSyncKey(supplier, name string) string {
+	return supplier + "\x00" + name
+}
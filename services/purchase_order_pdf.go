@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"purches-backend/config"
+	"purches-backend/models"
+	"purches-backend/pdf"
+
+	"gorm.io/gorm"
+)
+
+// 采购单版面（单位：点）
+const (
+	slipMargin      = 40.0
+	slipBottom      = pdf.PageHeight - 60
+	slipNameX       = 70.0
+	slipNameWidth   = 290.0
+	slipCountRight  = 395.0
+	slipUnitX       = 405.0
+	slipPriceRight  = 480.0
+	slipAmountRight = pdf.PageWidth - slipMargin
+)
+
+// printableStatuses 可以打印采购单的订单状态
+var printableStatuses = []string{"awaiting_approval", "pending", "confirmed", "delivering", "completed"}
+
+// WritePurchaseOrderPDF 生成单个订单的采购单 PDF
+func (os *OrderService) WritePurchaseOrderPDF(w io.Writer, orderID string) error {
+	order, err := os.GetOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+
+	doc := pdf.New()
+	if err := os.renderPurchaseOrder(doc, *order); err != nil {
+		return err
+	}
+	_, err = doc.WriteTo(w)
+	return err
+}
+
+// WriteDailyPurchaseOrdersPDF 生成当天所有供应商的采购单合集：首页为汇总，之后每个订单一张采购单
+func (os *OrderService) WriteDailyPurchaseOrdersPDF(w io.Writer, req models.DailyPurchaseOrdersRequest) error {
+	query, err := applyDateRange(os.db.Model(&models.Order{}), "created_at", req.Date, req.Date)
+	if err != nil {
+		return err
+	}
+	if req.Store != "" {
+		query = query.Where("store = ?", req.Store)
+	}
+
+	var orders []models.Order
+	if err := query.Where("status IN ?", printableStatuses).
+		Order("supplier, id").
+		Preload("Products").
+		Find(&orders).Error; err != nil {
+		return err
+	}
+
+	doc := pdf.New()
+	renderDailySummary(doc, req, orders)
+	for _, order := range orders {
+		if err := os.renderPurchaseOrder(doc, order); err != nil {
+			return err
+		}
+	}
+	_, err = doc.WriteTo(w)
+	return err
+}
+
+// renderDailySummary 当天采购汇总页
+func renderDailySummary(doc *pdf.Document, req models.DailyPurchaseOrdersRequest, orders []models.Order) {
+	page := doc.AddPage()
+	y := 70.0
+	page.Text(slipMargin, y, 20, config.GetConfig().App.Name+" 每日采购汇总")
+	y += 28
+	store := req.Store
+	if store == "" {
+		store = "全部门店"
+	}
+	page.Text(slipMargin, y, 11, fmt.Sprintf("日期：%s    门店：%s", req.Date, store))
+	y += 24
+
+	page.Text(slipMargin, y, 11, "供应商")
+	page.TextRight(slipPriceRight, y, 11, "订单数")
+	page.TextRight(slipAmountRight, y, 11, "金额")
+	y += 6
+	page.Line(slipMargin, y, slipAmountRight, y, 0.8)
+	y += 18
+
+	if len(orders) == 0 {
+		page.Text(slipMargin, y, 11, "当日没有需要打印的订单")
+		return
+	}
+
+	var total float64
+	for i := 0; i < len(orders); {
+		supplier := orders[i].Supplier
+		var count int
+		var amount float64
+		for ; i < len(orders) && orders[i].Supplier == supplier; i++ {
+			count++
+			amount += orders[i].TotalPrice
+		}
+		total += amount
+
+		if y > slipBottom {
+			page = doc.AddPage()
+			y = 70
+		}
+		page.Text(slipMargin, y, 11, supplier)
+		page.TextRight(slipPriceRight, y, 11, fmt.Sprintf("%d", count))
+		page.TextRight(slipAmountRight, y, 11, fmt.Sprintf("￥%.2f", amount))
+		y += 20
+	}
+
+	page.Line(slipMargin, y-12, slipAmountRight, y-12, 0.8)
+	y += 4
+	page.Text(slipMargin, y, 12, "合计")
+	page.TextRight(slipPriceRight, y, 12, fmt.Sprintf("%d", len(orders)))
+	page.TextRight(slipAmountRight, y, 12, fmt.Sprintf("￥%.2f", total))
+}
+
+// slipLayout 采购单排版状态，超出页面时自动换页并重复表头
+type slipLayout struct {
+	doc   *pdf.Document
+	page  *pdf.Page
+	order models.Order
+	y     float64
+	pages int
+}
+
+// renderPurchaseOrder 排版一张采购单
+func (os *OrderService) renderPurchaseOrder(doc *pdf.Document, order models.Order) error {
+	var supplier models.Supplier
+	if err := os.db.First(&supplier, "name = ?", order.Supplier).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	supplier.Name = order.Supplier
+
+	layout := &slipLayout{doc: doc, order: order}
+	layout.newPage()
+
+	// 门店与订单信息
+	page := layout.page
+	page.Text(slipMargin, layout.y, 11, fmt.Sprintf("门店：%s", order.Store))
+	page.TextRight(slipAmountRight, layout.y, 11, fmt.Sprintf("下单时间：%s", order.CreatedAt.Format("2006-01-02 15:04")))
+	layout.y += 20
+
+	// 供应商联系方式
+	page.Text(slipMargin, layout.y, 12, fmt.Sprintf("供应商：%s", supplier.Name))
+	layout.y += 18
+	contact := fmt.Sprintf("联系人：%s    电话：%s", supplier.ContactPerson, supplier.Phone)
+	page.Text(slipMargin, layout.y, 10, contact)
+	layout.y += 16
+	if supplier.Address != "" {
+		for _, line := range pdf.WrapText("地址："+supplier.Address, 10, slipAmountRight-slipMargin) {
+			page.Text(slipMargin, layout.y, 10, line)
+			layout.y += 14
+		}
+	}
+	layout.y += 10
+
+	layout.tableHeader()
+	for i, item := range order.Products {
+		descriptionLines := []string{}
+		if item.Description != "" {
+			descriptionLines = pdf.WrapText(item.Description, 9, slipNameWidth)
+		}
+		layout.ensure(18 + float64(len(descriptionLines))*13)
+
+		page := layout.page
+		page.Text(slipMargin, layout.y, 11, fmt.Sprintf("%d", i+1))
+		page.Text(slipNameX, layout.y, 11, item.Name)
+		page.TextRight(slipCountRight, layout.y, 11, fmt.Sprintf("%d", item.Count))
+		page.Text(slipUnitX, layout.y, 11, item.Unit)
+		page.TextRight(slipPriceRight, layout.y, 11, fmt.Sprintf("%.2f", item.Price))
+		page.TextRight(slipAmountRight, layout.y, 11, fmt.Sprintf("%.2f", item.TotalPrice))
+		layout.y += 15
+
+		// 商品描述包含处理要求，如"去掉内脏"，完整打印
+		for _, line := range descriptionLines {
+			page.Text(slipNameX, layout.y, 9, line)
+			layout.y += 13
+		}
+		layout.y += 3
+		page.Line(slipMargin, layout.y-9, slipAmountRight, layout.y-9, 0.3)
+	}
+
+	// 合计
+	layout.ensure(60)
+	layout.y += 6
+	layout.page.TextRight(slipAmountRight, layout.y, 12, fmt.Sprintf("合计：￥%.2f", order.TotalPrice))
+	layout.y += 18
+	if order.FinalPrice != nil {
+		layout.page.TextRight(slipAmountRight, layout.y, 12, fmt.Sprintf("最终价格：￥%.2f", *order.FinalPrice))
+		layout.y += 18
+	}
+
+	// 备注
+	if order.Notes != "" {
+		lines := pdf.WrapText("备注："+order.Notes, 11, slipAmountRight-slipMargin)
+		layout.ensure(float64(len(lines)) * 16)
+		for _, line := range lines {
+			layout.page.Text(slipMargin, layout.y, 11, line)
+			layout.y += 16
+		}
+	}
+
+	// 签收栏
+	layout.ensure(40)
+	layout.y += 24
+	layout.page.Text(slipMargin, layout.y, 11, "供应商签字：______________")
+	layout.page.Text(320, layout.y, 11, "收货人签字：______________")
+
+	return nil
+}
+
+// newPage 开始新的一页并打印标题
+func (l *slipLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.pages++
+	l.y = 60
+
+	title := "采购单"
+	l.page.Text((pdf.PageWidth-pdf.TextWidth(title, 20))/2, l.y, 20, title)
+	l.page.TextRight(slipAmountRight, l.y, 10, fmt.Sprintf("订单号：%s", l.order.ID))
+	if l.pages > 1 {
+		l.page.Text(slipMargin, l.y, 10, fmt.Sprintf("（续 第%d页）", l.pages))
+	}
+	l.y += 30
+}
+
+// tableHeader 打印商品表头
+func (l *slipLayout) tableHeader() {
+	page := l.page
+	page.Line(slipMargin, l.y-14, slipAmountRight, l.y-14, 0.8)
+	page.Text(slipMargin, l.y, 10, "序号")
+	page.Text(slipNameX, l.y, 10, "商品 / 处理要求")
+	page.TextRight(slipCountRight, l.y, 10, "数量")
+	page.Text(slipUnitX, l.y, 10, "单位")
+	page.TextRight(slipPriceRight, l.y, 10, "单价")
+	page.TextRight(slipAmountRight, l.y, 10, "金额")
+	l.y += 6
+	page.Line(slipMargin, l.y, slipAmountRight, l.y, 0.8)
+	l.y += 16
+}
+
+// ensure 剩余空间不足时换页
+func (l *slipLayout) ensure(height float64) {
+	if l.y+height <= slipBottom {
+		return
+	}
+	l.newPage()
+	l.tableHeader()
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"purches-backend/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrStocktakeInProgress 已有进行中的盘点
+	ErrStocktakeInProgress = errors.New("已有进行中的盘点单")
+	// ErrStocktakeClosed 盘点已结束
+	ErrStocktakeClosed = errors.New("盘点单已结束")
+	// ErrStocktakeDisputed 有多人盘点数量不一致的商品，需要复核后再结束盘点
+	ErrStocktakeDisputed = errors.New("有盘点数量不一致的商品需要复核")
+)
+
+type StocktakeService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewStocktakeService(db *gorm.DB, logger *slog.Logger) *StocktakeService {
+	return &StocktakeService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ss *StocktakeService) WithContext(ctx context.Context) *StocktakeService {
+	return &StocktakeService{db: ss.db.WithContext(ctx), logger: ss.logger}
+}
+
+// CreateSession 开始盘点
+func (ss *StocktakeService) CreateSession(req models.CreateStocktakeRequest) (*models.StocktakeSession, error) {
+	var openCount int64
+	if err := ss.db.Model(&models.StocktakeSession{}).Where("status = ?", "open").Count(&openCount).Error; err != nil {
+		return nil, err
+	}
+	if openCount > 0 {
+		return nil, ErrStocktakeInProgress
+	}
+
+	session := models.StocktakeSession{
+		Status:    "open",
+		Notes:     req.Notes,
+		CreatedBy: req.CreatedBy,
+	}
+	if err := ss.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	logEvent(ss.logger, ss.db, "盘点已开始", "session_id", session.ID, "created_by", session.CreatedBy)
+	return &session, nil
+}
+
+// GetSessions 获取盘点单列表
+func (ss *StocktakeService) GetSessions() ([]models.StocktakeSession, error) {
+	var sessions []models.StocktakeSession
+	err := ss.db.Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetSession 获取盘点单详情
+func (ss *StocktakeService) GetSession(sessionID int) (*models.StocktakeSession, error) {
+	var session models.StocktakeSession
+	if err := ss.db.Preload("Counts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("product_id, counted_by")
+	}).Preload("Counts.Product", withArchived).First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SubmitCounts 提交盘点数量，同一人重复提交同一商品时覆盖之前的数量。
+// 同时记录提交时的账面库存，之后的出入库不影响盘点差异
+func (ss *StocktakeService) SubmitCounts(sessionID int, req models.SubmitStocktakeCountsRequest) (*models.StocktakeSession, error) {
+	var session models.StocktakeSession
+	if err := ss.db.First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+	if session.Status != "open" {
+		return nil, ErrStocktakeClosed
+	}
+
+	err := ss.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range req.Counts {
+			var product models.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				return fmt.Errorf("商品ID %d 不存在", item.ProductID)
+			}
+
+			var count models.StocktakeCount
+			result := tx.Where("session_id = ? AND product_id = ? AND counted_by = ?",
+				sessionID, item.ProductID, req.CountedBy).First(&count)
+			if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
+				return result.Error
+			}
+
+			count.SessionID = sessionID
+			count.ProductID = item.ProductID
+			count.CountedBy = req.CountedBy
+			count.Quantity = item.Quantity
+
+			var inventory models.Inventory
+			if err := tx.FirstOrInit(&inventory, "product_id = ?", item.ProductID).Error; err != nil {
+				return err
+			}
+			count.Expected = inventory.Quantity
+			if err := tx.Save(&count).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.GetSession(sessionID)
+}
+
+// GetVariances 计算盘点差异，每个商品取最近一次提交的数量，多人盘点数量不一致时标记需要复核
+func (ss *StocktakeService) GetVariances(sessionID int) (*models.StocktakeVarianceResponse, error) {
+	session, err := ss.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss.buildVarianceReport(ss.db, session)
+}
+
+// CloseSession 结束盘点，按盘点差异（盘点数量 - 提交时的账面库存）调整库存，
+// 盘点后发生的出入库保留。有数量不一致的商品时需要 Force 才能结束，按最近一次的数量调整
+func (ss *StocktakeService) CloseSession(sessionID int, req models.CloseStocktakeRequest) (*models.StocktakeVarianceResponse, error) {
+	session, err := ss.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != "open" {
+		return nil, ErrStocktakeClosed
+	}
+
+	var report *models.StocktakeVarianceResponse
+	err = ss.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		report, err = ss.buildVarianceReport(tx, session)
+		if err != nil {
+			return err
+		}
+		if !req.Force {
+			for _, item := range report.Items {
+				if item.Disputed {
+					return fmt.Errorf("%w: %s", ErrStocktakeDisputed, item.Name)
+				}
+			}
+		}
+
+		reference := fmt.Sprintf("stocktake:%d", session.ID)
+		for _, item := range report.Items {
+			if item.Variance == 0 {
+				continue
+			}
+			if _, _, err := adjustStock(tx, item.ProductID, item.Variance, "stocktake", reference, req.ClosedBy); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		session.Status = "closed"
+		session.ClosedBy = req.ClosedBy
+		session.ClosedAt = &now
+		return tx.Model(&models.StocktakeSession{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+			"status":    session.Status,
+			"closed_by": session.ClosedBy,
+			"closed_at": session.ClosedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Session = *session
+	logEvent(ss.logger, ss.db, "盘点已结束", "session_id", session.ID, "items", len(report.Items),
+		"force", req.Force, "closed_by", session.ClosedBy)
+	return report, nil
+}
+
+// buildVarianceReport 每个商品取最近一次提交的盘点数量，与该次提交时的账面库存比较。
+// 多人盘点同一商品是复盘，数量不相加；数量不一致时标记为需要复核
+func (ss *StocktakeService) buildVarianceReport(db *gorm.DB, session *models.StocktakeSession) (*models.StocktakeVarianceResponse, error) {
+	counts := make([]models.StocktakeCount, len(session.Counts))
+	copy(counts, session.Counts)
+	sort.SliceStable(counts, func(i, j int) bool {
+		if !counts[i].UpdatedAt.Equal(counts[j].UpdatedAt) {
+			return counts[i].UpdatedAt.Before(counts[j].UpdatedAt)
+		}
+		return counts[i].ID < counts[j].ID
+	})
+
+	variances := make(map[int]*models.StocktakeVariance)
+	for _, count := range counts {
+		variance, exists := variances[count.ProductID]
+		if !exists {
+			variance = &models.StocktakeVariance{
+				ProductID: count.ProductID,
+				Name:      count.Product.Name,
+				Unit:      count.Product.Unit,
+				Supplier:  count.Product.Supplier,
+				Counts:    make(map[string]float64),
+			}
+			variances[count.ProductID] = variance
+		} else if math.Abs(count.Quantity-variance.Counted) > stockEpsilon {
+			variance.Disputed = true
+		}
+		variance.Counted = count.Quantity
+		variance.Expected = count.Expected
+		variance.CountedBy = append(variance.CountedBy, count.CountedBy)
+		variance.Counts[count.CountedBy] = count.Quantity
+	}
+
+	report := &models.StocktakeVarianceResponse{
+		Session: *session,
+		Items:   make([]models.StocktakeVariance, 0, len(variances)),
+	}
+
+	for productID, variance := range variances {
+		var product models.Product
+		if err := db.First(&product, productID).Error; err != nil {
+			return nil, err
+		}
+		unitPrice, err := lastPurchasePrice(db, product)
+		if err != nil {
+			return nil, err
+		}
+
+		variance.Variance = variance.Counted - variance.Expected
+		variance.UnitPrice = unitPrice
+		variance.VarianceValue = variance.Variance * unitPrice
+		report.TotalVarianceValue += variance.VarianceValue
+		report.Items = append(report.Items, *variance)
+	}
+
+	sort.Slice(report.Items, func(i, j int) bool {
+		return report.Items[i].ProductID < report.Items[j].ProductID
+	})
+
+	return report, nil
+}
@@ -1,34 +1,42 @@
 package services
 
 import (
+	"context"
+	"log/slog"
 	"purches-backend/models"
 
 	"gorm.io/gorm"
 )
 
 type SupplierService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	logger *slog.Logger
 }
 
-func NewSupplierService(db *gorm.DB) *SupplierService {
+func NewSupplierService(db *gorm.DB, logger *slog.Logger) *SupplierService {
 	return &SupplierService{
-		db: db,
+		db:     db,
+		logger: logger,
 	}
 }
 
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ss *SupplierService) WithContext(ctx context.Context) *SupplierService {
+	return &SupplierService{db: ss.db.WithContext(ctx), logger: ss.logger}
+}
+
 // GetSuppliers 获取供应商列表
 func (ss *SupplierService) GetSuppliers() (*models.SupplierListResponse, error) {
 	var suppliers []models.SupplierInfo
 
-	// 查询供应商及统计信息
+	// 查询供应商及统计信息（使用标准 SQL，兼容 SQLite、PostgreSQL 和 MySQL）
 	err := ss.db.Raw(`
 		SELECT s.name, s.contact_person, s.phone, s.status,
-			   COUNT(DISTINCT p.id) as product_count,
-			   COUNT(DISTINCT o.id) as total_orders
+			   (SELECT COUNT(*) FROM products p WHERE p.supplier = s.name AND p.deleted_at IS NULL) AS product_count,
+			   (SELECT COUNT(*) FROM orders o WHERE o.supplier = s.name AND o.deleted_at IS NULL) AS total_orders
 		FROM suppliers s
-		LEFT JOIN products p ON s.name = p.supplier
-		LEFT JOIN orders o ON s.name = o.supplier
-		GROUP BY s.name, s.contact_person, s.phone, s.status
+		WHERE s.deleted_at IS NULL
+		ORDER BY s.name
 	`).Scan(&suppliers).Error
 
 	if err != nil {
@@ -52,15 +60,13 @@ func (ss *SupplierService) GetSupplierDetail(supplierName string) (*models.Suppl
 	// 统计信息
 	var stats models.SupplierStatistics
 	err := ss.db.Raw(`
-		SELECT COUNT(DISTINCT p.id) as product_count,
-			   COUNT(DISTINCT o.id) as total_orders,
-			   COALESCE(SUM(o.total_price), 0) as total_amount,
-			   COALESCE(AVG(o.total_price), 0) as average_order_amount
-		FROM suppliers s
-		LEFT JOIN products p ON s.name = p.supplier
-		LEFT JOIN orders o ON s.name = o.supplier
-		WHERE s.name = ?
-	`, supplierName).Scan(&stats).Error
+		SELECT (SELECT COUNT(*) FROM products p WHERE p.supplier = ? AND p.deleted_at IS NULL) AS product_count,
+			   COUNT(o.id) AS total_orders,
+			   COALESCE(SUM(o.total_price), 0) AS total_amount,
+			   COALESCE(AVG(o.total_price), 0) AS average_order_amount
+		FROM orders o
+		WHERE o.supplier = ? AND o.deleted_at IS NULL
+	`, supplierName, supplierName).Scan(&stats).Error
 
 	if err != nil {
 		return nil, err
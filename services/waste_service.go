@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"purches-backend/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WasteService struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWasteService(db *gorm.DB, logger *slog.Logger) *WasteService {
+	return &WasteService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithContext 返回绑定请求 context 的服务，SQL 日志和业务日志带上请求相关字段
+func (ws *WasteService) WithContext(ctx context.Context) *WasteService {
+	return &WasteService{db: ws.db.WithContext(ctx), logger: ws.logger}
+}
+
+// LogWaste 登记报损并扣减库存
+func (ws *WasteService) LogWaste(req models.CreateWasteRequest) (*models.WasteRecord, error) {
+	var product models.Product
+	if err := ws.db.First(&product, req.ProductID).Error; err != nil {
+		return nil, err
+	}
+
+	unitCost, err := lastPurchasePrice(ws.db, product)
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.WasteRecord{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Unit:      product.Unit,
+		Supplier:  product.Supplier,
+		Quantity:  req.Quantity,
+		Reason:    req.Reason,
+		PhotoRef:  req.PhotoRef,
+		LoggedBy:  req.LoggedBy,
+		UnitCost:  unitCost,
+		TotalCost: unitCost * req.Quantity,
+		Notes:     req.Notes,
+		CreatedAt: time.Now(),
+	}
+
+	err = ws.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		reference := fmt.Sprintf("waste:%d", record.ID)
+		_, _, err := adjustStock(tx, product.ID, -req.Quantity, "waste", reference, req.LoggedBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	record.Product = product
+	logEvent(ws.logger, ws.db, "报损已登记", "waste_id", record.ID, "product_id", product.ID,
+		"quantity", record.Quantity, "reason", record.Reason, "total_cost", record.TotalCost, "logged_by", record.LoggedBy)
+	return &record, nil
+}
+
+// GetWasteRecords 获取报损记录
+func (ws *WasteService) GetWasteRecords(req models.WasteListRequest) ([]models.WasteRecord, error) {
+	query := ws.db.Model(&models.WasteRecord{})
+
+	// 筛选条件
+	if req.ProductID > 0 {
+		query = query.Where("product_id = ?", req.ProductID)
+	}
+	if req.Supplier != "" {
+		query = query.Where("supplier = ?", req.Supplier)
+	}
+	if req.Reason != "" {
+		query = query.Where("reason = ?", req.Reason)
+	}
+	query, err := applyDateRange(query, "created_at", req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.WasteRecord
+	err = query.Order("created_at DESC").Find(&records).Error
+	return records, err
+}
+
+// GetWasteReport 按商品、供应商或周汇总报损成本
+func (ws *WasteService) GetWasteReport(req models.WasteReportRequest) (*models.WasteReportResponse, error) {
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "product"
+	}
+
+	records, err := ws.GetWasteRecords(models.WasteListRequest{
+		Supplier: req.Supplier,
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 按周分组在内存中完成，避免依赖各数据库不同的日期函数
+	rows := make(map[string]*models.WasteReportRow)
+	response := &models.WasteReportResponse{GroupBy: groupBy}
+
+	for _, record := range records {
+		var key string
+		switch groupBy {
+		case "supplier":
+			key = record.Supplier
+		case "week":
+			year, week := record.CreatedAt.ISOWeek()
+			key = fmt.Sprintf("%d-W%02d", year, week)
+		default:
+			key = strconv.Itoa(record.ProductID)
+		}
+
+		row, exists := rows[key]
+		if !exists {
+			row = &models.WasteReportRow{Key: key, Name: key}
+			if groupBy == "product" {
+				row.Name = record.Name
+				row.Supplier = record.Supplier
+				row.Unit = record.Unit
+			}
+			rows[key] = row
+		}
+
+		// 不同商品单位不同，只有按商品汇总时数量才有意义
+		if groupBy == "product" {
+			row.Quantity += record.Quantity
+		}
+		row.RecordCount++
+		row.TotalCost += record.TotalCost
+		response.TotalCost += record.TotalCost
+	}
+
+	response.Rows = make([]models.WasteReportRow, 0, len(rows))
+	for _, row := range rows {
+		response.Rows = append(response.Rows, *row)
+	}
+
+	// 按周时间顺序排列，其余按成本从高到低
+	sort.Slice(response.Rows, func(i, j int) bool {
+		if groupBy == "week" {
+			return response.Rows[i].Key < response.Rows[j].Key
+		}
+		if response.Rows[i].TotalCost != response.Rows[j].TotalCost {
+			return response.Rows[i].TotalCost > response.Rows[j].TotalCost
+		}
+		return response.Rows[i].Key < response.Rows[j].Key
+	})
+
+	return response, nil
+}
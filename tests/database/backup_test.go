@@ -0,0 +1,186 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/migrations"
+	"purches-backend/models"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDatabase_BackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(dir, "purches.db")}
+
+	db, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+	require.NoError(t, testdata.SeedTestData(db))
+
+	backup := filepath.Join(dir, "backups", "snapshot.db")
+
+	t.Run("在线备份", func(t *testing.T) {
+		require.NoError(t, database.Backup(db, backup))
+
+		_, err := os.Stat(backup)
+		assert.NoError(t, err)
+
+		// 不覆盖已有的备份
+		assert.Error(t, database.Backup(db, backup))
+	})
+
+	// 备份之后的改动在恢复后消失
+	require.NoError(t, db.Where("1 = 1").Delete(&models.Product{}).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	t.Run("损坏的备份不能恢复", func(t *testing.T) {
+		broken := filepath.Join(dir, "broken.db")
+		require.NoError(t, os.WriteFile(broken, []byte("not a database"), 0644))
+
+		_, err := database.Restore(cfg, broken)
+		assert.Error(t, err)
+
+		// 原数据库保持不变
+		_, err = os.Stat(cfg.FilePath)
+		assert.NoError(t, err)
+	})
+
+	t.Run("数据库正在使用时不能恢复", func(t *testing.T) {
+		other, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+		tx := other.Begin()
+		require.NoError(t, tx.Exec("UPDATE products SET price = price").Error)
+
+		_, err = database.Restore(cfg, backup)
+		assert.ErrorIs(t, err, database.ErrDatabaseInUse)
+
+		require.NoError(t, tx.Rollback().Error)
+		sqlDB, err := other.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	})
+
+	t.Run("从备份恢复", func(t *testing.T) {
+		previous, err := database.Restore(cfg, backup)
+		require.NoError(t, err)
+
+		// 恢复前的数据库另存一份
+		_, err = os.Stat(previous)
+		assert.NoError(t, err)
+
+		restored, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+		var count int64
+		require.NoError(t, restored.Model(&models.Product{}).Count(&count).Error)
+		assert.Equal(t, int64(3), count)
+
+		sqlDB, err := restored.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	})
+
+	t.Run("备份路径带特殊字符", func(t *testing.T) {
+		special := filepath.Join(dir, "备份?#1%", "snapshot.db")
+		require.NoError(t, os.MkdirAll(filepath.Dir(special), 0755))
+		data, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(special, data, 0644))
+
+		assert.NoError(t, database.ValidateBackup(special))
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		relative, err := filepath.Rel(wd, special)
+		require.NoError(t, err)
+		assert.NoError(t, database.ValidateBackup(relative))
+
+		target := config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(filepath.Dir(special), "purches.db")}
+		_, err = database.Restore(target, special)
+		assert.NoError(t, err)
+	})
+
+	t.Run("其他数据库不支持", func(t *testing.T) {
+		_, err := database.Restore(config.DatabaseConfig{Type: "postgres"}, backup)
+		assert.ErrorIs(t, err, database.ErrBackupUnsupported)
+	})
+}
+
+func TestDatabase_BackupRetention(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := database.Open(config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(dir, "purches.db")},
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	// 已有三个较早的定时备份和一个手动备份
+	backupDir := filepath.Join(dir, "backups")
+	for _, name := range []string{"purches_20240101_060000.db", "purches_20240101_120000.db", "purches_20240102_060000.db"} {
+		require.NoError(t, database.Backup(db, filepath.Join(backupDir, name)))
+	}
+	require.NoError(t, database.Backup(db, filepath.Join(backupDir, "manual.db")))
+
+	t.Run("按时间点查找备份", func(t *testing.T) {
+		backup, err := database.FindBackup(backupDir, time.Date(2024, 1, 1, 18, 0, 0, 0, time.Local))
+		require.NoError(t, err)
+		assert.Equal(t, "purches_20240101_120000.db", filepath.Base(backup.Path))
+
+		_, err = database.FindBackup(backupDir, time.Date(2023, 12, 31, 0, 0, 0, 0, time.Local))
+		assert.ErrorIs(t, err, database.ErrNoBackup)
+	})
+
+	t.Run("新备份后只保留最近的几个", func(t *testing.T) {
+		backup, err := database.Snapshot(db, backupDir, 2)
+		require.NoError(t, err)
+		assert.NoError(t, database.ValidateBackup(backup.Path))
+
+		backups, err := database.ListBackups(backupDir)
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		assert.Equal(t, "purches_20240102_060000.db", filepath.Base(backups[0].Path))
+		assert.Equal(t, backup.Path, backups[1].Path)
+
+		// 非定时备份的文件不会被清理
+		_, err = os.Stat(filepath.Join(backupDir, "manual.db"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("同一秒内的多次备份不会重名", func(t *testing.T) {
+		first, err := database.Snapshot(db, backupDir, 0)
+		require.NoError(t, err)
+		second, err := database.Snapshot(db, backupDir, 0)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Path, second.Path)
+		assert.True(t, second.Time.After(first.Time))
+
+		backups, err := database.ListBackups(backupDir)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(backups), 2)
+		assert.Equal(t, first.Path, backups[len(backups)-2].Path)
+		assert.Equal(t, second.Path, backups[len(backups)-1].Path)
+		assert.Equal(t, second.Time, backups[len(backups)-1].Time)
+	})
+
+	t.Run("来自更新版本程序的备份不能恢复", func(t *testing.T) {
+		require.NoError(t, db.Create(&migrations.SchemaMigration{Version: 9999, Name: "from_newer_release", AppliedAt: time.Now()}).Error)
+		newer := filepath.Join(dir, "newer.db")
+		require.NoError(t, database.Backup(db, newer))
+
+		err := database.ValidateBackup(newer)
+		assert.ErrorIs(t, err, migrations.ErrUnknownMigration)
+	})
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabases 参与测试的数据库：SQLite 始终测试，PostgreSQL 和 MySQL 通过环境变量提供连接字符串时测试。
+// 加 -tags embeddedpg 时自动启动嵌入式 PostgreSQL（见 postgres_embedded_test.go）
+func testDatabases(t *testing.T) map[string]config.DatabaseConfig {
+	databases := map[string]config.DatabaseConfig{
+		"sqlite": {Type: "sqlite", FilePath: filepath.Join(t.TempDir(), "purches_test.db"), MaxOpenConns: 5, MaxIdleConns: 2},
+	}
+	if dsn := os.Getenv("PURCHES_TEST_POSTGRES_DSN"); dsn != "" {
+		databases["postgres"] = config.DatabaseConfig{Type: "postgres", DSN: dsn, MaxOpenConns: 5, MaxIdleConns: 2}
+	}
+	if dsn := os.Getenv("PURCHES_TEST_MYSQL_DSN"); dsn != "" {
+		databases["mysql"] = config.DatabaseConfig{Type: "mysql", DSN: dsn, MaxOpenConns: 5, MaxIdleConns: 2}
+	}
+	return databases
+}
+
+func TestDatabase_Open(t *testing.T) {
+	t.Run("不支持的数据库类型", func(t *testing.T) {
+		_, err := database.Open(config.DatabaseConfig{Type: "oracle"})
+		assert.Error(t, err)
+	})
+
+	t.Run("SQLite 使用配置的文件路径和连接池", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "custom.db")
+		db, err := database.Open(config.DatabaseConfig{Type: "sqlite", FilePath: path, MaxOpenConns: 3})
+		require.NoError(t, err)
+		require.NoError(t, database.Migrate(db))
+
+		_, err = os.Stat(path)
+		assert.NoError(t, err)
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+		require.NoError(t, sqlDB.Close())
+	})
+
+	t.Run("SQLite 连接使用 WAL 并等待写锁", func(t *testing.T) {
+		db, err := database.Open(config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(t.TempDir(), "wal.db"), MaxOpenConns: 10},
+			&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+		require.NoError(t, database.Migrate(db))
+
+		var journalMode string
+		require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+		assert.Equal(t, "wal", journalMode)
+		var busyTimeout int
+		require.NoError(t, db.Raw("PRAGMA busy_timeout").Scan(&busyTimeout).Error)
+		assert.Equal(t, 5000, busyTimeout)
+
+		// 多个连接同时写入时排队等待，不返回 database is locked
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- db.Transaction(func(tx *gorm.DB) error {
+					var count int64
+					if err := tx.Model(&models.Supplier{}).Count(&count).Error; err != nil {
+						return err
+					}
+					return tx.Create(&models.Supplier{Name: fmt.Sprintf("供应商%d", i), Status: "active"}).Error
+				})
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	})
+}
+
+func TestDatabase_Dialects(t *testing.T) {
+	for name, cfg := range testDatabases(t) {
+		t.Run(name, func(t *testing.T) {
+			db, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			require.NoError(t, err)
+			require.NoError(t, database.Migrate(db))
+			require.NoError(t, testdata.CleanupTestDB(db))
+			require.NoError(t, testdata.SeedTestData(db))
+
+			orderService := services.NewOrderService(db, slog.Default())
+			supplierService := services.NewSupplierService(db, slog.Default())
+			cartService := services.NewCartService(db, slog.Default())
+
+			// 外部数据库重复运行时自增ID不从1开始
+			var products []models.Product
+			require.NoError(t, db.Order("id").Find(&products).Error)
+			require.Len(t, products, 3)
+
+			_, err = cartService.GetCart()
+			require.NoError(t, err)
+			orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+				Items: []models.OrderItemRequest{
+					{ProductID: products[0].ID, Count: 2},
+					{ProductID: products[1].ID, Count: 1},
+					{ProductID: products[2].ID, Count: 1},
+				},
+			})
+			require.NoError(t, err)
+			require.Len(t, orders, 2)
+
+			t.Run("订单列表供应商统计", func(t *testing.T) {
+				list, err := orderService.GetOrders(models.OrderListRequest{Page: 1, Limit: 10})
+
+				require.NoError(t, err)
+				assert.Len(t, list.Orders, 2)
+				require.Len(t, list.Suppliers, 2)
+				assert.Equal(t, "测试供应商A", list.Suppliers[0].Supplier)
+				assert.Equal(t, 1, list.Suppliers[0].OrderCount)
+				assert.Equal(t, 2, list.Suppliers[0].ProductCount)
+				assert.InDelta(t, 46.0, list.Suppliers[0].TotalPrice, 0.001)
+			})
+
+			t.Run("供应商列表和详情统计", func(t *testing.T) {
+				suppliers, err := supplierService.GetSuppliers()
+				require.NoError(t, err)
+				require.Len(t, suppliers.Suppliers, 2)
+				assert.Equal(t, 2, suppliers.Suppliers[0].ProductCount)
+				assert.Equal(t, 1, suppliers.Suppliers[0].TotalOrders)
+
+				// 多个商品不会重复计算订单金额
+				detail, err := supplierService.GetSupplierDetail("测试供应商A")
+				require.NoError(t, err)
+				assert.Equal(t, 2, detail.Statistics.ProductCount)
+				assert.Equal(t, 1, detail.Statistics.TotalOrders)
+				assert.InDelta(t, 46.0, detail.Statistics.TotalAmount, 0.001)
+			})
+
+			require.NoError(t, testdata.CleanupTestDB(db))
+			sqlDB, err := db.DB()
+			require.NoError(t, err)
+			require.NoError(t, sqlDB.Close())
+		})
+	}
+}
+
+func TestDatabase_ResetData(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+
+	t.Run("清空失败时返回错误且不删除数据", func(t *testing.T) {
+		require.NoError(t, db.Migrator().DropTable(&models.Budget{}))
+
+		err := database.ResetData()
+
+		assert.ErrorContains(t, err, "budgets")
+		var count int64
+		db.Model(&models.Product{}).Count(&count)
+		assert.Equal(t, int64(3), count)
+
+		require.NoError(t, db.AutoMigrate(&models.Budget{}))
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestDatabase_ToImportProduct(t *testing.T) {
+	var products []database.ImportProductFromJSON
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"id": 12, "name": "牛蛙", "price": 31, "unit": "斤", "supplier": "F35"},
+		{"id": 7, "externalId": "", "name": "草鱼", "price": 12, "unit": "斤", "supplier": "快驴"},
+		{"id": 8, "externalId": "KL-008", "name": "鲫鱼", "price": 15, "unit": "斤", "supplier": "快驴"}
+	]`), &products))
+
+	// products.json 没有 externalId，使用 id
+	assert.Equal(t, "12", database.ToImportProduct(products[0]).ExternalID)
+	// 导出的目录中 id 是本系统的商品ID，externalId 为空时不使用
+	assert.Equal(t, "", database.ToImportProduct(products[1]).ExternalID)
+	assert.Equal(t, "KL-008", database.ToImportProduct(products[2]).ExternalID)
+}
@@ -0,0 +1,68 @@
+//go:build embeddedpg
+
+package database
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// TestMain 在未提供 PURCHES_TEST_POSTGRES_DSN 时启动嵌入式 PostgreSQL，数据库测试同时在 PostgreSQL 上运行：
+//
+//	go test -tags embeddedpg ./tests/database/
+//
+// 首次运行会下载 PostgreSQL 二进制文件（缓存在 ~/.embedded-postgres-go），PostgreSQL 不能以 root 用户运行
+func TestMain(m *testing.M) {
+	if os.Getenv("PURCHES_TEST_POSTGRES_DSN") != "" {
+		os.Exit(m.Run())
+	}
+
+	dir, err := os.MkdirTemp("", "purches-embedded-pg")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "创建临时目录失败:", err)
+		os.Exit(1)
+	}
+	port, err := freePort()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "获取空闲端口失败:", err)
+		os.Exit(1)
+	}
+
+	postgres := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("purches_test").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		Logger(io.Discard))
+	if err := postgres.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "启动嵌入式 PostgreSQL 失败:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	os.Setenv("PURCHES_TEST_POSTGRES_DSN",
+		fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=purches_test sslmode=disable", port))
+
+	code := m.Run()
+
+	if err := postgres.Stop(); err != nil {
+		fmt.Fprintln(os.Stderr, "停止嵌入式 PostgreSQL 失败:", err)
+		code = 1
+	}
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// freePort 返回一个当前空闲的本地端口
+func freePort() (uint32, error) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return uint32(ln.Addr().(*net.TCPAddr).Port), nil
+}
@@ -0,0 +1,222 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/logging"
+	"purches-backend/middleware"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// decodeLines 解析 JSON 日志，每行一条
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestLogging_New(t *testing.T) {
+	t.Run("生产环境输出 JSON 并带上 context 中的字段", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+
+		ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req-1", User: "user_1"})
+		ctx = logging.WithFields(ctx, logging.Fields{Store: "store_1", OrderID: "ORD1"})
+		logger.InfoContext(ctx, "订单已创建", "supplier", "F35")
+		logger.Debug("不输出")
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "订单已创建", lines[0]["msg"])
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+		assert.Equal(t, "user_1", lines[0]["user"])
+		assert.Equal(t, "store_1", lines[0]["store"])
+		assert.Equal(t, "ORD1", lines[0]["order_id"])
+		assert.Equal(t, "F35", lines[0]["supplier"])
+	})
+
+	t.Run("开发环境输出文本", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, config.AppConfig{Environment: "development", LogLevel: "debug"})
+
+		logger.Debug("调试信息", "key", "value")
+
+		assert.Contains(t, buf.String(), "level=DEBUG")
+		assert.Contains(t, buf.String(), "key=value")
+	})
+}
+
+func TestLogging_GormLogger(t *testing.T) {
+	open := func(t *testing.T, level string) (*gorm.DB, *bytes.Buffer) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: level})
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logging.NewGormLogger(logger)})
+		require.NoError(t, err)
+		require.NoError(t, db.AutoMigrate(&models.Supplier{}))
+		buf.Reset()
+		return db, &buf
+	}
+
+	t.Run("debug 级别输出 SQL", func(t *testing.T) {
+		db, buf := open(t, "debug")
+		ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req-2"})
+
+		require.NoError(t, db.WithContext(ctx).Create(&models.Supplier{Name: "F35"}).Error)
+
+		lines := decodeLines(t, buf)
+		require.NotEmpty(t, lines)
+		assert.Equal(t, "SQL", lines[0]["msg"])
+		assert.Equal(t, "DEBUG", lines[0]["level"])
+		assert.Equal(t, "req-2", lines[0]["request_id"])
+		assert.Contains(t, lines[0]["sql"], "INSERT INTO `suppliers`")
+	})
+
+	t.Run("info 级别只输出错误，记录不存在不算错误", func(t *testing.T) {
+		db, buf := open(t, "info")
+
+		var supplier models.Supplier
+		assert.ErrorIs(t, db.First(&supplier, "name = ?", "不存在").Error, gorm.ErrRecordNotFound)
+		require.NoError(t, db.Create(&models.Supplier{Name: "F35"}).Error)
+		assert.Empty(t, buf.String())
+
+		assert.Error(t, db.Exec("SELECT * FROM missing_table").Error)
+		lines := decodeLines(t, buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "SQL 执行失败", lines[0]["msg"])
+		assert.Equal(t, "ERROR", lines[0]["level"])
+	})
+}
+
+func TestLogging_RequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+
+	r := gin.New()
+	r.Use(middleware.RequestLogger(logger, "user_1", nil))
+	r.GET("/v1/orders/:orderId", func(c *gin.Context) {
+		// 处理请求时的日志也带上请求字段
+		logger.InfoContext(c.Request.Context(), "处理中")
+		c.Status(http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/orders/ORD1?store=store_2", nil))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, "user_1", line["user"])
+		assert.Equal(t, "store_2", line["store"])
+		assert.Equal(t, "ORD1", line["order_id"])
+	}
+	assert.Equal(t, "HTTP 请求", lines[1]["msg"])
+	assert.Equal(t, "WARN", lines[1]["level"])
+	assert.Equal(t, "/v1/orders/:orderId", lines[1]["route"])
+	assert.Equal(t, float64(404), lines[1]["status"])
+}
+
+func TestLogging_ServiceEvents(t *testing.T) {
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+	ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req-1", User: "owner_1"})
+
+	// 注入的日志记录业务事件，带上请求字段和门店
+	budget, err := services.NewBudgetService(db, logger).WithContext(ctx).CreateBudget(models.BudgetRequest{
+		Store: "store_2", Period: "weekly", Amount: 500,
+	})
+	require.NoError(t, err)
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "预算已创建", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "owner_1", lines[0]["user"])
+	assert.Equal(t, "store_2", lines[0]["store"])
+	assert.Equal(t, float64(budget.ID), lines[0]["budget_id"])
+}
+
+func TestLogging_RequestLoggerUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+	approvalService := services.NewApprovalService(db, slog.Default())
+	token, err := approvalService.IssueToken("test_user_001")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+	r := gin.New()
+	r.Use(middleware.RequestLogger(logger, "user_1", approvalService.ResolveUser))
+	r.GET("/v1/users/:openId/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	userOf := func(authorization string) interface{} {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/someone_else/orders", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		return lines[0]["user"]
+	}
+
+	// 有效令牌记录令牌对应的用户，无效令牌和没有令牌时按路径中的 openId
+	assert.Equal(t, "test_user_001", userOf("Bearer "+token))
+	assert.Equal(t, "someone_else", userOf("Bearer invalid"))
+	assert.Equal(t, "someone_else", userOf(""))
+}
+
+func TestLogging_OrderFields(t *testing.T) {
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+	require.NoError(t, db.Create(&models.Order{
+		ID: "ORD1", UserID: 1, Store: "store_2", Supplier: "测试供应商A", TotalPrice: 10.5, Status: "pending",
+	}).Error)
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+	ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req-1"})
+
+	// context 中没有订单号和门店时，服务加载订单后的日志带上该订单的订单号和门店
+	err = services.NewOrderService(db, logger).WithContext(ctx).UpdateOrderStatus("ORD1", models.UpdateOrderStatusRequest{Status: "confirmed"})
+	require.NoError(t, err)
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "订单状态已更新", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "ORD1", lines[0]["order_id"])
+	assert.Equal(t, "store_2", lines[0]["store"])
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/metrics"
+	"purches-backend/middleware"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape 抓取 /metrics，返回状态码和内容
+func scrape(r *gin.Engine, token string) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	body, _ := io.ReadAll(w.Body)
+	return w.Code, string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+	require.NoError(t, metrics.RegisterDB(db))
+
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/v1/orders/:orderId", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+	require.True(t, routes.SetupMetricsRoutes(r, config.MetricsConfig{Enabled: true, Token: "scrape-token"}))
+
+	t.Run("未启用时不注册", func(t *testing.T) {
+		assert.False(t, routes.SetupMetricsRoutes(gin.New(), config.MetricsConfig{}))
+	})
+
+	t.Run("配置了令牌时需要 Bearer 令牌", func(t *testing.T) {
+		code, _ := scrape(r, "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		code, _ = scrape(r, "wrong")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("按路由模板和状态码统计请求", func(t *testing.T) {
+		for _, path := range []string{"/v1/orders/ORD1", "/v1/orders/ORD2", "/not-found"} {
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		code, body := scrape(r, "scrape-token")
+		require.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, `purches_http_requests_total{method="GET",route="/v1/orders/:orderId",status="404"} 2`)
+		assert.Contains(t, body, `purches_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+		assert.Contains(t, body, `purches_http_request_duration_seconds_count{method="GET",route="/v1/orders/:orderId",status="404"} 2`)
+		assert.NotContains(t, body, "ORD1")
+	})
+
+	t.Run("业务计数、SQL 耗时和连接池状态", func(t *testing.T) {
+		cartService := services.NewCartService(db, slog.Default())
+		orderService := services.NewOrderService(db, slog.Default())
+		productService := services.NewProductService(db, slog.Default())
+
+		_, err := cartService.AddToCart(models.AddToCartRequest{ProductID: 1, Count: 2})
+		require.NoError(t, err)
+		orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 1, Count: 3}, {ProductID: 3, Count: 1}},
+		})
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		require.NoError(t, orderService.UpdateOrderFinalPrice(orders[0].ID, 30))
+
+		// 预览不计入同步次数
+		items := []models.ImportProduct{{Name: "测试商品4", Price: 5, Unit: "个", Supplier: "测试供应商B"}}
+		_, err = productService.SyncProducts(items, false, true)
+		require.NoError(t, err)
+		_, err = productService.SyncProducts(items, false, false)
+		require.NoError(t, err)
+
+		_, body := scrape(r, "scrape-token")
+		assert.Contains(t, body, `purches_cart_items_added_total{supplier="测试供应商A"} 1`)
+		assert.Contains(t, body, `purches_orders_created_total{source="cart",supplier="测试供应商A"} 1`)
+		assert.Contains(t, body, `purches_orders_created_total{source="cart",supplier="测试供应商B"} 1`)
+		assert.Contains(t, body, `purches_order_final_price_overrides_total{supplier="`+orders[0].Supplier+`"} 1`)
+		assert.Contains(t, body, "purches_product_imports_total 1")
+		assert.Contains(t, body, `purches_product_import_changes_total{change="added"} 1`)
+		assert.Contains(t, body, `purches_db_query_duration_seconds_count{operation="create",table="orders"} 2`)
+		assert.Contains(t, body, `go_sql_open_connections{db_name="sqlite"}`)
+	})
+}
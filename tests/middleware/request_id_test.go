@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/controllers"
+	"purches-backend/logging"
+	"purches-backend/middleware"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouter 创建带请求 ID、访问日志中间件和购物车接口的路由，日志以 JSON 写入 buf
+func newRouter(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+
+	// 访问日志和业务事件日志写入同一个 logger
+	logger := logging.New(buf, config.AppConfig{Environment: "production", LogLevel: "info"})
+
+	cartController := controllers.NewCartController(services.NewCartService(db, logger))
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.RequestLogger(logger, "user_1", nil))
+	r.POST("/v1/cart/items", cartController.AddToCart)
+	return r
+}
+
+// addToCart 发送加入购物车请求，requestID 为空时不带请求头
+func addToCart(r *gin.Engine, body, requestID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/cart/items", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestID(t *testing.T) {
+	t.Run("沿用请求头中的请求 ID，并写入响应头、响应体和日志", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newRouter(t, &buf)
+
+		w := addToCart(r, `{"productId": 1, "count": 2}`, "mp-1718000000-abc")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "mp-1718000000-abc", w.Header().Get(middleware.RequestIDHeader))
+
+		var response models.APIResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "mp-1718000000-abc", response.RequestID)
+
+		// 业务事件和访问日志都带上请求 ID
+		var messages []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+			assert.Equal(t, "mp-1718000000-abc", entry["request_id"], line)
+			messages = append(messages, entry["msg"].(string))
+		}
+		assert.Equal(t, []string{"商品已加入购物车", "HTTP 请求"}, messages)
+	})
+
+	t.Run("没有请求 ID 时生成，错误响应也带上", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newRouter(t, &buf)
+
+		w := addToCart(r, `{"productId": 1}`, "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		requestID := w.Header().Get(middleware.RequestIDHeader)
+		assert.Len(t, requestID, 32)
+
+		var response models.APIResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, requestID, response.RequestID)
+		assert.Contains(t, buf.String(), `"request_id":"`+requestID+`"`)
+	})
+
+	t.Run("不合法的请求 ID 被替换", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newRouter(t, &buf)
+
+		for _, invalid := range []string{"bad id\nlevel=ERROR", strings.Repeat("a", 65)} {
+			w := addToCart(r, `{"productId": 1, "count": 1}`, invalid)
+			requestID := w.Header().Get(middleware.RequestIDHeader)
+			assert.NotEqual(t, invalid, requestID)
+			assert.Len(t, requestID, 32)
+		}
+	})
+
+	t.Run("每个请求生成不同的请求 ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		r := newRouter(t, &buf)
+
+		first := addToCart(r, `{"productId": 1, "count": 1}`, "").Header().Get(middleware.RequestIDHeader)
+		second := addToCart(r, `{"productId": 1, "count": 1}`, "").Header().Get(middleware.RequestIDHeader)
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/middleware"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_NoWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 响应写入前耗时超过服务的写入超时
+	slow := func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	}
+	r := gin.New()
+	r.GET("/export", middleware.NoWriteTimeout(), slow)
+	r.GET("/slow", slow)
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	t.Run("取消写入超时的接口完整返回", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/export")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "done", string(body))
+	})
+
+	t.Run("其他接口仍受写入超时限制", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/slow")
+		if err == nil {
+			defer resp.Body.Close()
+			_, err = io.ReadAll(resp.Body)
+		}
+		assert.Error(t, err)
+	})
+}
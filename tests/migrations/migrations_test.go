@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"path/filepath"
+	"purches-backend/migrations"
+	"purches-backend/models"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrations.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMigrations_UpDownStatus(t *testing.T) {
+	db := openTestDB(t)
+	total := len(migrations.All())
+
+	pending, err := migrations.Pending(db)
+	require.NoError(t, err)
+	assert.Len(t, pending, total)
+	// 查询迁移状态不建表
+	assert.False(t, db.Migrator().HasTable(&migrations.SchemaMigration{}))
+
+	t.Run("按步数执行", func(t *testing.T) {
+		done, err := migrations.Up(db, 1)
+
+		require.NoError(t, err)
+		require.Len(t, done, 1)
+		assert.Equal(t, int64(1), done[0].Version)
+		assert.True(t, db.Migrator().HasTable(&models.Order{}))
+
+		statuses, err := migrations.Status(db)
+		require.NoError(t, err)
+		require.Len(t, statuses, total)
+		assert.True(t, statuses[0].Applied)
+		assert.NotNil(t, statuses[0].AppliedAt)
+		assert.False(t, statuses[1].Applied)
+	})
+
+	t.Run("执行全部后没有待执行的迁移", func(t *testing.T) {
+		_, err := migrations.Up(db, 0)
+		require.NoError(t, err)
+
+		pending, err := migrations.Pending(db)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		done, err := migrations.Up(db, 0)
+		require.NoError(t, err)
+		assert.Empty(t, done)
+	})
+
+	t.Run("倒序回滚", func(t *testing.T) {
+		done, err := migrations.Down(db, 0)
+
+		require.NoError(t, err)
+		require.Len(t, done, total)
+		assert.Equal(t, int64(1), done[len(done)-1].Version)
+		assert.False(t, db.Migrator().HasTable(&models.Order{}))
+
+		pending, err := migrations.Pending(db)
+		require.NoError(t, err)
+		assert.Len(t, pending, total)
+	})
+
+	t.Run("未知版本不能回滚", func(t *testing.T) {
+		require.NoError(t, db.Create(&migrations.SchemaMigration{Version: 9999, Name: "from_newer_release"}).Error)
+
+		_, err := migrations.Down(db, 1)
+		assert.ErrorIs(t, err, migrations.ErrUnknownMigration)
+	})
+}
+
+func TestMigrations_ExistingDatabase(t *testing.T) {
+	// 引入版本化迁移之前由 AutoMigrate 创建的数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+	require.NoError(t, db.Create(&models.Order{ID: "ORD0001", UserID: 1, Supplier: "测试供应商A", TotalPrice: 10, Status: "completed"}).Error)
+
+	done, err := migrations.Up(db, 0)
+	require.NoError(t, err)
+	assert.Len(t, done, len(migrations.All()))
+
+	// 基线迁移不影响已有数据，旧订单归属默认门店
+	var count int64
+	db.Model(&models.Product{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+
+	var order models.Order
+	require.NoError(t, db.First(&order, "id = ?", "ORD0001").Error)
+	assert.Equal(t, "store_1", order.Store)
+
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+// TestMigrations_SchemaMatchesModels 模型新增的字段必须有对应的迁移
+func TestMigrations_SchemaMatchesModels(t *testing.T) {
+	db := openTestDB(t)
+	_, err := migrations.Up(db, 0)
+	require.NoError(t, err)
+
+	for _, model := range []interface{}{
+		&models.Product{}, &models.Supplier{}, &models.User{}, &models.CartItem{},
+		&models.Order{}, &models.OrderItem{}, &models.Inventory{}, &models.ParLevel{},
+		&models.StockMovement{}, &models.StockBatch{}, &models.WasteRecord{},
+		&models.StocktakeSession{}, &models.StocktakeCount{}, &models.OrderTemplate{},
+		&models.OrderTemplateItem{}, &models.StandingOrder{}, &models.Notification{},
+		&models.ApprovalRule{}, &models.OrderApproval{}, &models.Budget{},
+	} {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		require.True(t, db.Migrator().HasTable(stmt.Schema.Table), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s 缺少迁移", stmt.Schema.Table, field.DBName)
+		}
+	}
+}
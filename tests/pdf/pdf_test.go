@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"purches-backend/pdf"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := pdf.New()
+	page := doc.AddPage()
+	page.Text(40, 60, 12, "牛蛙 50斤")
+	page.Line(40, 70, 555, 70, 0.5)
+	doc.AddPage().Text(40, 60, 12, "第二页")
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	data := buf.Bytes()
+
+	t.Run("文件头尾", func(t *testing.T) {
+		assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+		assert.Contains(t, buf.String(), "/Count 2")
+	})
+
+	t.Run("交叉引用表偏移正确", func(t *testing.T) {
+		match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+		require.NotNil(t, match)
+		xref, err := strconv.Atoi(string(match[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n")))
+
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+		require.Len(t, entries, 9) // 5个公共对象 + 每页2个对象
+		for i, entry := range entries {
+			offset, err := strconv.Atoi(string(entry[1]))
+			require.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "对象 %d 偏移错误", i+1)
+		}
+	})
+
+	t.Run("文字按 UTF-16BE 编码", func(t *testing.T) {
+		assert.Contains(t, buf.String(), "<725B86D900200035003065A4> Tj") // 牛蛙 50斤
+		assert.Contains(t, buf.String(), "40 782 Td")                     // y 坐标从页面顶部计算
+	})
+}
+
+func TestWrapText(t *testing.T) {
+	t.Run("按宽度折行", func(t *testing.T) {
+		lines := pdf.WrapText("牛蛙杀好处理干净去掉内脏和眼睛", 10, 50)
+
+		assert.Equal(t, []string{"牛蛙杀好处", "理干净去掉", "内脏和眼睛"}, lines)
+	})
+
+	t.Run("ASCII 按半角计算", func(t *testing.T) {
+		assert.Equal(t, 30.0, pdf.TextWidth("ab牛蛙", 10))
+	})
+
+	t.Run("保留换行", func(t *testing.T) {
+		assert.Equal(t, []string{"第一行", "第二行"}, pdf.WrapText("第一行\n第二行", 10, 500))
+	})
+}
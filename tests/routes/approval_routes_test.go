@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/controllers"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+
+	approvalService := services.NewApprovalService(db, slog.Default())
+	approvalController := controllers.NewApprovalController(approvalService)
+	budgetController := controllers.NewBudgetController(services.NewBudgetService(db, slog.Default()))
+
+	_, err = approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_owner", Role: "owner"})
+	require.NoError(t, err)
+	ownerToken, err := approvalService.IssueToken("test_owner")
+	require.NoError(t, err)
+	_, err = approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_buyer"})
+	require.NoError(t, err)
+	buyerToken, err := approvalService.IssueToken("test_buyer")
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&models.Order{ID: "ORD_APPROVAL", UserID: 1, Supplier: "测试供应商A", TotalPrice: 80, Status: "awaiting_approval"}).Error)
+
+	r := gin.New()
+	routes.SetupRoutes(r, nil, nil, nil, nil, nil, nil, nil, nil, nil, approvalController, budgetController, nil)
+	enabled := routes.SetupAdminRoutes(r, config.AppConfig{AdminToken: "secret"}, approvalController)
+	require.True(t, enabled)
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("审批需要用户令牌，不信任请求体中的审批人", func(t *testing.T) {
+		w := send(http.MethodPost, "/v1/orders/ORD_APPROVAL/approve", `{"approver":"test_owner"}`, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = send(http.MethodPost, "/v1/orders/ORD_APPROVAL/approve", `{"approver":"test_owner"}`,
+			map[string]string{"Authorization": "Bearer " + buyerToken})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("审批人为令牌对应的用户", func(t *testing.T) {
+		w := send(http.MethodPost, "/v1/orders/ORD_APPROVAL/approve", `{"comment":"同意"}`,
+			map[string]string{"Authorization": "Bearer " + ownerToken})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var approval models.OrderApproval
+		require.NoError(t, db.First(&approval, "order_id = ?", "ORD_APPROVAL").Error)
+		assert.Equal(t, "test_owner", approval.Approver)
+	})
+
+	t.Run("修改审批规则和预算需要店主或管理员", func(t *testing.T) {
+		rule := `{"name":"超过50元","minAmount":50}`
+		budget := `{"period":"weekly","amount":1000,"enforcement":"block"}`
+
+		w := send(http.MethodPost, "/v1/approval-rules", rule, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		w = send(http.MethodPost, "/v1/budgets", budget, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		buyer := map[string]string{"Authorization": "Bearer " + buyerToken}
+		for _, request := range []struct{ method, path, body string }{
+			{http.MethodPost, "/v1/approval-rules", rule},
+			{http.MethodPut, "/v1/approval-rules/1", rule},
+			{http.MethodDelete, "/v1/approval-rules/1", ""},
+			{http.MethodPost, "/v1/budgets", budget},
+			{http.MethodPut, "/v1/budgets/1", budget},
+			{http.MethodDelete, "/v1/budgets/1", ""},
+		} {
+			w := send(request.method, request.path, request.body, buyer)
+			assert.Equal(t, http.StatusForbidden, w.Code, request.method+" "+request.path)
+		}
+
+		owner := map[string]string{"Authorization": "Bearer " + ownerToken}
+		w = send(http.MethodPost, "/v1/approval-rules", rule, owner)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = send(http.MethodPost, "/v1/budgets", budget, owner)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// 查询不需要令牌
+		w = send(http.MethodGet, "/v1/approval-rules", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = send(http.MethodGet, "/v1/budgets", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("设置角色需要管理员令牌", func(t *testing.T) {
+		w := send(http.MethodPut, "/v1/users/test_buyer/role", `{"role":"owner"}`,
+			map[string]string{"Authorization": "Bearer " + ownerToken})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = send(http.MethodPut, "/v1/users/test_buyer/role", `{"role":"owner"}`, map[string]string{"X-Admin-Token": "secret"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("未配置管理员令牌时不注册用户管理路由", func(t *testing.T) {
+		r := gin.New()
+		assert.False(t, routes.SetupAdminRoutes(r, config.AppConfig{}, approvalController))
+		assert.Empty(t, r.Routes())
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/database"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// devPaths 返回路由表中 /dev 开头的路由
+func devPaths(r *gin.Engine) []string {
+	var paths []string
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/dev") || strings.Contains(route.Path, "reset-data") {
+			paths = append(paths, route.Method+" "+route.Path)
+		}
+	}
+	return paths
+}
+
+func TestSetupDevRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	database.DB = db
+	productService := services.NewProductService(db, slog.Default())
+
+	t.Run("生产环境不注册开发工具路由", func(t *testing.T) {
+		r := gin.New()
+		enabled := routes.SetupDevRoutes(r, config.AppConfig{Environment: "production", DevAdminToken: "secret"}, productService)
+
+		assert.False(t, enabled)
+		assert.Empty(t, devPaths(r))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/dev/reset-data", nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("未配置令牌时不注册", func(t *testing.T) {
+		r := gin.New()
+		enabled := routes.SetupDevRoutes(r, config.AppConfig{Environment: "development"}, productService)
+
+		assert.False(t, enabled)
+		assert.Empty(t, devPaths(r))
+	})
+
+	t.Run("开发环境需要管理员令牌", func(t *testing.T) {
+		r := gin.New()
+		enabled := routes.SetupDevRoutes(r, config.AppConfig{Environment: "development", DevAdminToken: "secret"}, productService)
+
+		require.True(t, enabled)
+		assert.ElementsMatch(t, []string{"POST /dev/reset-data", "POST /dev/import-products-json"}, devPaths(r))
+
+		for _, token := range []string{"", "wrong"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/dev/reset-data", nil)
+			if token != "" {
+				req.Header.Set("X-Admin-Token", token)
+			}
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+
+		// 未授权的请求没有删除数据
+		require.NoError(t, testdata.SeedTestData(db))
+		var count int64
+		db.Model(&models.Product{}).Count(&count)
+		assert.Equal(t, int64(3), count)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/dev/reset-data", nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// 重置后重新插入初始数据（测试目录下没有 products.json，使用默认数据）
+		db.Model(&models.Product{}).Where("name = ?", "测试商品1").Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("业务路由不包含开发工具接口", func(t *testing.T) {
+		r := gin.New()
+		routes.SetupRoutes(r, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+		assert.Empty(t, devPaths(r))
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
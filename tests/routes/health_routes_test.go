@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/config"
+	"purches-backend/controllers"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/scheduler"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupHealthRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 未执行迁移的测试数据库，就绪检查失败
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	cfg := &config.Config{Database: config.DatabaseConfig{Type: "sqlite", FilePath: ":memory:"}}
+
+	r := gin.New()
+	routes.SetupHealthRoutes(r, controllers.NewHealthController(services.NewHealthService(db, cfg, scheduler.New(slog.Default()))))
+
+	t.Run("存活检查不检查依赖", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("就绪检查失败时返回 503 和每项检查结果", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var response struct {
+			models.APIResponse
+			Data models.ReadinessResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 503, response.Code)
+		assert.Equal(t, "degraded", response.Data.Status)
+		assert.Equal(t, "ok", response.Data.Checks["database"].Status)
+		assert.Equal(t, "fail", response.Data.Checks["migrations"].Status)
+		assert.Equal(t, "skipped", response.Data.Checks["scheduler"].Status)
+		assert.Len(t, response.Data.Checks, 4)
+	})
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/controllers"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+
+	inventoryController := controllers.NewInventoryController(services.NewInventoryService(db, slog.Default()))
+
+	r := gin.New()
+	routes.SetupRoutes(r, nil, nil, nil, nil, inventoryController, nil, nil, nil, nil, nil, nil, nil)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("商品ID格式错误返回 400", func(t *testing.T) {
+		w := send(http.MethodPut, "/v1/inventory/abc", `{"quantity":1}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		for _, path := range []string{"/v1/inventory/movements?productId=abc", "/v1/inventory/batches?productId=-1", "/v1/par-levels?productId=1x"} {
+			w := send(http.MethodGet, path, "")
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+
+	t.Run("商品不存在返回 404", func(t *testing.T) {
+		w := send(http.MethodPut, "/v1/inventory/999", `{"quantity":1}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send(http.MethodDelete, "/v1/par-levels/999", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("其他错误返回 500", func(t *testing.T) {
+		require.NoError(t, db.Migrator().DropTable(&models.Inventory{}))
+		t.Cleanup(func() { db.AutoMigrate(&models.Inventory{}) })
+
+		w := send(http.MethodPut, "/v1/inventory/1", `{"quantity":1}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("不传 productId 时不筛选", func(t *testing.T) {
+		w := send(http.MethodGet, "/v1/inventory/movements", "")
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	// 清理测试数据
+	require.NoError(t, testdata.CleanupTestDB(db))
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"purches-backend/controllers"
+	"purches-backend/models"
+	"purches-backend/routes"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderExportRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	require.NoError(t, testdata.SeedTestData(db))
+
+	orderController := controllers.NewOrderController(services.NewOrderService(db, slog.Default()))
+	r := gin.New()
+	routes.SetupRoutes(r, nil, nil, orderController, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	export := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/orders/export?format=csv", nil))
+		return w
+	}
+
+	t.Run("导出文件", func(t *testing.T) {
+		w := export()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	})
+
+	t.Run("日期格式错误返回 400", func(t *testing.T) {
+		for _, path := range []string{"/v1/orders/export?format=csv&dateFrom=2025/09/01", "/v1/orders/daily-pdf?date=20250901"} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+			assert.Contains(t, w.Body.String(), "日期格式错误", path)
+		}
+	})
+
+	t.Run("开始写入前失败时返回 JSON 错误", func(t *testing.T) {
+		require.NoError(t, db.Migrator().DropTable(&models.Order{}))
+		t.Cleanup(func() { db.AutoMigrate(&models.Order{}) })
+
+		w := export()
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+		assert.Contains(t, w.Body.String(), "导出失败")
+	})
+
+	// 清理测试数据
+	require.NoError(t, testdata.CleanupTestDB(db))
+}
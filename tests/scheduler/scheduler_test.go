@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"purches-backend/scheduler"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RejectsNonPositiveInterval(t *testing.T) {
+	jobs := scheduler.New(slog.Default())
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := jobs.Every("backup", interval, func(ctx context.Context) error { return nil })
+		assert.ErrorIs(t, err, scheduler.ErrInvalidInterval)
+	}
+	assert.Empty(t, jobs.Status())
+
+	// 没有注册任务时启动不会 panic
+	jobs.Start(context.Background())
+	jobs.Stop()
+}
+
+func TestScheduler_RunsJobsAndRecordsStatus(t *testing.T) {
+	jobs := scheduler.New(slog.Default())
+
+	var runs int32
+	jobs.Every("counter", 10*time.Millisecond, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	jobs.Every("failing", time.Hour, func(ctx context.Context) error {
+		return errors.New("数据库不可用")
+	})
+
+	jobs.Start(context.Background())
+	assert.True(t, jobs.Running())
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	jobs.Stop()
+	assert.False(t, jobs.Running())
+
+	statuses := jobs.Status()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "counter", statuses[0].Name)
+	assert.Empty(t, statuses[0].LastError)
+	assert.Equal(t, "failing", statuses[1].Name)
+	assert.Equal(t, "数据库不可用", statuses[1].LastError)
+	assert.Equal(t, 1, statuses[1].RunCount)
+
+	// 停止后不再运行
+	stopped := atomic.LoadInt32(&runs)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&runs))
+}
@@ -0,0 +1,101 @@
+//go:build linux || darwin || freebsd
+
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"purches-backend/server"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowServer 启动一个处理请求时等待 release 的服务，请求开始处理时向 started 发送
+func slowServer(t *testing.T, drain time.Duration, cleanup func() error) (addr string, started, release chan struct{}, exit chan int) {
+	started = make(chan struct{}, 1)
+	release = make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		io.WriteString(w, "done")
+	})}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	exit = make(chan int, 1)
+	go func() {
+		exit <- server.ServeUntilSignal(slog.Default(), srv, ln, drain, cleanup)
+	}()
+	return ln.Addr().String(), started, release, exit
+}
+
+// get 在后台发送请求，返回响应内容或错误
+func get(url string) chan string {
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+	return result
+}
+
+func TestServer_ServeUntilSignal(t *testing.T) {
+	t.Run("收到 SIGTERM 后等待进行中的请求完成", func(t *testing.T) {
+		var requestDone, cleanedUp atomic.Bool
+		addr, started, release, exit := slowServer(t, 5*time.Second, func() error {
+			// 进行中的请求完成后才释放资源
+			cleanedUp.Store(requestDone.Load())
+			return nil
+		})
+
+		response := get("http://" + addr)
+		<-started
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+		// 不再接收新连接
+		assert.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				conn.Close()
+			}
+			return err != nil
+		}, 2*time.Second, 10*time.Millisecond)
+
+		requestDone.Store(true)
+		close(release)
+		assert.Equal(t, "done", <-response)
+		assert.Equal(t, 0, <-exit)
+		assert.True(t, cleanedUp.Load())
+	})
+
+	t.Run("超过等待时间时强制关闭", func(t *testing.T) {
+		var cleanedUp atomic.Bool
+		addr, started, release, exit := slowServer(t, 100*time.Millisecond, func() error {
+			cleanedUp.Store(true)
+			return nil
+		})
+		defer close(release)
+
+		response := get("http://" + addr)
+		<-started
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+		assert.Equal(t, 1, <-exit)
+		assert.Contains(t, <-response, "error")
+		assert.True(t, cleanedUp.Load())
+	})
+}
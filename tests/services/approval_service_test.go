@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"purches-backend/logging"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalService_ApprovalWorkflow(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	approvalService := services.NewApprovalService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+
+	// 下单用户
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+
+	// 店主账号
+	require.NoError(t, db.Create(&models.User{OpenID: "test_owner", NickName: "店主"}).Error)
+	_, err = approvalService.UpdateUserRole("test_owner", "owner")
+	require.NoError(t, err)
+
+	// 单笔超过 50 元需要审批
+	_, err = approvalService.CreateRule(models.ApprovalRuleRequest{Name: "超过50元", MinAmount: 50})
+	require.NoError(t, err)
+
+	t.Run("未触发规则的订单直接提交", func(t *testing.T) {
+		orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 3, Count: 1}},
+		})
+
+		assert.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, "pending", orders[0].Status)
+		assert.Empty(t, orders[0].ApprovalReason)
+	})
+
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 2, Count: 3}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+
+	t.Run("超额订单进入待审批", func(t *testing.T) {
+		assert.Equal(t, "awaiting_approval", orders[0].Status)
+		assert.Equal(t, "超过50元", orders[0].ApprovalReason)
+
+		pending, err := approvalService.GetPendingApprovals()
+		assert.NoError(t, err)
+		assert.Len(t, pending, 1)
+
+		// 待审批的订单不能通过收货绕过审批
+		_, err = orderService.ReceiveOrder(orders[0].ID, models.ReceiveOrderRequest{})
+		assert.ErrorIs(t, err, services.ErrOrderNotReceivable)
+		var batches int64
+		require.NoError(t, db.Model(&models.StockBatch{}).Count(&batches).Error)
+		assert.Zero(t, batches)
+	})
+
+	t.Run("采购员不能审批", func(t *testing.T) {
+		_, err := approvalService.ApproveOrder(orders[0].ID, "test_user_001", models.ApproveOrderRequest{})
+
+		assert.ErrorIs(t, err, services.ErrNotApprover)
+	})
+
+	t.Run("店主审批通过并通知下单人", func(t *testing.T) {
+		ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req_approve"})
+		order, err := approvalService.WithContext(ctx).ApproveOrder(orders[0].ID, "test_owner", models.ApproveOrderRequest{Comment: "同意"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "pending", order.Status)
+		require.Len(t, order.Approvals, 1)
+		assert.Equal(t, "approved", order.Approvals[0].Action)
+		assert.Equal(t, "req_approve", order.Approvals[0].RequestID)
+
+		// 通知记录同一个请求ID
+		var notifications []models.Notification
+		db.Where("type = ? AND reference = ?", "approval", order.ID).Find(&notifications)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "req_approve", notifications[0].RequestID)
+	})
+
+	t.Run("重复审批", func(t *testing.T) {
+		_, err := approvalService.ApproveOrder(orders[0].ID, "test_owner", models.ApproveOrderRequest{})
+
+		assert.ErrorIs(t, err, services.ErrOrderNotAwaitingApproval)
+	})
+
+	t.Run("驳回订单", func(t *testing.T) {
+		rejected, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 1, Count: 10}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "awaiting_approval", rejected[0].Status)
+
+		order, err := approvalService.RejectOrder(rejected[0].ID, "test_owner", models.ApproveOrderRequest{Comment: "数量太多"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "rejected", order.Status)
+
+		// 驳回的订单不能绕过审批改为待处理
+		for _, status := range []string{"pending", "confirmed", "cancelled"} {
+			err = orderService.UpdateOrderStatus(order.ID, models.UpdateOrderStatusRequest{Status: status})
+			assert.ErrorIs(t, err, services.ErrOrderStatusTransition, status)
+		}
+
+		// 也不能收货
+		_, err = orderService.ReceiveOrder(order.ID, models.ReceiveOrderRequest{})
+		assert.ErrorIs(t, err, services.ErrOrderNotReceivable)
+		reloaded, err := orderService.GetOrderByID(order.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "rejected", reloaded.Status)
+	})
+
+	t.Run("取消后不能恢复为待处理绕过审批", func(t *testing.T) {
+		cancelled, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 1, Count: 10}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "awaiting_approval", cancelled[0].Status)
+
+		require.NoError(t, orderService.UpdateOrderStatus(cancelled[0].ID, models.UpdateOrderStatusRequest{Status: "cancelled"}))
+		for _, status := range []string{"pending", "confirmed", "delivering", "completed"} {
+			err = orderService.UpdateOrderStatus(cancelled[0].ID, models.UpdateOrderStatusRequest{Status: status})
+			assert.ErrorIs(t, err, services.ErrOrderStatusTransition, status)
+		}
+
+		order, err := orderService.GetOrderByID(cancelled[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", order.Status)
+	})
+
+	t.Run("已完成的订单不能再变更", func(t *testing.T) {
+		require.NoError(t, orderService.UpdateOrderStatus(orders[0].ID, models.UpdateOrderStatusRequest{Status: "completed"}))
+
+		for _, status := range []string{"pending", "confirmed", "cancelled"} {
+			err := orderService.UpdateOrderStatus(orders[0].ID, models.UpdateOrderStatusRequest{Status: status})
+			assert.ErrorIs(t, err, services.ErrOrderStatusTransition, status)
+		}
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestApprovalService_CreateUser(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	approvalService := services.NewApprovalService(db, slog.Default())
+
+	t.Run("默认角色为采购员", func(t *testing.T) {
+		user, err := approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_buyer", NickName: "采购员"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "buyer", user.Role)
+		assert.NotZero(t, user.ID)
+	})
+
+	t.Run("创建店主", func(t *testing.T) {
+		user, err := approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_owner", Role: "owner"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "owner", user.Role)
+	})
+
+	t.Run("用户令牌", func(t *testing.T) {
+		token, err := approvalService.IssueToken("test_owner")
+		require.NoError(t, err)
+
+		user, err := approvalService.Authenticate(token)
+		require.NoError(t, err)
+		assert.Equal(t, "test_owner", user.OpenID)
+
+		// 重新生成后旧令牌失效
+		_, err = approvalService.IssueToken("test_owner")
+		require.NoError(t, err)
+		_, err = approvalService.Authenticate(token)
+		assert.ErrorIs(t, err, services.ErrInvalidToken)
+
+		_, err = approvalService.Authenticate("")
+		assert.ErrorIs(t, err, services.ErrInvalidToken)
+	})
+
+	t.Run("重复创建", func(t *testing.T) {
+		_, err := approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_buyer"})
+		assert.ErrorIs(t, err, services.ErrUserExists)
+	})
+
+	t.Run("未知角色", func(t *testing.T) {
+		_, err := approvalService.CreateUser(models.CreateUserRequest{OpenID: "test_king", Role: "king"})
+		assert.ErrorIs(t, err, services.ErrInvalidRole)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
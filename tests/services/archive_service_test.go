@@ -0,0 +1,170 @@
+package services
+
+import (
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestArchiveService_ArchiveAndRestore(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	archiveService := services.NewArchiveService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+	productService := services.NewProductService(db, slog.Default())
+	supplierService := services.NewSupplierService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+
+	// 下单用户和管理员
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.User{OpenID: "test_admin", NickName: "管理员", Role: "admin"}).Error)
+
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	orderID := orders[0].ID
+
+	t.Run("非管理员不能归档", func(t *testing.T) {
+		err := archiveService.ArchiveProduct(1, "test_user_001")
+		assert.ErrorIs(t, err, services.ErrNotAdmin)
+	})
+
+	t.Run("进行中的订单不能归档", func(t *testing.T) {
+		err := archiveService.ArchiveOrder(orderID, "test_admin")
+		assert.ErrorIs(t, err, services.ErrOrderNotClosed)
+	})
+
+	t.Run("归档并恢复订单", func(t *testing.T) {
+		require.NoError(t, orderService.UpdateOrderStatus(orderID, models.UpdateOrderStatusRequest{Status: "completed"}))
+		require.NoError(t, archiveService.ArchiveOrder(orderID, "test_admin"))
+
+		_, err := orderService.GetOrderByID(orderID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		list, err := orderService.GetOrders(models.OrderListRequest{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, list.Orders)
+
+		archive, err := archiveService.GetArchive(models.ArchiveListRequest{Operator: "test_admin", Type: "orders"})
+		require.NoError(t, err)
+		require.Len(t, archive.Orders, 1)
+		assert.Len(t, archive.Orders[0].Products, 1)
+
+		restored, err := archiveService.RestoreOrder(orderID, "test_admin")
+		require.NoError(t, err)
+		assert.False(t, restored.DeletedAt.Valid)
+
+		_, err = archiveService.RestoreOrder(orderID, "test_admin")
+		assert.ErrorIs(t, err, services.ErrNotArchived)
+	})
+
+	t.Run("归档商品后历史订单仍能关联商品", func(t *testing.T) {
+		_, err := cartService.AddToCart(models.AddToCartRequest{ProductID: 1, Count: 1})
+		require.NoError(t, err)
+
+		require.NoError(t, archiveService.ArchiveProduct(1, "test_admin"))
+
+		_, err = productService.GetProductByID(1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// 归档的商品从购物车移除
+		cart, err := cartService.GetCart()
+		require.NoError(t, err)
+		assert.Empty(t, cart.Items)
+
+		order, err := orderService.GetOrderByID(orderID)
+		require.NoError(t, err)
+		require.Len(t, order.Products, 1)
+		assert.Equal(t, "测试商品1", order.Products[0].Product.Name)
+		assert.True(t, order.Products[0].Product.DeletedAt.Valid)
+
+		product, err := archiveService.RestoreProduct(1, "test_admin")
+		require.NoError(t, err)
+		assert.Equal(t, "测试商品1", product.Name)
+		_, err = productService.GetProductByID(1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("归档供应商时一并归档其商品", func(t *testing.T) {
+		// 商品2先单独归档，恢复供应商时不应一起恢复
+		require.NoError(t, archiveService.ArchiveProduct(2, "test_admin"))
+		require.NoError(t, archiveService.ArchiveSupplier("测试供应商A", "test_admin"))
+
+		suppliers, err := supplierService.GetSuppliers()
+		require.NoError(t, err)
+		require.Len(t, suppliers.Suppliers, 1)
+		assert.Equal(t, "测试供应商B", suppliers.Suppliers[0].Name)
+
+		products, err := supplierService.GetSupplierProducts("测试供应商A")
+		require.NoError(t, err)
+		assert.Empty(t, products)
+
+		archive, err := archiveService.GetArchive(models.ArchiveListRequest{Operator: "test_admin"})
+		require.NoError(t, err)
+		assert.Len(t, archive.Suppliers, 1)
+		assert.Len(t, archive.Products, 2)
+		assert.Empty(t, archive.Orders)
+
+		_, err = archiveService.RestoreProduct(1, "test_admin")
+		assert.ErrorIs(t, err, services.ErrSupplierArchived)
+
+		_, err = archiveService.RestoreSupplier("测试供应商A", "test_admin")
+		require.NoError(t, err)
+
+		products, err = supplierService.GetSupplierProducts("测试供应商A")
+		require.NoError(t, err)
+		require.Len(t, products, 1)
+		assert.Equal(t, 1, products[0].ID)
+	})
+
+	t.Run("目录同步跳过已归档的商品和供应商", func(t *testing.T) {
+		require.NoError(t, archiveService.ArchiveSupplier("测试供应商B", "test_admin"))
+
+		summary, err := productService.SyncProducts([]models.ImportProduct{
+			{Name: "测试商品2", Price: 26.00, Unit: "斤", Description: testdata.Ptr("测试商品描述2"), Supplier: "测试供应商A"},
+			{Name: "测试商品3", Price: 9.00, Unit: "包", Supplier: "测试供应商B"},
+			{Name: "新商品", Price: 1.00, Unit: "个", Supplier: "测试供应商B"},
+		}, true, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, summary.Added)
+		assert.Equal(t, 0, summary.Updated)
+		assert.Equal(t, 3, summary.Skipped)
+		assert.Equal(t, 1, summary.Discontinued) // 只有目录中没有的商品1，跳过的商品不停售
+		require.Len(t, summary.Changes, 4)
+		assert.Equal(t, models.ProductSyncChange{ProductID: 2, Name: "测试商品2", Supplier: "测试供应商A", Action: "skipped", Reason: "商品已归档"}, summary.Changes[0])
+		assert.Equal(t, models.ProductSyncChange{ProductID: 3, Name: "测试商品3", Supplier: "测试供应商B", Action: "skipped", Reason: "供应商已归档"}, summary.Changes[1])
+		assert.Equal(t, "供应商已归档", summary.Changes[2].Reason)
+
+		// 仍然是归档状态，价格未修改
+		_, err = productService.GetProductByID(2)
+		assert.Error(t, err)
+		var product models.Product
+		require.NoError(t, db.Unscoped().First(&product, 2).Error)
+		assert.True(t, product.DeletedAt.Valid)
+		assert.Equal(t, 25.00, product.Price)
+
+		var supplier models.Supplier
+		require.NoError(t, db.Unscoped().First(&supplier, "name = ?", "测试供应商B").Error)
+		assert.True(t, supplier.DeletedAt.Valid)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
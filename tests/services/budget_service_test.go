@@ -0,0 +1,116 @@
+package services
+
+import (
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetService_Enforcement(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	budgetService := services.NewBudgetService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 2).Update("category", "海鲜").Error)
+
+	// 月度总预算 100 元超出提醒，海鲜每周 60 元超出禁止下单
+	_, err = budgetService.CreateBudget(models.BudgetRequest{Period: "monthly", Amount: 100})
+	require.NoError(t, err)
+	_, err = budgetService.CreateBudget(models.BudgetRequest{Category: "海鲜", Period: "weekly", Amount: 60, Enforcement: "block"})
+	require.NoError(t, err)
+
+	first, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}},
+	})
+	require.NoError(t, err)
+
+	t.Run("预算内正常下单", func(t *testing.T) {
+		require.Len(t, first, 1)
+		assert.Equal(t, "store_1", first[0].Store)
+		assert.Empty(t, first[0].BudgetWarnings)
+	})
+
+	t.Run("超出禁止类预算", func(t *testing.T) {
+		_, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 2, Count: 3}},
+		})
+
+		assert.ErrorIs(t, err, services.ErrBudgetExceeded)
+
+		var count int64
+		db.Model(&models.Order{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("超出提醒类预算", func(t *testing.T) {
+		_, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 2, Count: 2}},
+		})
+		require.NoError(t, err)
+
+		orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Items: []models.OrderItemRequest{{ProductID: 3, Count: 5}},
+		})
+
+		assert.NoError(t, err)
+		require.Len(t, orders[0].BudgetWarnings, 1)
+		assert.Contains(t, orders[0].BudgetWarnings[0], "store_1本月预算")
+	})
+
+	t.Run("预算使用情况", func(t *testing.T) {
+		require.NoError(t, orderService.UpdateOrderFinalPrice(first[0].ID, 20))
+		require.NoError(t, orderService.UpdateOrderStatus(first[0].ID, models.UpdateOrderStatusRequest{Status: "completed"}))
+
+		usages, err := budgetService.GetBudgetUsage(models.BudgetUsageRequest{Store: "store_1"})
+
+		assert.NoError(t, err)
+		require.Len(t, usages, 2)
+
+		monthly := usages[0]
+		assert.Equal(t, "monthly", monthly.Budget.Period)
+		assert.InDelta(t, 94.0, monthly.Committed, 0.001) // 50 + 44
+		assert.InDelta(t, 20.0, monthly.Actual, 0.001)    // 按最终价格
+		assert.True(t, monthly.Exceeded)
+
+		seafood := usages[1]
+		assert.InDelta(t, 50.0, seafood.Committed, 0.001)
+		assert.InDelta(t, 10.0, seafood.Remaining, 0.001)
+		assert.False(t, seafood.Exceeded)
+	})
+
+	t.Run("日期格式错误", func(t *testing.T) {
+		_, err := budgetService.GetBudgetUsage(models.BudgetUsageRequest{Date: "09-01"})
+		assert.ErrorIs(t, err, services.ErrInvalidDate)
+	})
+
+	t.Run("其他门店不受影响", func(t *testing.T) {
+		orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+			Store: "store_2",
+			Items: []models.OrderItemRequest{{ProductID: 2, Count: 10}},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "store_2", orders[0].Store)
+		assert.Empty(t, orders[0].BudgetWarnings)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
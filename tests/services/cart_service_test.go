@@ -1,6 +1,7 @@
 package services
 
 import (
+	"log/slog"
 	"purches-backend/models"
 	"purches-backend/services"
 	"purches-backend/tests/testdata"
@@ -20,7 +21,7 @@ func TestCartService_GetCart(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	cartService := services.NewCartService(db)
+	cartService := services.NewCartService(db, slog.Default())
 
 	t.Run("获取空购物车", func(t *testing.T) {
 		response, err := cartService.GetCart()
@@ -48,7 +49,7 @@ func TestCartService_AddToCart(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	cartService := services.NewCartService(db)
+	cartService := services.NewCartService(db, slog.Default())
 
 	t.Run("添加新商品到购物车", func(t *testing.T) {
 		req := models.AddToCartRequest{
@@ -120,7 +121,7 @@ func TestCartService_UpdateCartItem(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	cartService := services.NewCartService(db)
+	cartService := services.NewCartService(db, slog.Default())
 
 	// 先添加商品到购物车
 	req := models.AddToCartRequest{
@@ -175,7 +176,7 @@ func TestCartService_DeleteCartItem(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	cartService := services.NewCartService(db)
+	cartService := services.NewCartService(db, slog.Default())
 
 	// 先添加商品到购物车
 	req := models.AddToCartRequest{
@@ -217,7 +218,7 @@ func TestCartService_ClearCart(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	cartService := services.NewCartService(db)
+	cartService := services.NewCartService(db, slog.Default())
 
 	// 先添加几个商品到购物车
 	items := []models.AddToCartRequest{
@@ -253,3 +254,66 @@ func TestCartService_ClearCart(t *testing.T) {
 	err = testdata.CleanupTestDB(db)
 	require.NoError(t, err)
 }
+
+func TestCartService_ReorderToCart(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	cartService := services.NewCartService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+
+	// 上周二的订单
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}, {ProductID: 2, Count: 1}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+
+	// 之后商品1涨价，商品2停售
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 1).Update("price", 12.00).Error)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 2).Update("status", "discontinued").Error)
+
+	t.Run("按当前价格加入购物车并跳过停售商品", func(t *testing.T) {
+		response, err := cartService.ReorderToCart(orders[0].ID)
+
+		assert.NoError(t, err)
+		require.Len(t, response.Added, 1)
+		assert.Equal(t, 10.50, response.Added[0].OriginalPrice)
+		assert.Equal(t, 12.00, response.Added[0].CurrentPrice)
+		assert.Equal(t, 1.5, response.Added[0].PriceChange)
+		assert.Equal(t, 3.0, response.PriceDifference) // (12 - 10.5) * 2
+
+		require.Len(t, response.Skipped, 1)
+		assert.Equal(t, 2, response.Skipped[0].ProductID)
+		assert.Equal(t, "商品已停售", response.Skipped[0].Reason)
+
+		require.Len(t, response.Cart.Items, 1)
+		assert.Equal(t, 2, response.Cart.Items[0].Count)
+		assert.Equal(t, 24.0, response.Cart.Items[0].TotalPrice)
+	})
+
+	t.Run("再次加入时累加数量", func(t *testing.T) {
+		response, err := cartService.ReorderToCart(orders[0].ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, response.Cart.Items[0].Count)
+	})
+
+	t.Run("不存在的订单", func(t *testing.T) {
+		_, err := cartService.ReorderToCart("ORD_NOT_EXIST")
+
+		assert.Error(t, err)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"purches-backend/config"
+	"purches-backend/migrations"
+	"purches-backend/scheduler"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthService_Readiness(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+	_, err = migrations.Up(db, 0)
+	require.NoError(t, err)
+
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Database:  config.DatabaseConfig{Type: "sqlite", FilePath: filepath.Join(t.TempDir(), "purches.db")},
+			Scheduler: config.SchedulerConfig{Enabled: true},
+			Health:    config.HealthConfig{MinFreeDiskMB: 1},
+		}
+	}
+	startJobs := func(t *testing.T, fn scheduler.JobFunc) *scheduler.Scheduler {
+		jobs := scheduler.New(slog.Default())
+		jobs.Every("standing_orders", time.Hour, fn)
+		jobs.Start(context.Background())
+		t.Cleanup(jobs.Stop)
+		return jobs
+	}
+	ok := func(ctx context.Context) error { return nil }
+
+	t.Run("全部检查通过", func(t *testing.T) {
+		readiness := services.NewHealthService(db, newConfig(), startJobs(t, ok)).Readiness()
+
+		assert.Equal(t, "ready", readiness.Status)
+		for _, name := range []string{"database", "migrations", "disk", "scheduler"} {
+			assert.Equal(t, "ok", readiness.Checks[name].Status, name)
+		}
+	})
+
+	t.Run("有未执行的迁移", func(t *testing.T) {
+		fresh, err := testdata.SetupTestDB()
+		require.NoError(t, err)
+
+		readiness := services.NewHealthService(fresh, newConfig(), startJobs(t, ok)).Readiness()
+
+		assert.Equal(t, "degraded", readiness.Status)
+		check := readiness.Checks["migrations"]
+		assert.Equal(t, "fail", check.Status)
+		assert.Equal(t, map[string]interface{}{"pending": []string{"0001_initial_schema", "0002_backfill_order_store", "0003_add_user_token", "0004_add_request_id", "0005_add_stocktake_expected"}}, check.Details)
+	})
+
+	t.Run("数据库中有未知的迁移版本", func(t *testing.T) {
+		newer, err := testdata.SetupTestDB()
+		require.NoError(t, err)
+		_, err = migrations.Up(newer, 0)
+		require.NoError(t, err)
+		require.NoError(t, newer.Create(&migrations.SchemaMigration{Version: 9999, Name: "from_newer_release", AppliedAt: time.Now()}).Error)
+
+		readiness := services.NewHealthService(newer, newConfig(), startJobs(t, ok)).Readiness()
+
+		check := readiness.Checks["migrations"]
+		assert.Equal(t, "fail", check.Status)
+		assert.Equal(t, map[string]interface{}{"unknown": []int64{9999}}, check.Details)
+	})
+
+	t.Run("磁盘剩余空间不足", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Health.MinFreeDiskMB = 1 << 40
+
+		readiness := services.NewHealthService(db, cfg, startJobs(t, ok)).Readiness()
+
+		assert.Equal(t, "degraded", readiness.Status)
+		assert.Equal(t, "fail", readiness.Checks["disk"].Status)
+	})
+
+	t.Run("定时任务未运行", func(t *testing.T) {
+		readiness := services.NewHealthService(db, newConfig(), scheduler.New(slog.Default())).Readiness()
+
+		assert.Equal(t, "degraded", readiness.Status)
+		assert.Equal(t, "fail", readiness.Checks["scheduler"].Status)
+	})
+
+	t.Run("定时任务卡住", func(t *testing.T) {
+		jobs := scheduler.New(slog.Default())
+		require.NoError(t, jobs.Every("standing_orders", 10*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		jobs.Start(context.Background())
+		t.Cleanup(jobs.Stop)
+		time.Sleep(30 * time.Millisecond)
+
+		readiness := services.NewHealthService(db, newConfig(), jobs).Readiness()
+
+		assert.Equal(t, "degraded", readiness.Status)
+		assert.Equal(t, "fail", readiness.Checks["scheduler"].Status)
+		assert.Contains(t, readiness.Checks["scheduler"].Message, "standing_orders")
+	})
+
+	t.Run("定时任务运行失败只是警告", func(t *testing.T) {
+		jobs := startJobs(t, func(ctx context.Context) error { return errors.New("生成失败") })
+		require.Eventually(t, func() bool { return jobs.Status()[0].RunCount > 0 }, time.Second, 10*time.Millisecond)
+
+		readiness := services.NewHealthService(db, newConfig(), jobs).Readiness()
+
+		assert.Equal(t, "ready", readiness.Status)
+		assert.Equal(t, "warn", readiness.Checks["scheduler"].Status)
+		assert.Contains(t, readiness.Checks["scheduler"].Message, "生成失败")
+	})
+
+	t.Run("定时任务未启用时跳过", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Scheduler.Enabled = false
+
+		readiness := services.NewHealthService(db, cfg, scheduler.New(slog.Default())).Readiness()
+
+		assert.Equal(t, "ready", readiness.Status)
+		assert.Equal(t, "skipped", readiness.Checks["scheduler"].Status)
+	})
+
+	t.Run("数据库无法连接", func(t *testing.T) {
+		closed, err := testdata.SetupTestDB()
+		require.NoError(t, err)
+		sqlDB, err := closed.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		readiness := services.NewHealthService(closed, newConfig(), startJobs(t, ok)).Readiness()
+
+		assert.Equal(t, "degraded", readiness.Status)
+		assert.Equal(t, "fail", readiness.Checks["database"].Status)
+		assert.Equal(t, "fail", readiness.Checks["migrations"].Status)
+	})
+}
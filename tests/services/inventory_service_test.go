@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"purches-backend/logging"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestInventoryService_SetParLevels(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	t.Run("设置并更新标准库存", func(t *testing.T) {
+		_, err := inventoryService.SetParLevels(models.SetParLevelsRequest{
+			ProductID: 1,
+			Levels: []models.ParLevelRequest{
+				{Weekday: 1, Quantity: 10},
+				{Weekday: 2, Quantity: 8},
+			},
+		})
+		require.NoError(t, err)
+
+		levels, err := inventoryService.SetParLevels(models.SetParLevelsRequest{
+			ProductID: 1,
+			Levels:    []models.ParLevelRequest{{Weekday: 1, Quantity: 12}},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, levels, 2)
+		assert.Equal(t, 1, levels[0].Weekday)
+		assert.Equal(t, 12.0, levels[0].Quantity)
+	})
+
+	t.Run("不存在的商品", func(t *testing.T) {
+		_, err := inventoryService.SetParLevels(models.SetParLevelsRequest{
+			ProductID: 999,
+			Levels:    []models.ParLevelRequest{{Weekday: 1, Quantity: 1}},
+		})
+
+		assert.Error(t, err)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestCartService_SuggestCart(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	cartService := services.NewCartService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	// 周一标准库存：商品1 10个，商品2 3斤，商品3 4.5包
+	for productID, quantity := range map[int]float64{1: 10, 2: 3, 3: 4.5} {
+		_, err := inventoryService.SetParLevels(models.SetParLevelsRequest{
+			ProductID: productID,
+			Levels:    []models.ParLevelRequest{{Weekday: 1, Quantity: quantity}},
+		})
+		require.NoError(t, err)
+	}
+
+	// 现有库存：商品1 3个，商品2 5斤
+	_, err = inventoryService.SetOnHand(1, 3)
+	require.NoError(t, err)
+	_, err = inventoryService.SetOnHand(2, 5)
+	require.NoError(t, err)
+
+	// 在途订单：商品1 2个
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	_, err = orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}},
+	})
+	require.NoError(t, err)
+
+	t.Run("按供应商分组生成建议采购", func(t *testing.T) {
+		response, err := cartService.SuggestCart(1)
+
+		assert.NoError(t, err)
+		require.Len(t, response.Suppliers, 2)
+
+		groupA := response.Suppliers[0]
+		assert.Equal(t, "测试供应商A", groupA.Supplier)
+		require.Len(t, groupA.Items, 1) // 商品2库存充足
+		assert.Equal(t, 1, groupA.Items[0].ProductID)
+		assert.Equal(t, 5, groupA.Items[0].Count) // 10 - 3 - 2 = 5
+		assert.Equal(t, 52.5, groupA.TotalPrice)
+
+		groupB := response.Suppliers[1]
+		assert.Equal(t, "测试供应商B", groupB.Supplier)
+		assert.Equal(t, 5, groupB.Items[0].Count) // 4.5 向上取整
+
+		assert.Len(t, response.Cart.Items, 2)
+		assert.Equal(t, 2, response.Cart.Summary.SupplierCount)
+	})
+
+	t.Run("重复生成不会累加数量", func(t *testing.T) {
+		response, err := cartService.SuggestCart(1)
+
+		assert.NoError(t, err)
+		assert.Len(t, response.Cart.Items, 2)
+		for _, item := range response.Cart.Items {
+			assert.Equal(t, 5, item.Count)
+		}
+	})
+
+	t.Run("当天没有标准库存", func(t *testing.T) {
+		response, err := cartService.SuggestCart(0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, response.Suppliers)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestInventoryService_ConsumeStockFEFO(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	// 两个批次：后到的批次先到期
+	now := time.Now()
+	early := now.AddDate(0, 0, 1)
+	late := now.AddDate(0, 0, 5)
+	batches := []models.StockBatch{
+		{ProductID: 1, Quantity: 6, Remaining: 6, ReceivedAt: now.AddDate(0, 0, -2), ExpiresAt: &late},
+		{ProductID: 1, Quantity: 4, Remaining: 4, ReceivedAt: now, ExpiresAt: &early},
+	}
+	for i := range batches {
+		require.NoError(t, db.Create(&batches[i]).Error)
+	}
+	_, err = inventoryService.SetOnHand(1, 10)
+	require.NoError(t, err)
+
+	t.Run("先扣减最早到期的批次", func(t *testing.T) {
+		ctx := logging.WithFields(context.Background(), logging.Fields{RequestID: "req_consume"})
+		response, err := inventoryService.WithContext(ctx).ConsumeStock(models.ConsumeStockRequest{ProductID: 1, Quantity: 5})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5.0, response.Inventory.Quantity)
+		require.Len(t, response.Allocations, 2)
+		assert.Equal(t, batches[1].ID, response.Allocations[0].BatchID)
+		assert.Equal(t, 4.0, response.Allocations[0].Quantity)
+		assert.Equal(t, batches[0].ID, response.Allocations[1].BatchID)
+		assert.Equal(t, 1.0, response.Allocations[1].Quantity)
+
+		// 库存变动记录请求ID
+		var movement models.StockMovement
+		require.NoError(t, db.Where("product_id = ?", 1).Order("id DESC").First(&movement).Error)
+		assert.Equal(t, -5.0, movement.Quantity)
+		assert.Equal(t, "req_consume", movement.RequestID)
+	})
+
+	t.Run("库存不足时不出库", func(t *testing.T) {
+		_, err := inventoryService.ConsumeStock(models.ConsumeStockRequest{ProductID: 1, Quantity: 6})
+
+		assert.ErrorIs(t, err, services.ErrInsufficientStock)
+
+		// 库存和批次都没有变化
+		inventory, err := inventoryService.GetInventory()
+		require.NoError(t, err)
+		assert.Equal(t, 5.0, inventory[0].Quantity)
+		var remaining float64
+		require.NoError(t, db.Model(&models.StockBatch{}).Where("product_id = ?", 1).Select("SUM(remaining)").Scan(&remaining).Error)
+		assert.Equal(t, 5.0, remaining)
+	})
+
+	t.Run("不存在的商品", func(t *testing.T) {
+		_, err := inventoryService.ConsumeStock(models.ConsumeStockRequest{ProductID: 999, Quantity: 1})
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("即将到期批次", func(t *testing.T) {
+		expiring, err := inventoryService.GetExpiringBatches(7)
+
+		assert.NoError(t, err)
+		require.Len(t, expiring, 1) // 已用完的批次不再列出
+		assert.Equal(t, 5.0, expiring[0].Remaining)
+
+		expiring, err = inventoryService.GetExpiringBatches(2)
+		assert.NoError(t, err)
+		assert.Empty(t, expiring)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedExportOrders 批量创建导出用订单，每单 3 个商品，分属两个供应商
+func seedExportOrders(db *gorm.DB, count int) error {
+	suppliers := []string{"测试供应商A", "测试供应商B"}
+	start := time.Now().AddDate(0, 0, -1)
+
+	orders := make([]models.Order, 0, 100)
+	for i := 0; i < count; i++ {
+		orderID := fmt.Sprintf("ORD%d%06d", start.Unix(), i)
+		order := models.Order{
+			ID:         orderID,
+			UserID:     1,
+			Store:      "store_1",
+			Supplier:   suppliers[i%len(suppliers)],
+			TotalPrice: 31.5,
+			Status:     "completed",
+			CreatedAt:  start.Add(time.Duration(i) * time.Second),
+			UpdatedAt:  start,
+		}
+		for j := 1; j <= 3; j++ {
+			order.Products = append(order.Products, models.OrderItem{
+				OrderID: orderID, ProductID: j, Name: fmt.Sprintf("测试商品%d", j),
+				Count: 1, Unit: "个", Price: 10.5, TotalPrice: 10.5,
+			})
+		}
+		orders = append(orders, order)
+
+		if len(orders) == cap(orders) || i == count-1 {
+			if err := db.Create(&orders).Error; err != nil {
+				return err
+			}
+			orders = orders[:0]
+		}
+	}
+	return nil
+}
+
+// peakHeapWriter 定期采样堆内存，记录导出过程中的峰值
+type peakHeapWriter struct {
+	writes int
+	peak   uint64
+}
+
+func (pw *peakHeapWriter) Write(p []byte) (int, error) {
+	pw.writes++
+	if pw.writes%500 == 0 {
+		pw.sample()
+	}
+	return len(p), nil
+}
+
+func (pw *peakHeapWriter) sample() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > pw.peak {
+		pw.peak = stats.HeapAlloc
+	}
+}
+
+// BenchmarkOrderService_WriteOrderExport 订单数量增加时导出的峰值堆内存应保持稳定
+func BenchmarkOrderService_WriteOrderExport(b *testing.B) {
+	for _, count := range []int{1000, 10000} {
+		for _, format := range []string{"csv", "excel"} {
+			b.Run(fmt.Sprintf("%s/orders=%d", format, count), func(b *testing.B) {
+				db, err := testdata.SetupTestDB()
+				require.NoError(b, err)
+				require.NoError(b, testdata.SeedTestData(db))
+				require.NoError(b, seedExportOrders(db, count))
+				orderService := services.NewOrderService(db, slog.Default())
+
+				runtime.GC()
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				var peak uint64
+				for i := 0; i < b.N; i++ {
+					writer := &peakHeapWriter{}
+					err := orderService.WriteOrderExport(io.Writer(writer), models.ExportOrdersRequest{Format: format})
+					require.NoError(b, err)
+					writer.sample()
+					if writer.peak > peak {
+						peak = writer.peak
+					}
+				}
+
+				b.StopTimer()
+				b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+				require.NoError(b, testdata.CleanupTestDB(db))
+			})
+		}
+	}
+}
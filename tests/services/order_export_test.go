@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestOrderService_WriteOrderExport(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	orderService := services.NewOrderService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+
+	// 供应商A两单、供应商B一单，其中一单调整了最终价格
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}, {ProductID: 3, Count: 1}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	require.NoError(t, orderService.UpdateOrderFinalPrice(orders[0].ID, 20))
+	_, err = orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 2, Count: 1}},
+	})
+	require.NoError(t, err)
+
+	t.Run("CSV 带 BOM 并包含小计", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WriteOrderExport(&buf, models.ExportOrdersRequest{Format: "csv"})
+		require.NoError(t, err)
+
+		data := buf.Bytes()
+		assert.True(t, bytes.HasPrefix(data, []byte("\xEF\xBB\xBF")))
+
+		rows, err := csv.NewReader(bytes.NewReader(data[3:])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 7) // 表头 + 3行明细 + 2行小计 + 合计
+
+		assert.Equal(t, "订单号", rows[0][0])
+		assert.Equal(t, orders[0].ID, rows[1][0])
+		assert.Equal(t, "测试商品1", rows[1][5])
+		assert.Equal(t, "21.00", rows[1][10])
+		assert.Equal(t, "20.00", rows[1][11])
+		assert.Equal(t, "-1.00", rows[1][12])
+
+		assert.Equal(t, "测试供应商A 小计", rows[3][0])
+		assert.Equal(t, "2 单", rows[3][4])
+		assert.Equal(t, "46.00", rows[3][10])
+		assert.Equal(t, "45.00", rows[3][11])
+
+		assert.Equal(t, "测试供应商B 小计", rows[5][0])
+		assert.Equal(t, "合计", rows[6][0])
+		assert.Equal(t, "54.80", rows[6][10])
+		assert.Equal(t, "-1.00", rows[6][12])
+	})
+
+	t.Run("xlsx 文件", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WriteOrderExport(&buf, models.ExportOrdersRequest{Format: "excel", Supplier: "测试供应商B"})
+		require.NoError(t, err)
+
+		file, err := excelize.OpenReader(&buf)
+		require.NoError(t, err)
+		defer file.Close()
+
+		rows, err := file.GetRows("订单明细")
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, "测试商品3", rows[1][5])
+		assert.Equal(t, "8.8", rows[1][10])
+		assert.Equal(t, "合计", rows[3][0])
+	})
+
+	t.Run("已取消和已驳回的订单不计入小计", func(t *testing.T) {
+		require.NoError(t, orderService.UpdateOrderStatus(orders[0].ID, models.UpdateOrderStatusRequest{Status: "cancelled"}))
+		require.NoError(t, db.Model(&models.Order{}).Where("id = ?", orders[1].ID).Update("status", "rejected").Error)
+
+		var buf bytes.Buffer
+		err := orderService.WriteOrderExport(&buf, models.ExportOrdersRequest{Format: "csv"})
+		require.NoError(t, err)
+
+		rows, err := csv.NewReader(bytes.NewReader(buf.Bytes()[3:])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 7) // 明细仍然列出
+
+		assert.Equal(t, "cancelled", rows[1][4])
+		assert.Equal(t, "测试供应商A 小计", rows[3][0])
+		assert.Equal(t, "1 单", rows[3][4])
+		assert.Equal(t, "25.00", rows[3][10])
+		assert.Equal(t, "25.00", rows[3][11])
+
+		assert.Equal(t, "rejected", rows[4][4])
+		assert.Equal(t, "测试供应商B 小计", rows[5][0])
+		assert.Equal(t, "0 单", rows[5][4])
+		assert.Equal(t, "0.00", rows[5][10])
+
+		assert.Equal(t, "合计", rows[6][0])
+		assert.Equal(t, "1 单", rows[6][4])
+		assert.Equal(t, "25.00", rows[6][10])
+		assert.Equal(t, "0.00", rows[6][12])
+	})
+
+	t.Run("日期格式错误", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WriteOrderExport(&buf, models.ExportOrdersRequest{Format: "csv", DateFrom: "2025/09/01"})
+
+		assert.Error(t, err)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestOrderService_WriteOrderExportBatches(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 超过单批数量的订单
+	require.NoError(t, seedExportOrders(db, 450))
+	orderService := services.NewOrderService(db, slog.Default())
+
+	t.Run("分批读取不遗漏不重复", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WriteOrderExport(&buf, models.ExportOrdersRequest{Format: "csv"})
+		require.NoError(t, err)
+
+		rows, err := csv.NewReader(bytes.NewReader(buf.Bytes()[3:])).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 1+450*3+2+1)
+
+		seen := make(map[string]bool)
+		for _, row := range rows[1 : len(rows)-1] {
+			if strings.HasPrefix(row[0], "ORD") && row[10] != "" {
+				assert.False(t, seen[row[0]], "订单 %s 重复导出", row[0])
+				seen[row[0]] = true
+			}
+		}
+		assert.Equal(t, 450, len(seen))
+		assert.Equal(t, "测试供应商A 小计", rows[1+225*3][0])
+		assert.Equal(t, "225 单", rows[1+225*3][4])
+		assert.Equal(t, "14175.00", rows[len(rows)-1][10])
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
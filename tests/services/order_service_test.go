@@ -0,0 +1,91 @@
+package services
+
+import (
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderService_ReceiveOrder(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 商品1保质期2天
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 1).Update("shelf_life", 2).Error)
+
+	// 创建服务实例
+	cartService := services.NewCartService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 3}, {ProductID: 2, Count: 2}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	orderID := orders[0].ID
+
+	t.Run("不能收订单以外的商品", func(t *testing.T) {
+		_, err := orderService.ReceiveOrder(orderID, models.ReceiveOrderRequest{
+			Items: []models.ReceiveItemRequest{{ProductID: 1, Quantity: 3}, {ProductID: 3, Quantity: 1}},
+		})
+
+		assert.ErrorIs(t, err, services.ErrReceiveItemNotInOrder)
+
+		inventory, err := inventoryService.GetInventory()
+		require.NoError(t, err)
+		assert.Empty(t, inventory)
+	})
+
+	t.Run("按订单数量全部收货", func(t *testing.T) {
+		response, err := orderService.ReceiveOrder(orderID, models.ReceiveOrderRequest{ReceivedBy: "仓管"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "completed", response.Order.Status)
+		require.Len(t, response.Batches, 2)
+
+		for _, batch := range response.Batches {
+			if batch.ProductID == 1 {
+				require.NotNil(t, batch.ExpiresAt)
+				assert.WithinDuration(t, time.Now().AddDate(0, 0, 2), *batch.ExpiresAt, time.Minute)
+			} else {
+				assert.Nil(t, batch.ExpiresAt) // 未设置保质期
+			}
+		}
+
+		inventory, err := inventoryService.GetInventory()
+		require.NoError(t, err)
+		require.Len(t, inventory, 2)
+		assert.Equal(t, 3.0, inventory[0].Quantity)
+		assert.Equal(t, 2.0, inventory[1].Quantity)
+	})
+
+	t.Run("重复收货", func(t *testing.T) {
+		_, err := orderService.ReceiveOrder(orderID, models.ReceiveOrderRequest{})
+
+		assert.ErrorIs(t, err, services.ErrOrderNotReceivable)
+	})
+
+	t.Run("不存在的订单", func(t *testing.T) {
+		_, err := orderService.ReceiveOrder("ORD_NOT_EXIST", models.ReceiveOrderRequest{})
+
+		assert.Error(t, err)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
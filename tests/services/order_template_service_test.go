@@ -0,0 +1,203 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTemplateService_StandingOrders(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	templateService := services.NewOrderTemplateService(db, slog.Default())
+	notificationService := services.NewNotificationService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+
+	template, err := templateService.CreateTemplate(models.OrderTemplateRequest{
+		Name:  "每日豆腐鸡蛋",
+		Notes: "早上7点前送到",
+		Items: []models.OrderItemRequest{
+			{ProductID: 1, Count: 10},
+			{ProductID: 2, Count: 2},
+			{ProductID: 3, Count: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, template.Items, 3)
+
+	t.Run("计划设置错误", func(t *testing.T) {
+		_, err := templateService.CreateStandingOrder(models.StandingOrderRequest{
+			TemplateID: template.ID,
+			Frequency:  "weekly",
+			RunAt:      "06:00",
+		})
+
+		assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+	})
+
+	// 每周一、三、五早上6点生成草稿订单，从2025-09-08（周一）开始
+	standingOrder, err := templateService.CreateStandingOrder(models.StandingOrderRequest{
+		TemplateID: template.ID,
+		Frequency:  "weekly",
+		Weekdays:   []int{1, 3, 5},
+		RunAt:      "06:00",
+		StartDate:  "2025-09-08",
+	})
+	require.NoError(t, err)
+
+	t.Run("到期时生成草稿订单并通知", func(t *testing.T) {
+		// 商品2已下架
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 2).Update("status", "discontinued").Error)
+
+		now := standingOrder.NextRunAt.Add(time.Minute)
+		results, err := templateService.RunDueStandingOrders(now)
+
+		assert.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Len(t, results[0].Orders, 2) // 按供应商拆分
+		assert.Equal(t, []string{"测试商品2"}, results[0].Skipped)
+		for _, order := range results[0].Orders {
+			assert.Equal(t, "draft", order.Status)
+			assert.Equal(t, "早上7点前送到", order.Notes)
+		}
+
+		notifications, err := notificationService.GetNotifications(true)
+		require.NoError(t, err)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "standing_order", notifications[0].Type)
+		assert.Contains(t, notifications[0].Content, "测试商品2")
+
+		// 下次生成时间推进到下一个计划日
+		updated, err := templateService.GetStandingOrder(standingOrder.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.NextRunAt.After(now))
+		assert.Contains(t, []time.Weekday{time.Monday, time.Wednesday, time.Friday}, updated.NextRunAt.Weekday())
+		assert.Equal(t, 6, updated.NextRunAt.Hour())
+
+		// 同一时间再次运行不会重复生成
+		results, err = templateService.RunDueStandingOrders(now)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("修改并提交草稿订单", func(t *testing.T) {
+		var orders []models.Order
+		require.NoError(t, db.Where("supplier = ?", "测试供应商A").Find(&orders).Error)
+		require.Len(t, orders, 1)
+
+		order, err := orderService.UpdateDraftOrderItems(orders[0].ID, models.UpdateOrderItemsRequest{
+			Items: []models.OrderItemRequest{{ProductID: 1, Count: 4}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 42.0, order.TotalPrice)
+
+		// 不能加入其他供应商的商品
+		_, err = orderService.UpdateDraftOrderItems(orders[0].ID, models.UpdateOrderItemsRequest{
+			Items: []models.OrderItemRequest{{ProductID: 3, Count: 1}},
+		})
+		assert.Error(t, err)
+
+		// 草稿不能直接改为待处理，必须经过提交时的审批和预算检查
+		err = orderService.UpdateOrderStatus(orders[0].ID, models.UpdateOrderStatusRequest{Status: "pending"})
+		assert.ErrorIs(t, err, services.ErrOrderStatusTransition)
+
+		submitted, err := orderService.SubmitDraftOrder(orders[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", submitted.Status)
+
+		_, err = orderService.SubmitDraftOrder(orders[0].ID)
+		assert.ErrorIs(t, err, services.ErrOrderNotDraft)
+	})
+
+	t.Run("单个常规订单失败不影响其他常规订单", func(t *testing.T) {
+		// 计划数据损坏的常规订单，无法计算下次生成时间
+		broken := models.StandingOrder{
+			TemplateID:  template.ID,
+			Frequency:   "yearly",
+			RunAt:       "06:00",
+			OrderStatus: "draft",
+			Active:      true,
+			NextRunAt:   standingOrder.NextRunAt,
+		}
+		require.NoError(t, db.Create(&broken).Error)
+
+		now := time.Now().AddDate(1, 0, 0)
+		results, err := templateService.RunDueStandingOrders(now)
+
+		assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+		assert.Contains(t, err.Error(), fmt.Sprintf("常规订单 %d", broken.ID))
+		require.Len(t, results, 2)
+		for _, result := range results {
+			if result.StandingOrderID == broken.ID {
+				assert.NotEmpty(t, result.Error)
+				assert.Empty(t, result.Orders)
+			} else {
+				assert.Empty(t, result.Error)
+				assert.NotEmpty(t, result.Orders)
+			}
+		}
+
+		// 失败的常规订单跳过本次，无法计算下次计划时间时延后一天重试
+		var reloaded models.StandingOrder
+		require.NoError(t, db.First(&reloaded, broken.ID).Error)
+		assert.WithinDuration(t, now.Add(24*time.Hour), reloaded.NextRunAt, time.Second)
+		assert.Nil(t, reloaded.LastRunAt)
+
+		// 通知采购员生成失败
+		var failed []models.Notification
+		require.NoError(t, db.Where("type = ?", "standing_order_failed").Find(&failed).Error)
+		require.Len(t, failed, 1)
+		assert.Equal(t, fmt.Sprint(broken.ID), failed[0].Reference)
+		assert.Equal(t, template.UserID, failed[0].UserID)
+		assert.Contains(t, failed[0].Content, "每日豆腐鸡蛋")
+
+		// 同一时间再次检查不会重复失败
+		results, err = templateService.RunDueStandingOrders(now)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("所有商品都已下架时不生成订单也不通知", func(t *testing.T) {
+		discontinued, err := templateService.CreateTemplate(models.OrderTemplateRequest{
+			Name:  "已下架商品",
+			Items: []models.OrderItemRequest{{ProductID: 2, Count: 1}},
+		})
+		require.NoError(t, err)
+		standing, err := templateService.CreateStandingOrder(models.StandingOrderRequest{
+			TemplateID: discontinued.ID,
+			Frequency:  "daily",
+			RunAt:      "06:00",
+		})
+		require.NoError(t, err)
+
+		var before int64
+		require.NoError(t, db.Model(&models.Notification{}).Count(&before).Error)
+
+		result, err := templateService.RunStandingOrder(standing.ID)
+		require.NoError(t, err)
+		assert.Empty(t, result.Orders)
+		assert.Equal(t, []string{"测试商品2"}, result.Skipped)
+
+		var after int64
+		require.NoError(t, db.Model(&models.Notification{}).Count(&after).Error)
+		assert.Equal(t, before, after)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
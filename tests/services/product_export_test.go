@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestProductService_WriteProductExport(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	productService := services.NewProductService(db, slog.Default())
+
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 1).Updates(map[string]interface{}{
+		"external_id": "A-1", "category": "干货", "shelf_life": 30,
+	}).Error)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 3).Update("status", "unavailable").Error)
+
+	t.Run("JSON 与 products.json 格式一致", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := productService.WriteProductExport(&buf, models.ExportProductsRequest{})
+		require.NoError(t, err)
+
+		var items []map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &items))
+		require.Len(t, items, 3)
+		assert.Equal(t, float64(1), items[0]["id"])
+		assert.Equal(t, "A-1", items[0]["externalId"])
+		assert.Equal(t, "测试商品1", items[0]["name"])
+		assert.Equal(t, 10.5, items[0]["price"])
+		assert.Equal(t, "干货", items[0]["category"])
+		assert.Equal(t, float64(30), items[0]["shelfLife"])
+		assert.Equal(t, "unavailable", items[2]["status"])
+
+		// 导出的 JSON 可直接作为导入数据，重新导入不产生变化
+		var products []models.ImportProduct
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &products))
+		summary, err := productService.SyncProducts(products, true, true)
+		require.NoError(t, err)
+		assert.Equal(t, 3, summary.Unchanged)
+		assert.Empty(t, summary.Changes)
+	})
+
+	t.Run("按商品列表条件筛选", func(t *testing.T) {
+		items, err := productService.ExportProducts(models.ExportProductsRequest{Supplier: "测试供应商A", Status: "available"})
+
+		assert.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "测试商品2", items[1].Name)
+	})
+
+	t.Run("CSV 修改后重新导入", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := productService.WriteProductExport(&buf, models.ExportProductsRequest{Format: "csv"})
+		require.NoError(t, err)
+
+		content := buf.String()
+		assert.True(t, strings.HasPrefix(content, "\xEF\xBB\xBF编号,商品名称,价格,单位,描述,供应商,分类,保质期,状态\n"))
+		assert.Contains(t, content, "A-1,测试商品1,10.50,个,测试商品描述1,测试供应商A,干货,30,available\n")
+
+		// 原样导入没有变化
+		report, err := productService.ImportProductsFile(strings.NewReader(content), "csv", nil, nil, true, true)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, 3, report.Summary.Unchanged)
+
+		// 采购员离线改价后导入
+		edited := strings.Replace(content, "测试商品2,25.00", "测试商品2,26.00", 1)
+		report, err = productService.ImportProductsFile(strings.NewReader(edited), "csv", nil, nil, false, true)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Summary.Updated)
+		assert.Equal(t, 2, report.Summary.Unchanged)
+
+		product, err := productService.GetProductByID(2)
+		require.NoError(t, err)
+		assert.Equal(t, 26.0, product.Price)
+	})
+
+	t.Run("Excel 重新导入没有变化", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := productService.WriteProductExport(&buf, models.ExportProductsRequest{Format: "excel"})
+		require.NoError(t, err)
+
+		file, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		rows, err := file.GetRows("商品目录")
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, "unavailable", rows[3][8])
+
+		report, err := productService.ImportProductsFile(bytes.NewReader(buf.Bytes()), "xlsx", nil, nil, true, true)
+		require.NoError(t, err)
+		assert.Empty(t, report.Errors)
+		assert.Equal(t, 3, report.Summary.Unchanged)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestProductService_ImportProductsFile(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	productService := services.NewProductService(db, slog.Default())
+
+	countProducts := func() int64 {
+		var count int64
+		db.Model(&models.Product{}).Count(&count)
+		return count
+	}
+
+	// 采购员的价目表：供应商列叫"档口名"
+	priceList := "\xEF\xBB\xBF品名,单价,单位,处理要求,档口名\n" +
+		"牛蛙,31,斤,去掉内脏,F35\n" +
+		"草鱼,abc,斤,,快驴\n" +
+		"鲫鱼,15,吨,,华兴街14号\n" +
+		"鸡翅,-2,斤,,\n" +
+		"\n" +
+		"牛蛙,32,斤,,F35\n"
+	mapping := map[string]string{"supplier": "档口名"}
+	// 已确认新建的供应商
+	newSuppliers := []string{"F35", "快驴", "华兴街14号"}
+
+	t.Run("试运行返回逐行校验报告", func(t *testing.T) {
+		report, err := productService.ImportProductsFile(strings.NewReader(priceList), "csv", mapping, newSuppliers, true, false)
+
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 5, report.TotalRows)
+		assert.Equal(t, 1, report.ValidRows)
+		require.Len(t, report.Errors, 5)
+
+		assert.Equal(t, models.ProductImportRowError{Row: 3, Field: "price", Value: "abc", Message: "价格格式错误"}, report.Errors[0])
+		assert.Equal(t, "未知单位", report.Errors[1].Message)
+		assert.Equal(t, 4, report.Errors[1].Row)
+		assert.Equal(t, "价格必须大于0", report.Errors[2].Message)
+		assert.Equal(t, "缺少供应商", report.Errors[3].Message)
+		assert.Equal(t, 7, report.Errors[4].Row) // 跳过空行后行号不变
+		assert.Equal(t, "与第 2 行重复", report.Errors[4].Message)
+
+		assert.Equal(t, int64(3), countProducts())
+	})
+
+	t.Run("有错误时不写入", func(t *testing.T) {
+		report, err := productService.ImportProductsFile(strings.NewReader(priceList), "csv", mapping, newSuppliers, false, false)
+
+		assert.ErrorIs(t, err, services.ErrImportInvalid)
+		assert.Len(t, report.Errors, 5)
+		assert.Equal(t, int64(3), countProducts())
+	})
+
+	t.Run("未确认的新供应商作为行错误报告", func(t *testing.T) {
+		valid := "品名,单价,单位,处理要求,档口名\n牛蛙,31,斤,去掉内脏,F35\n草鱼,12,斤,切块,快驴\n鸡蛋,0.8,个,,测试供应商A\n"
+
+		report, err := productService.ImportProductsFile(strings.NewReader(valid), "csv", mapping, []string{"F35"}, true, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"F35", "快驴"}, report.NewSuppliers)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, models.ProductImportRowError{Row: 3, Field: "supplier", Value: "快驴", Message: "未知供应商，确认新增后重新提交"}, report.Errors[0])
+		assert.Nil(t, report.Summary)
+
+		var count int64
+		db.Model(&models.Supplier{}).Where("name IN ?", []string{"F35", "快驴"}).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("CSV 导入并创建供应商", func(t *testing.T) {
+		valid := "品名,单价,单位,处理要求,档口名\n牛蛙,￥31.00,斤,去掉内脏,F35\n草鱼,12,斤,切块,快驴\n"
+
+		report, err := productService.ImportProductsFile(strings.NewReader(valid), "csv", mapping, newSuppliers, false, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, int64(5), countProducts())
+		assert.Equal(t, []string{"F35", "快驴"}, report.Summary.NewSuppliers)
+
+		var supplier models.Supplier
+		assert.NoError(t, db.First(&supplier, "name = ?", "F35").Error)
+
+		var product models.Product
+		require.NoError(t, db.First(&product, "name = ?", "牛蛙").Error)
+		assert.Equal(t, 31.0, product.Price)
+		assert.Equal(t, "去掉内脏", product.Description)
+	})
+
+	t.Run("Excel 导入", func(t *testing.T) {
+		file := excelize.NewFile()
+		rows := [][]interface{}{
+			{"商品名称", "价格", "单位", "供应商", "分类", "保质期"},
+			{"鸡蛋", 0.8, "个", "测试供应商A", "蛋类", 14},
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			require.NoError(t, file.SetSheetRow("Sheet1", cell, &row))
+		}
+		var buf bytes.Buffer
+		require.NoError(t, file.Write(&buf))
+
+		report, err := productService.ImportProductsFile(&buf, "xlsx", nil, nil, false, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+
+		var product models.Product
+		require.NoError(t, db.First(&product, "name = ?", "鸡蛋").Error)
+		assert.Equal(t, "蛋类", product.Category)
+		assert.Equal(t, 14, product.ShelfLife)
+	})
+
+	t.Run("文件中没有的可选列不清空已有内容", func(t *testing.T) {
+		// 只有必需列的价目表
+		priceOnly := "品名,单价,单位,供应商\n鸡蛋,0.9,个,测试供应商A\n"
+
+		report, err := productService.ImportProductsFile(strings.NewReader(priceOnly), "csv", nil, nil, false, false)
+
+		require.NoError(t, err)
+		require.Len(t, report.Summary.Changes, 1)
+		assert.Equal(t, []string{"price"}, report.Summary.Changes[0].Fields)
+
+		var product models.Product
+		require.NoError(t, db.First(&product, "name = ?", "鸡蛋").Error)
+		assert.Equal(t, 0.9, product.Price)
+		assert.Equal(t, "蛋类", product.Category)
+		assert.Equal(t, 14, product.ShelfLife)
+	})
+
+	t.Run("缺少必需的列", func(t *testing.T) {
+		_, err := productService.ImportProductsFile(strings.NewReader("品名,单价\n牛蛙,31\n"), "csv", nil, nil, true, false)
+
+		assert.ErrorIs(t, err, services.ErrImportColumnsMissing)
+	})
+
+	t.Run("映射的列不存在", func(t *testing.T) {
+		_, err := productService.ImportProductsFile(strings.NewReader(priceList), "csv", map[string]string{"price": "进价"}, nil, true, false)
+
+		assert.ErrorIs(t, err, services.ErrImportColumnsMissing)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
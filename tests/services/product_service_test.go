@@ -1,6 +1,7 @@
 package services
 
 import (
+	"log/slog"
 	"purches-backend/models"
 	"purches-backend/services"
 	"purches-backend/tests/testdata"
@@ -20,7 +21,7 @@ func TestProductService_GetProducts(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	productService := services.NewProductService(db)
+	productService := services.NewProductService(db, slog.Default())
 
 	t.Run("获取所有商品", func(t *testing.T) {
 		req := models.ProductListRequest{
@@ -118,7 +119,7 @@ func TestProductService_GetProductByID(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	productService := services.NewProductService(db)
+	productService := services.NewProductService(db, slog.Default())
 
 	t.Run("获取存在的商品", func(t *testing.T) {
 		product, err := productService.GetProductByID(1)
@@ -152,7 +153,7 @@ func TestProductService_ImportProducts(t *testing.T) {
 	require.NoError(t, err)
 
 	// 创建服务实例
-	productService := services.NewProductService(db)
+	productService := services.NewProductService(db, slog.Default())
 
 	t.Run("导入新商品", func(t *testing.T) {
 		importProducts := []models.ImportProduct{
@@ -160,14 +161,14 @@ func TestProductService_ImportProducts(t *testing.T) {
 				Name:        "导入测试商品1",
 				Price:       20.00,
 				Unit:        "盒",
-				Description: "导入的测试商品描述1",
+				Description: testdata.Ptr("导入的测试商品描述1"),
 				Supplier:    "测试供应商A",
 			},
 			{
 				Name:        "导入测试商品2",
 				Price:       30.50,
 				Unit:        "袋",
-				Description: "导入的测试商品描述2",
+				Description: testdata.Ptr("导入的测试商品描述2"),
 				Supplier:    "测试供应商B",
 			},
 		}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductService_SyncProducts(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	productService := services.NewProductService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+
+	// 下单用户
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+
+	// 购物车和历史订单引用现有商品
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 2, Count: 1}},
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	_, err = cartService.AddToCart(models.AddToCartRequest{ProductID: 1, Count: 2})
+	require.NoError(t, err)
+
+	// 新目录：商品1涨价，商品2不变，商品3不在目录中，新增商品4
+	catalogue := []models.ImportProduct{
+		{ExternalID: "A-1", Name: "测试商品1", Price: 11.00, Unit: "个", Description: testdata.Ptr("测试商品描述1"), Supplier: "测试供应商A"},
+		{ExternalID: "A-2", Name: "测试商品2", Price: 25.00, Unit: "斤", Description: testdata.Ptr("测试商品描述2"), Supplier: "测试供应商A"},
+		{ExternalID: "C-1", Name: "测试商品4", Price: 6.00, Unit: "包", Supplier: "测试供应商C"},
+	}
+
+	t.Run("试运行只返回差异不写入", func(t *testing.T) {
+		summary, err := productService.SyncProducts(catalogue, true, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.Added)
+		assert.Equal(t, 2, summary.Updated) // 首次同步补上外部编号
+		assert.Equal(t, 1, summary.Discontinued)
+
+		var count int64
+		db.Model(&models.Product{}).Count(&count)
+		assert.Equal(t, int64(3), count)
+		db.Model(&models.Supplier{}).Where("name = ?", "测试供应商C").Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("原地更新并保留商品ID", func(t *testing.T) {
+		summary, err := productService.SyncProducts(catalogue, true, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.Added)
+		assert.Equal(t, 2, summary.Updated)
+		assert.Equal(t, 1, summary.Discontinued)
+		assert.Equal(t, 0, summary.Unchanged)
+
+		product, err := productService.GetProductByID(1)
+		require.NoError(t, err)
+		assert.Equal(t, 11.00, product.Price)
+		assert.Equal(t, "A-1", product.ExternalID)
+
+		discontinued, err := productService.GetProductByID(3)
+		require.NoError(t, err)
+		assert.Equal(t, "discontinued", discontinued.Status)
+
+		// 购物车和订单仍指向原商品
+		cart, err := cartService.GetCart()
+		require.NoError(t, err)
+		require.Len(t, cart.Items, 1)
+		assert.Equal(t, 1, cart.Items[0].ProductID)
+
+		order, err := orderService.GetOrderByID(orders[0].ID)
+		require.NoError(t, err)
+		require.Len(t, order.Products, 1)
+		assert.Equal(t, 2, order.Products[0].ProductID)
+	})
+
+	t.Run("按外部编号匹配改名商品", func(t *testing.T) {
+		renamed := append([]models.ImportProduct{}, catalogue...)
+		renamed[1].Name = "测试商品2（精选）"
+		renamed = append(renamed, models.ImportProduct{
+			ExternalID: "B-3", Name: "测试商品3", Price: 8.80, Unit: "包", Description: testdata.Ptr("测试商品描述3"), Supplier: "测试供应商B",
+		})
+
+		summary, err := productService.SyncProducts(renamed, true, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, summary.Added)
+		assert.Equal(t, 2, summary.Updated)
+		assert.Equal(t, 0, summary.Discontinued)
+		assert.Equal(t, 2, summary.Unchanged)
+		require.Len(t, summary.Changes, 2)
+		assert.Equal(t, []string{"name"}, summary.Changes[0].Fields)
+
+		// 重新出现在目录中的停售商品恢复上架
+		assert.Equal(t, 3, summary.Changes[1].ProductID)
+		assert.Contains(t, summary.Changes[1].Fields, "status")
+		product, err := productService.GetProductByID(3)
+		require.NoError(t, err)
+		assert.Equal(t, "available", product.Status)
+
+		var count int64
+		db.Model(&models.Product{}).Count(&count)
+		assert.Equal(t, int64(4), count)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
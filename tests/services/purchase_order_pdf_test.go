@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// pdfText 文字在 PDF 内容流中的编码形式
+func pdfText(text string) string {
+	var sb strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&sb, "%04X", unit)
+	}
+	return sb.String()
+}
+
+func TestOrderService_PurchaseOrderPDF(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	orderService := services.NewOrderService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", 1).Update("description", "杀好去掉内脏").Error)
+
+	orders, err := orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 2}, {ProductID: 3, Count: 1}},
+		Notes: "早上8点前送到",
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+
+	t.Run("单个订单采购单", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WritePurchaseOrderPDF(&buf, orders[0].ID)
+		require.NoError(t, err)
+
+		content := buf.String()
+		assert.True(t, strings.HasPrefix(content, "%PDF-"))
+		assert.Contains(t, content, "/Count 1")
+		assert.Contains(t, content, pdfText("供应商：测试供应商A"))
+		assert.Contains(t, content, pdfText("联系人：张三    电话：13800000001"))
+		assert.Contains(t, content, pdfText("杀好去掉内脏"))
+		assert.Contains(t, content, pdfText("备注：早上8点前送到"))
+		assert.Contains(t, content, pdfText("合计：￥21.00"))
+	})
+
+	t.Run("商品较多时分页", func(t *testing.T) {
+		items := make([]models.OrderItemRequest, 0, 30)
+		for i := 0; i < 30; i++ {
+			product := models.Product{Name: fmt.Sprintf("批量商品%d", i), Price: 1, Unit: "个", Supplier: "测试供应商B", Status: "available", Description: "切丝"}
+			require.NoError(t, db.Create(&product).Error)
+			items = append(items, models.OrderItemRequest{ProductID: product.ID, Count: 1})
+		}
+		many, err := orderService.CreateOrder(models.CreateOrderRequest{Items: items})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, orderService.WritePurchaseOrderPDF(&buf, many[0].ID))
+
+		assert.Contains(t, buf.String(), "/Count 2")
+		assert.Contains(t, buf.String(), pdfText("（续 第2页）"))
+	})
+
+	t.Run("每日采购单合集", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WriteDailyPurchaseOrdersPDF(&buf, models.DailyPurchaseOrdersRequest{Date: time.Now().Format("2006-01-02")})
+		require.NoError(t, err)
+
+		content := buf.String()
+		assert.Contains(t, content, "/Count 5") // 汇总页 + 3个订单（其中一个2页）
+		assert.Contains(t, content, pdfText("每日采购汇总"))
+		assert.Contains(t, content, pdfText("测试供应商B"))
+	})
+
+	t.Run("不存在的订单", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := orderService.WritePurchaseOrderPDF(&buf, "ORD_NOT_EXIST")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
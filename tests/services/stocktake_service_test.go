@@ -0,0 +1,154 @@
+package services
+
+import (
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStocktakeService_Workflow(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	stocktakeService := services.NewStocktakeService(db, slog.Default())
+	inventoryService := services.NewInventoryService(db, slog.Default())
+	cartService := services.NewCartService(db, slog.Default())
+	orderService := services.NewOrderService(db, slog.Default())
+
+	// 账面库存：商品1 10个，商品2 4斤
+	_, err = inventoryService.SetOnHand(1, 10)
+	require.NoError(t, err)
+	_, err = inventoryService.SetOnHand(2, 4)
+	require.NoError(t, err)
+
+	// 最近一次采购：商品1 单价10.50
+	_, err = cartService.GetCart()
+	require.NoError(t, err)
+	_, err = orderService.CreateOrder(models.CreateOrderRequest{
+		Items: []models.OrderItemRequest{{ProductID: 1, Count: 1}},
+	})
+	require.NoError(t, err)
+
+	session, err := stocktakeService.CreateSession(models.CreateStocktakeRequest{CreatedBy: "厨师长"})
+	require.NoError(t, err)
+
+	t.Run("同时只能有一个进行中的盘点", func(t *testing.T) {
+		_, err := stocktakeService.CreateSession(models.CreateStocktakeRequest{})
+
+		assert.ErrorIs(t, err, services.ErrStocktakeInProgress)
+	})
+
+	t.Run("多人提交盘点数量", func(t *testing.T) {
+		_, err := stocktakeService.SubmitCounts(session.ID, models.SubmitStocktakeCountsRequest{
+			CountedBy: "张三",
+			Counts:    []models.StocktakeCountRequest{{ProductID: 1, Quantity: 5}, {ProductID: 2, Quantity: 9}},
+		})
+		require.NoError(t, err)
+
+		// 李四盘点冷库中的商品1，先录错再修正
+		_, err = stocktakeService.SubmitCounts(session.ID, models.SubmitStocktakeCountsRequest{
+			CountedBy: "李四",
+			Counts:    []models.StocktakeCountRequest{{ProductID: 1, Quantity: 30}},
+		})
+		require.NoError(t, err)
+		updated, err := stocktakeService.SubmitCounts(session.ID, models.SubmitStocktakeCountsRequest{
+			CountedBy: "李四",
+			Counts:    []models.StocktakeCountRequest{{ProductID: 1, Quantity: 3}},
+		})
+
+		require.NoError(t, err)
+
+		// 王五复盘商品2，数量与张三一致
+		updated, err = stocktakeService.SubmitCounts(session.ID, models.SubmitStocktakeCountsRequest{
+			CountedBy: "王五",
+			Counts:    []models.StocktakeCountRequest{{ProductID: 2, Quantity: 9}},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, updated.Counts, 4)
+	})
+
+	t.Run("盘点差异按最近采购价计算金额", func(t *testing.T) {
+		report, err := stocktakeService.GetVariances(session.ID)
+
+		assert.NoError(t, err)
+		require.Len(t, report.Items, 2)
+
+		// 两人数量不一致：取最近一次提交的数量，不相加，并标记需要复核
+		item1 := report.Items[0]
+		assert.Equal(t, 10.0, item1.Expected)
+		assert.Equal(t, 3.0, item1.Counted)
+		assert.Equal(t, -7.0, item1.Variance)
+		assert.Equal(t, 10.50, item1.UnitPrice)
+		assert.Equal(t, -73.5, item1.VarianceValue)
+		assert.True(t, item1.Disputed)
+		assert.Equal(t, []string{"张三", "李四"}, item1.CountedBy)
+		assert.Equal(t, map[string]float64{"张三": 5, "李四": 3}, item1.Counts)
+
+		// 两人数量一致
+		item2 := report.Items[1]
+		assert.Equal(t, 9.0, item2.Counted)
+		assert.Equal(t, 5.0, item2.Variance)
+		assert.Equal(t, 25.00, item2.UnitPrice) // 无采购记录，使用商品价格
+		assert.False(t, item2.Disputed)
+		assert.Equal(t, 51.5, report.TotalVarianceValue)
+	})
+
+	t.Run("有数量不一致的商品时不能结束", func(t *testing.T) {
+		_, err := stocktakeService.CloseSession(session.ID, models.CloseStocktakeRequest{ClosedBy: "老板"})
+
+		assert.ErrorIs(t, err, services.ErrStocktakeDisputed)
+	})
+
+	t.Run("结束盘点后调整库存", func(t *testing.T) {
+		// 盘点后又入库2个，差异仍按提交时的账面库存计算，入库的数量保留
+		_, err := inventoryService.SetOnHand(1, 12)
+		require.NoError(t, err)
+
+		report, err := stocktakeService.CloseSession(session.ID, models.CloseStocktakeRequest{ClosedBy: "老板", Force: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "closed", report.Session.Status)
+		require.Len(t, report.Items, 2)
+		assert.Equal(t, 10.0, report.Items[0].Expected)
+		assert.Equal(t, -7.0, report.Items[0].Variance)
+
+		inventory, err := inventoryService.GetInventory()
+		require.NoError(t, err)
+		quantities := make(map[int]float64)
+		for _, item := range inventory {
+			quantities[item.ProductID] = item.Quantity
+		}
+		assert.Equal(t, 5.0, quantities[1])
+		assert.Equal(t, 9.0, quantities[2])
+
+		movements, err := inventoryService.GetMovements(1)
+		require.NoError(t, err)
+		assert.Equal(t, "stocktake", movements[0].Type)
+		assert.Equal(t, -7.0, movements[0].Quantity)
+	})
+
+	t.Run("已结束的盘点不能再提交", func(t *testing.T) {
+		_, err := stocktakeService.SubmitCounts(session.ID, models.SubmitStocktakeCountsRequest{
+			CountedBy: "张三",
+			Counts:    []models.StocktakeCountRequest{{ProductID: 1, Quantity: 1}},
+		})
+
+		assert.ErrorIs(t, err, services.ErrStocktakeClosed)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"purches-backend/models"
+	"purches-backend/services"
+	"purches-backend/tests/testdata"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWasteService_LogWaste(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	wasteService := services.NewWasteService(db, slog.Default())
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	_, err = inventoryService.SetOnHand(2, 10)
+	require.NoError(t, err)
+
+	t.Run("报损扣减库存并按采购价计算成本", func(t *testing.T) {
+		record, err := wasteService.LogWaste(models.CreateWasteRequest{
+			ProductID: 2,
+			Quantity:  1.5,
+			Reason:    "spoiled",
+			PhotoRef:  "waste/20250910-001.jpg",
+			LoggedBy:  "厨师长",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "测试供应商A", record.Supplier)
+		assert.Equal(t, 25.00, record.UnitCost)
+		assert.Equal(t, 37.5, record.TotalCost)
+
+		inventory, err := inventoryService.GetInventory()
+		require.NoError(t, err)
+		assert.Equal(t, 8.5, inventory[0].Quantity)
+	})
+
+	t.Run("报损数量超过库存", func(t *testing.T) {
+		_, err := wasteService.LogWaste(models.CreateWasteRequest{
+			ProductID: 2,
+			Quantity:  9,
+			Reason:    "spoiled",
+			LoggedBy:  "厨师长",
+		})
+
+		assert.ErrorIs(t, err, services.ErrInsufficientStock)
+
+		var count int64
+		db.Model(&models.WasteRecord{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("不存在的商品", func(t *testing.T) {
+		_, err := wasteService.LogWaste(models.CreateWasteRequest{
+			ProductID: 999,
+			Quantity:  1,
+			Reason:    "damaged",
+			LoggedBy:  "厨师长",
+		})
+
+		assert.Error(t, err)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
+
+func TestWasteService_GetWasteReport(t *testing.T) {
+	// 设置测试数据库
+	db, err := testdata.SetupTestDB()
+	require.NoError(t, err)
+
+	// 创建测试数据
+	err = testdata.SeedTestData(db)
+	require.NoError(t, err)
+
+	// 创建服务实例
+	wasteService := services.NewWasteService(db, slog.Default())
+	inventoryService := services.NewInventoryService(db, slog.Default())
+
+	// 报损不能超过现有库存
+	for productID := 1; productID <= 3; productID++ {
+		_, err := inventoryService.SetOnHand(productID, 10)
+		require.NoError(t, err)
+	}
+
+	// 本周与上周各报损若干
+	thisWeek := time.Now()
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	entries := []struct {
+		productID int
+		quantity  float64
+		loggedAt  time.Time
+	}{
+		{1, 2, thisWeek}, // 测试供应商A 10.50 * 2 = 21
+		{2, 1, lastWeek}, // 测试供应商A 25 * 1 = 25
+		{3, 5, thisWeek}, // 测试供应商B 8.80 * 5 = 44
+		{1, 1, lastWeek}, // 测试供应商A 10.50 * 1 = 10.5
+	}
+	for _, entry := range entries {
+		record, err := wasteService.LogWaste(models.CreateWasteRequest{
+			ProductID: entry.productID,
+			Quantity:  entry.quantity,
+			Reason:    "spoiled",
+			LoggedBy:  "厨师长",
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.Model(record).Update("created_at", entry.loggedAt).Error)
+	}
+
+	t.Run("按商品汇总", func(t *testing.T) {
+		report, err := wasteService.GetWasteReport(models.WasteReportRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "product", report.GroupBy)
+		require.Len(t, report.Rows, 3)
+		assert.Equal(t, "测试商品3", report.Rows[0].Name) // 成本最高的排在前面
+		assert.Equal(t, 3.0, report.Rows[1].Quantity)
+		assert.InDelta(t, 100.5, report.TotalCost, 0.001)
+	})
+
+	t.Run("按供应商汇总", func(t *testing.T) {
+		report, err := wasteService.GetWasteReport(models.WasteReportRequest{GroupBy: "supplier"})
+
+		assert.NoError(t, err)
+		require.Len(t, report.Rows, 2)
+		assert.Equal(t, "测试供应商A", report.Rows[0].Key)
+		assert.InDelta(t, 56.5, report.Rows[0].TotalCost, 0.001)
+		assert.Equal(t, 3, report.Rows[0].RecordCount)
+	})
+
+	t.Run("按周汇总", func(t *testing.T) {
+		report, err := wasteService.GetWasteReport(models.WasteReportRequest{GroupBy: "week"})
+
+		assert.NoError(t, err)
+		require.Len(t, report.Rows, 2)
+		year, week := thisWeek.ISOWeek()
+		assert.Equal(t, fmt.Sprintf("%d-W%02d", year, week), report.Rows[1].Key)
+		assert.InDelta(t, 65.0, report.Rows[1].TotalCost, 0.001)
+	})
+
+	t.Run("按日期筛选", func(t *testing.T) {
+		report, err := wasteService.GetWasteReport(models.WasteReportRequest{
+			GroupBy:  "supplier",
+			DateFrom: thisWeek.Format("2006-01-02"),
+			DateTo:   thisWeek.Format("2006-01-02"),
+		})
+
+		assert.NoError(t, err)
+		assert.InDelta(t, 65.0, report.TotalCost, 0.001)
+	})
+
+	t.Run("日期格式错误", func(t *testing.T) {
+		_, err := wasteService.GetWasteReport(models.WasteReportRequest{DateFrom: "2025/09/01"})
+		assert.ErrorIs(t, err, services.ErrInvalidDate)
+
+		_, err = wasteService.GetWasteRecords(models.WasteListRequest{DateTo: "2025-13-01"})
+		assert.ErrorIs(t, err, services.ErrInvalidDate)
+	})
+
+	// 清理测试数据
+	err = testdata.CleanupTestDB(db)
+	require.NoError(t, err)
+}
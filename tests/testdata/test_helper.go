@@ -27,6 +27,20 @@ func SetupTestDB() (*gorm.DB, error) {
 		&models.CartItem{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.Inventory{},
+		&models.ParLevel{},
+		&models.StockMovement{},
+		&models.StockBatch{},
+		&models.WasteRecord{},
+		&models.StocktakeSession{},
+		&models.StocktakeCount{},
+		&models.OrderTemplate{},
+		&models.OrderTemplateItem{},
+		&models.StandingOrder{},
+		&models.Notification{},
+		&models.ApprovalRule{},
+		&models.OrderApproval{},
+		&models.Budget{},
 	)
 	if err != nil {
 		return nil, err
@@ -82,6 +96,18 @@ func SeedTestData(db *gorm.DB) error {
 func CleanupTestDB(db *gorm.DB) error {
 	// 删除所有表的数据
 	tables := []interface{}{
+		&models.Budget{},
+		&models.OrderApproval{},
+		&models.ApprovalRule{},
+		&models.Notification{},
+		&models.StandingOrder{},
+		&models.OrderTemplateItem{},
+		&models.OrderTemplate{},
+		&models.StocktakeCount{},
+		&models.StocktakeSession{},
+		&models.StockMovement{},
+		&models.ParLevel{},
+		&models.Inventory{},
 		&models.OrderItem{},
 		&models.Order{},
 		&models.CartItem{},
@@ -117,3 +143,8 @@ func GetTestProductData() models.Product {
 func GetTestUserID() uint {
 	return 1 // 通常测试中第一个创建的用户ID是1
 }
+
+// Ptr 返回值的指针，用于填写可选字段
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net/http"
+	"purches-backend/logging"
 	"purches-backend/models"
 	"time"
 
@@ -15,6 +16,7 @@ func ResponseOK(c *gin.Context, message string, data interface{}) {
 		Message:   message,
 		Data:      data,
 		Timestamp: time.Now(),
+		RequestID: requestID(c),
 	})
 }
 
@@ -25,5 +27,25 @@ func ResponseError(c *gin.Context, code int, message string, err string) {
 		Message:   message,
 		Data:      err,
 		Timestamp: time.Now(),
+		RequestID: requestID(c),
 	})
 }
+
+// ResponseErrorData 生成带详细数据的错误响应，如导入校验报告
+func ResponseErrorData(c *gin.Context, code int, message string, data interface{}) {
+	c.JSON(code, models.APIResponse{
+		Code:      code,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now(),
+		RequestID: requestID(c),
+	})
+}
+
+// requestID 当前请求的请求 ID，由 RequestID 中间件写入请求 context
+func requestID(c *gin.Context) string {
+	if c.Request == nil {
+		return ""
+	}
+	return logging.FieldsFrom(c.Request.Context()).RequestID
+}